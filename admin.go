@@ -0,0 +1,288 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// adminTimeout bounds how long an admin request waits on the main loop.
+const adminTimeout = 5 * time.Second
+
+// adminOp identifies a request the admin server hands to the main loop.
+type adminOp int
+
+const (
+	adminPing adminOp = iota
+	adminReady
+	adminCollect
+	adminReload
+)
+
+// adminCommand is a request from the admin server to the main loop, which
+// owns the config and the collector schedule. The reply channel is buffered
+// so the loop never blocks on a handler that has given up.
+type adminCommand struct {
+	op    adminOp
+	arg   string
+	reply chan adminReply
+}
+
+type adminReply struct {
+	status  int
+	reasons []string
+	err     error
+}
+
+// ReadyStatus is the body of the admin server's /readyz response.
+type ReadyStatus struct {
+	Ready   bool     `json:"ready"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// AdminStats is the body of the admin server's /stats response.
+type AdminStats struct {
+	Name       string               `json:"name"`
+	Polls      PollStats            `json:"polls"`
+	Collectors map[string]PollStats `json:"collectors"`
+	Pipeline   PipelineStats        `json:"pipeline"`
+	Backends   []BackendStats       `json:"backends"`
+}
+
+// adminServer serves the admin endpoints for a running Monitor.
+type adminServer struct {
+	m      *Monitor
+	server *http.Server
+}
+
+// startAdmin starts the admin HTTP server. Requests that touch the config or
+// schedule are forwarded to the main loop through m.adminCh.
+func (m *Monitor) startAdmin(cfg AdminConfig) (*adminServer, error) {
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start admin server: %w", err)
+	}
+
+	m.adminCh = make(chan adminCommand)
+	a := &adminServer{m: m}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", a.handleHealth)
+	mux.HandleFunc("GET /readyz", a.handleReady)
+	mux.HandleFunc("GET /stats", a.handleStats)
+	mux.HandleFunc("POST /collect", a.handleCollect)
+	mux.HandleFunc("POST /reload", a.handleReload)
+	mux.HandleFunc("POST /loglevel", a.handleLogLevel)
+
+	a.server = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		m.logger.Info("starting admin server", "addr", ln.Addr().String())
+		if err := a.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			m.logger.Error("admin server error", "error", err)
+		}
+	}()
+
+	return a, nil
+}
+
+func (a *adminServer) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.m.logger.Error("error shutting down admin server", "error", err)
+	}
+}
+
+// send hands a command to the main loop and waits for its reply.
+func (a *adminServer) send(ctx context.Context, op adminOp, arg string) adminReply {
+	ctx, cancel := context.WithTimeout(ctx, adminTimeout)
+	defer cancel()
+
+	cmd := adminCommand{op: op, arg: arg, reply: make(chan adminReply, 1)}
+	select {
+	case a.m.adminCh <- cmd:
+	case <-ctx.Done():
+		return adminReply{status: http.StatusServiceUnavailable, err: errors.New("main loop not responding")}
+	}
+	select {
+	case r := <-cmd.reply:
+		return r
+	case <-ctx.Done():
+		return adminReply{status: http.StatusServiceUnavailable, err: errors.New("main loop not responding")}
+	}
+}
+
+// handleHealth reports liveness: the main loop is still answering.
+func (a *adminServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if reply := a.send(r.Context(), adminPing, ""); reply.err != nil {
+		writeJSONError(w, reply.status, reply.err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady reports readiness: no collector is failing repeatedly and
+// every backend is healthy with a closed circuit.
+func (a *adminServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	reply := a.send(r.Context(), adminReady, "")
+	if reply.err != nil {
+		writeJSONError(w, reply.status, reply.err)
+		return
+	}
+	status := ReadyStatus{Ready: len(reply.reasons) == 0, Reasons: reply.reasons}
+	code := http.StatusOK
+	if !status.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, status)
+}
+
+func (a *adminServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	m := a.m
+	writeJSON(w, http.StatusOK, AdminStats{
+		Name:       m.name,
+		Polls:      m.Stats(),
+		Collectors: m.CollectorStats(),
+		Pipeline:   m.pipeline.Stats(),
+		Backends:   m.pipeline.BackendStats(),
+	})
+}
+
+// handleCollect triggers an immediate collection of the collector named by
+// the "collector" parameter, or of every enabled collector.
+func (a *adminServer) handleCollect(w http.ResponseWriter, r *http.Request) {
+	reply := a.send(r.Context(), adminCollect, r.FormValue("collector"))
+	if reply.err != nil {
+		writeJSONError(w, reply.status, reply.err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "collection started"})
+}
+
+func (a *adminServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	reply := a.send(r.Context(), adminReload, "")
+	if reply.err != nil {
+		writeJSONError(w, reply.status, reply.err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "reloaded"})
+}
+
+// handleLogLevel sets the log level from the "level" parameter. The level
+// stays in effect until the next reload.
+func (a *adminServer) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	m := a.m
+	if m.levelVar == nil {
+		writeJSONError(w, http.StatusConflict, errors.New("log level is not adjustable with a custom logger"))
+		return
+	}
+
+	level := strings.ToLower(r.FormValue("level"))
+	switch level {
+	case "debug", "info", "warn", "error":
+	default:
+		writeJSONError(w, http.StatusBadRequest, errors.New("level must be one of: debug, info, warn, error"))
+		return
+	}
+
+	m.levelVar.Set(ParseLogLevel(level))
+	m.logger.Info("updated log level", "level", level, "source", "admin")
+	writeJSON(w, http.StatusOK, map[string]string{"level": level})
+}
+
+// handleAdmin runs an admin command on the main loop.
+func (m *Monitor) handleAdmin(ctx context.Context, cmd adminCommand) adminReply {
+	switch cmd.op {
+	case adminPing:
+		return adminReply{}
+
+	case adminReady:
+		return adminReply{reasons: m.notReadyReasons()}
+
+	case adminCollect:
+		var targets []*collector
+		for _, c := range m.collectors {
+			if cmd.arg != "" && c.name != cmd.arg {
+				continue
+			}
+			if !c.enabled(m.cfg) {
+				if cmd.arg != "" {
+					return adminReply{status: http.StatusConflict, err: fmt.Errorf("collector %q is disabled", cmd.arg)}
+				}
+				continue
+			}
+			targets = append(targets, c)
+		}
+		if cmd.arg != "" && len(targets) == 0 {
+			return adminReply{status: http.StatusNotFound, err: fmt.Errorf("unknown collector %q", cmd.arg)}
+		}
+		for _, c := range targets {
+			m.logger.Info("collection triggered", "collector", c.name, "source", "admin")
+			m.schedule(ctx, c)
+		}
+		return adminReply{}
+
+	case adminReload:
+		if err := m.handleReload(); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errNoReloadSource) {
+				status = http.StatusConflict
+			}
+			return adminReply{status: status, err: err}
+		}
+		return adminReply{}
+	}
+
+	return adminReply{status: http.StatusBadRequest, err: fmt.Errorf("unknown admin command %d", cmd.op)}
+}
+
+// notReadyReasons explains why the monitor is not ready, or returns nil.
+func (m *Monitor) notReadyReasons() []string {
+	var reasons []string
+
+	limit := int64(m.cfg.Admin.ReadyFailures)
+	if limit <= 0 {
+		limit = 3
+	}
+	for _, c := range m.collectors {
+		if !c.enabled(m.cfg) {
+			continue
+		}
+		s := c.stats.snapshot()
+		if s.ConsecutiveFailures >= limit {
+			reasons = append(reasons, fmt.Sprintf("collector %s: %d consecutive failures: %s",
+				c.name, s.ConsecutiveFailures, s.LastError))
+		}
+	}
+
+	for _, b := range m.pipeline.BackendStats() {
+		switch {
+		case b.State != BreakerClosed:
+			reasons = append(reasons, fmt.Sprintf("backend %s: circuit %s", b.Name, b.State))
+		case !b.Healthy:
+			reasons = append(reasons, fmt.Sprintf("backend %s: unhealthy", b.Name))
+		}
+	}
+
+	return reasons
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startAdminMonitor runs a monitor with the admin server enabled on a free
+// local port and returns its base URL.
+func startAdminMonitor(t *testing.T, opts ...Option) (*Monitor, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Global.PollInterval = Duration{1 * time.Hour}
+	cfg.Admin = AdminConfig{Enabled: true, Addr: addr, ReadyFailures: 2}
+
+	m, err := New("test", nil, append([]Option{
+		WithConfig(cfg),
+		WithBackend(&mockBackend{name: "sink", healthy: true}),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		// Idle client connections would hold up the server's shutdown.
+		http.DefaultClient.CloseIdleConnections()
+		cancel()
+		<-done
+	})
+
+	base := "http://" + addr
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := http.Get(base + "/healthz")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return m, base
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("admin server did not come up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func getJSON(t *testing.T, u string, v any) int {
+	t.Helper()
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatalf("GET %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s: decoding response: %v", u, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func postForm(t *testing.T, u string, form url.Values) int {
+	t.Helper()
+	resp, err := http.PostForm(u, form)
+	if err != nil {
+		t.Fatalf("POST %s: %v", u, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAdminReadinessAndCollect(t *testing.T) {
+	var calls atomic.Int32
+	var failing atomic.Bool
+	collect := func(ctx context.Context) ([]*Metric, error) {
+		calls.Add(1)
+		if failing.Load() {
+			return nil, fmt.Errorf("device unreachable")
+		}
+		return []*Metric{NewMetric("app").WithField("value", 1)}, nil
+	}
+	_, base := startAdminMonitor(t, WithCollector("app", collect, 0))
+
+	waitCalls := func(n int32) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for calls.Load() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("collector ran %d times, want %d", calls.Load(), n)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		// Let the collection record its stats.
+		time.Sleep(20 * time.Millisecond)
+	}
+	waitCalls(1)
+
+	var ready ReadyStatus
+	if code := getJSON(t, base+"/readyz", &ready); code != http.StatusOK || !ready.Ready {
+		t.Errorf("/readyz = %d %+v, want 200 ready", code, ready)
+	}
+
+	failing.Store(true)
+	for i := int32(2); i <= 3; i++ {
+		if code := postForm(t, base+"/collect", url.Values{"collector": {"app"}}); code != http.StatusAccepted {
+			t.Fatalf("POST /collect = %d, want 202", code)
+		}
+		waitCalls(i)
+	}
+
+	if code := getJSON(t, base+"/readyz", &ready); code != http.StatusServiceUnavailable || ready.Ready || len(ready.Reasons) != 1 {
+		t.Errorf("/readyz = %d %+v, want 503 with one reason", code, ready)
+	}
+
+	var stats AdminStats
+	if code := getJSON(t, base+"/stats", &stats); code != http.StatusOK {
+		t.Fatalf("/stats = %d, want 200", code)
+	}
+	app := stats.Collectors["app"]
+	if app.ConsecutiveFailures != 2 || app.LastError != "device unreachable" {
+		t.Errorf("/stats collectors.app = %+v, want 2 consecutive failures", app)
+	}
+	if len(stats.Backends) != 1 || stats.Backends[0].Name != "sink" {
+		t.Errorf("/stats backends = %+v, want the sink backend", stats.Backends)
+	}
+
+	if code := postForm(t, base+"/collect", url.Values{"collector": {"nope"}}); code != http.StatusNotFound {
+		t.Errorf("POST /collect unknown collector = %d, want 404", code)
+	}
+	if code := getJSON(t, base+"/collect", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /collect = %d, want 405", code)
+	}
+}
+
+func TestAdminReloadAndLogLevel(t *testing.T) {
+	var reloads atomic.Int32
+	reload := func(path string) (*Config, error) {
+		reloads.Add(1)
+		cfg := DefaultConfig()
+		cfg.Global.LogLevel = "warn"
+		return cfg, nil
+	}
+	collect := func(ctx context.Context) ([]*Metric, error) { return nil, nil }
+	m, base := startAdminMonitor(t, WithCollector("app", collect, 0), WithReloadFunc(reload))
+
+	if code := postForm(t, base+"/loglevel", url.Values{"level": {"debug"}}); code != http.StatusOK {
+		t.Fatalf("POST /loglevel = %d, want 200", code)
+	}
+	if got := m.levelVar.Level(); got != slog.LevelDebug {
+		t.Errorf("level = %v, want debug", got)
+	}
+	if code := postForm(t, base+"/loglevel", url.Values{"level": {"loud"}}); code != http.StatusBadRequest {
+		t.Errorf("POST /loglevel with a bad level = %d, want 400", code)
+	}
+
+	if code := postForm(t, base+"/reload", nil); code != http.StatusOK {
+		t.Fatalf("POST /reload = %d, want 200", code)
+	}
+	if reloads.Load() != 1 {
+		t.Errorf("reload function called %d times, want 1", reloads.Load())
+	}
+	if got := m.levelVar.Level(); got != slog.LevelWarn {
+		t.Errorf("level after reload = %v, want warn from the reloaded config", got)
+	}
+}
+
+func TestAdminReloadWithoutSource(t *testing.T) {
+	collect := func(ctx context.Context) ([]*Metric, error) { return nil, nil }
+	_, base := startAdminMonitor(t, WithCollector("app", collect, 0))
+
+	if code := postForm(t, base+"/reload", nil); code != http.StatusConflict {
+		t.Errorf("POST /reload without a config source = %d, want 409", code)
+	}
+}
+
+func TestValidationAdmin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Admin = AdminConfig{Enabled: true}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should error for a missing addr and ready_failures")
+	}
+	if errs := err.(ValidationErrors); len(errs) != 2 {
+		t.Errorf("Expected 2 validation errors, got %d: %v", len(errs), errs)
+	}
+}
@@ -27,6 +27,14 @@ type Backend interface {
 	Healthy() bool
 }
 
+// Prober is implemented by backends that can check connectivity without
+// writing. While a backend's circuit breaker is half-open the pipeline calls
+// Probe instead of attempting a trial write.
+type Prober interface {
+	// Probe returns nil if the backend is reachable and ready for writes.
+	Probe(ctx context.Context) error
+}
+
 // Echo is a debug backend that writes metrics to an io.Writer.
 type Echo struct {
 	writer io.Writer
@@ -77,12 +85,17 @@ func (e *Echo) Write(ctx context.Context, batch []*Metric) error {
 		return fmt.Errorf("echo backend not initialized")
 	}
 
+	enc := NewLineEncoder(PrecisionNanoseconds)
+	var buf []byte
 	for _, m := range batch {
-		line := m.ToLineProtocol()
-		if _, err := fmt.Fprintln(writer, line); err != nil {
-			return fmt.Errorf("failed to write metric: %w", err)
+		var err error
+		if buf, err = enc.Append(buf, m); err != nil {
+			e.logger.Debug("skipping metric", "measurement", m.Measurement, "error", err)
 		}
 	}
+	if _, err := writer.Write(buf); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
 
 	e.logger.Debug("echoed metrics", "count", len(batch))
 	return nil
@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a backend's circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed delivers normally.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen holds batches back (spooling them when possible) until the
+	// cooldown elapses.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe or trial write through to decide
+	// whether to close or reopen.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler for BreakerState.
+func (s BreakerState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// circuitBreaker tracks consecutive delivery failures for one backend. It is
+// only driven by the backend's worker, so transitions happen serially; the
+// mutex exists for concurrent readers of the state.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// current returns the breaker state, moving from open to half-open once the
+// cooldown has elapsed.
+func (cb *circuitBreaker) current(now time.Time) BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == BreakerOpen && now.Sub(cb.openedAt) >= cb.cooldown {
+		cb.state = BreakerHalfOpen
+	}
+	return cb.state
+}
+
+// peek returns the breaker state without transitioning it.
+func (cb *circuitBreaker) peek() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// retryIn reports how long until an open breaker may be probed.
+func (cb *circuitBreaker) retryIn(now time.Time) (time.Duration, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state != BreakerOpen {
+		return 0, false
+	}
+	return max(cb.cooldown-now.Sub(cb.openedAt), 0), true
+}
+
+// success closes the breaker and resets the failure count.
+func (cb *circuitBreaker) success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.state = BreakerClosed
+	cb.failures = 0
+}
+
+// failure records a failed delivery, reporting whether the breaker opened.
+// A failure while half-open reopens the breaker immediately.
+func (cb *circuitBreaker) failure(now time.Time) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	if cb.state == BreakerHalfOpen || cb.failures >= cb.threshold {
+		cb.state = BreakerOpen
+		cb.openedAt = now
+		return true
+	}
+	return false
+}
+
+// trip opens the breaker regardless of the failure count.
+func (cb *circuitBreaker) trip(now time.Time) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.state = BreakerOpen
+	cb.openedAt = now
+}
+
+// UnmarshalText parses a state written by MarshalText.
+func (s *BreakerState) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "closed":
+		*s = BreakerClosed
+	case "open":
+		*s = BreakerOpen
+	case "half-open":
+		*s = BreakerHalfOpen
+	default:
+		return fmt.Errorf("unknown breaker state %q", text)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAtThreshold(t *testing.T) {
+	cb := newCircuitBreaker(2, time.Minute)
+	now := time.Now()
+
+	if cb.failure(now) {
+		t.Error("first failure should not open the breaker")
+	}
+	if cb.current(now) != BreakerClosed {
+		t.Errorf("state = %v, want closed", cb.current(now))
+	}
+	if !cb.failure(now) {
+		t.Error("second failure should open the breaker")
+	}
+	if cb.current(now) != BreakerOpen {
+		t.Errorf("state = %v, want open", cb.current(now))
+	}
+
+	wait, ok := cb.retryIn(now.Add(20 * time.Second))
+	if !ok || wait != 40*time.Second {
+		t.Errorf("retryIn() = %v, %v, want 40s, true", wait, ok)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	cb := newCircuitBreaker(1, time.Minute)
+	now := time.Now()
+	cb.failure(now)
+
+	later := now.Add(time.Minute)
+	if cb.current(later) != BreakerHalfOpen {
+		t.Fatalf("state = %v, want half-open after cooldown", cb.current(later))
+	}
+	if _, ok := cb.retryIn(later); ok {
+		t.Error("retryIn() should report false while half-open")
+	}
+
+	// A failed probe reopens immediately.
+	if !cb.failure(later) {
+		t.Error("failure while half-open should reopen the breaker")
+	}
+	if cb.current(later) != BreakerOpen {
+		t.Errorf("state = %v, want open", cb.current(later))
+	}
+
+	cb.current(later.Add(time.Minute))
+	cb.success()
+	if cb.peek() != BreakerClosed {
+		t.Errorf("state = %v, want closed after success", cb.peek())
+	}
+}
+
+func TestBreakerStateString(t *testing.T) {
+	tests := []struct {
+		state BreakerState
+		want  string
+	}{
+		{BreakerClosed, "closed"},
+		{BreakerOpen, "open"},
+		{BreakerHalfOpen, "half-open"},
+		{BreakerState(99), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
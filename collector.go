@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCollectorName is the name given to the CollectFunc passed to New.
+const DefaultCollectorName = "default"
+
+// OverlapPolicy selects what happens when a collector is due while its
+// previous collection is still running.
+type OverlapPolicy string
+
+const (
+	// OverlapSkip skips the new collection and counts it in SkippedPolls.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue runs one more collection as soon as the current one
+	// finishes; further overlapping polls are skipped.
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapCancel cancels the running collection and starts a new one. The
+	// cancelled collection's results are discarded.
+	OverlapCancel OverlapPolicy = "cancel"
+)
+
+// Valid reports whether the policy is one of the known policies.
+func (o OverlapPolicy) Valid() bool {
+	switch o {
+	case OverlapSkip, OverlapQueue, OverlapCancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// collector is a named CollectFunc with its own schedule and statistics.
+type collector struct {
+	name     string
+	fn       CollectFunc
+	interval time.Duration // zero follows global.poll_interval
+	stats    statsTracker
+	next     time.Time
+
+	// Run state, guarded by mu. gen identifies the current run so a run
+	// superseded under OverlapCancel can tell its results are stale.
+	mu      sync.Mutex
+	running bool
+	queued  bool
+	gen     uint64
+	cancel  context.CancelFunc
+}
+
+// intervalFor resolves a collector's poll interval: a [collectors.<name>]
+// override wins, then the interval given to WithCollector, then
+// global.poll_interval.
+func (c *collector) intervalFor(cfg *Config) time.Duration {
+	if cc, ok := cfg.Collectors[c.name]; ok && cc.PollInterval.Duration > 0 {
+		return cc.PollInterval.Duration
+	}
+	if c.interval > 0 {
+		return c.interval
+	}
+	return cfg.Global.PollInterval.Duration
+}
+
+// timeoutFor resolves the deadline for a single collection: a
+// [collectors.<name>] override wins, then global.collect_timeout, and when
+// neither is set the collector's poll interval.
+func (c *collector) timeoutFor(cfg *Config) time.Duration {
+	if cc, ok := cfg.Collectors[c.name]; ok && cc.CollectTimeout.Duration > 0 {
+		return cc.CollectTimeout.Duration
+	}
+	if cfg.Global.CollectTimeout.Duration > 0 {
+		return cfg.Global.CollectTimeout.Duration
+	}
+	return c.intervalFor(cfg)
+}
+
+// overlapFor resolves the collector's overlap policy.
+func (c *collector) overlapFor(cfg *Config) OverlapPolicy {
+	if cc, ok := cfg.Collectors[c.name]; ok && cc.OverlapPolicy.Valid() {
+		return cc.OverlapPolicy
+	}
+	if cfg.Global.OverlapPolicy.Valid() {
+		return cfg.Global.OverlapPolicy
+	}
+	return OverlapSkip
+}
+
+// enabled reports whether the collector is enabled in the config.
+func (c *collector) enabled(cfg *Config) bool {
+	cc, ok := cfg.Collectors[c.name]
+	return !ok || !cc.Disabled
+}
+
+// superseded reports whether run gen has been replaced by a newer run.
+// Synchronous collections use gen zero and are never superseded.
+func (c *collector) superseded(gen uint64) bool {
+	if gen == 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen != gen
+}
@@ -0,0 +1,324 @@
+package collectors
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsTypes maps the supported record types to their wire values.
+var dnsTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"TXT":   dnsmessage.TypeTXT,
+	"PTR":   dnsmessage.TypePTR,
+}
+
+type dnsProbe struct {
+	cfg     monitor.DNSProbeConfig
+	name    string
+	server  string
+	network string
+	qtype   string
+	timeout time.Duration
+}
+
+// DNS returns a CollectFunc that performs every lookup in cfg.DNS
+// concurrently and reports a "dns_probe" metric per target, tagged with the
+// target's name (if it has one), server, query and type. Fields:
+//
+//	result      the outcome, such as "success" or "timeout"
+//	success     whether the lookup succeeded with every expected answer
+//	answers     number of records returned
+//	query_time  seconds the lookup took
+//
+// With a server configured the query is sent straight to it, as a fully
+// qualified name, so neither the hosts file nor search domains come into
+// play. Without one the system resolver answers, with both. Answers are
+// compared without case or trailing dots, and IP addresses in their
+// canonical form.
+func DNS(cfg monitor.ProbesConfig) (monitor.CollectFunc, error) {
+	probes, err := dnsProbes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return runProbes(probes), nil
+}
+
+func dnsProbes(cfg monitor.ProbesConfig) ([]prober, error) {
+	probes := make([]prober, 0, len(cfg.DNS))
+	for i, pc := range cfg.DNS {
+		p := &dnsProbe{
+			cfg:     pc,
+			name:    pc.Name,
+			qtype:   strings.ToUpper(pc.Type),
+			timeout: probeTimeout(pc.Timeout, cfg.Timeout),
+		}
+		if p.qtype == "" {
+			p.qtype = "A"
+		}
+		if _, ok := dnsTypes[p.qtype]; !ok {
+			return nil, fmt.Errorf("probes.dns[%d].type: unsupported record type %q", i, pc.Type)
+		}
+
+		if pc.Server != "" {
+			p.server = pc.Server
+			if _, _, err := net.SplitHostPort(p.server); err != nil {
+				p.server = net.JoinHostPort(p.server, "53")
+			}
+			p.network = pc.Network
+			if p.network == "" {
+				p.network = "udp"
+			}
+		}
+		probes = append(probes, p)
+	}
+	return probes, nil
+}
+
+func (p *dnsProbe) probe(ctx context.Context) *monitor.Metric {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	server := p.server
+	if server == "" {
+		server = "system"
+	}
+	m := probeMetric("dns_probe", p.cfg.Tags, p.name).WithTags(map[string]string{
+		"server": server,
+		"query":  p.cfg.Query,
+		"type":   p.qtype,
+	})
+
+	start := time.Now()
+	answers, err := p.lookup(ctx)
+	setLatency(m, "query_time", time.Since(start))
+	if err != nil {
+		return finish(m, classifyNetError(err))
+	}
+	m.WithGauge("answers", int64(len(answers)))
+
+	got := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		got[normalizeAnswer(a)] = true
+	}
+	for _, want := range p.cfg.Expect {
+		if !got[normalizeAnswer(want)] {
+			return finish(m, ResultAnswerMismatch)
+		}
+	}
+	return finish(m, ResultSuccess)
+}
+
+func (p *dnsProbe) lookup(ctx context.Context) ([]string, error) {
+	if p.server != "" {
+		return p.exchange(ctx, p.network)
+	}
+
+	r, q := net.DefaultResolver, p.cfg.Query
+	switch p.qtype {
+	case "A", "AAAA":
+		network := "ip4"
+		if p.qtype == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, q)
+		answers := make([]string, len(ips))
+		for i, ip := range ips {
+			answers[i] = ip.String()
+		}
+		return answers, err
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return []string{cname}, nil
+	case "MX":
+		mxs, err := r.LookupMX(ctx, q)
+		answers := make([]string, len(mxs))
+		for i, mx := range mxs {
+			answers[i] = mx.Host
+		}
+		return answers, err
+	case "NS":
+		nss, err := r.LookupNS(ctx, q)
+		answers := make([]string, len(nss))
+		for i, ns := range nss {
+			answers[i] = ns.Host
+		}
+		return answers, err
+	case "TXT":
+		return r.LookupTXT(ctx, q)
+	default: // PTR
+		return r.LookupAddr(ctx, q)
+	}
+}
+
+// exchange sends the query to the configured server over network and
+// returns the answers of the requested type. A truncated UDP response is
+// retried over TCP.
+func (p *dnsProbe) exchange(ctx context.Context, network string) ([]string, error) {
+	qname, err := p.questionName()
+	if err != nil {
+		return nil, err
+	}
+	qtype := dnsTypes[p.qtype]
+	id := uint16(rand.Uint32())
+	query, err := (&dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}).Pack()
+	if err != nil {
+		return nil, p.dnsError(fmt.Sprintf("cannot build query: %v", err), false)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, p.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	raw, err := roundTrip(conn, network, query)
+	if err != nil {
+		return nil, err
+	}
+	var resp dnsmessage.Message
+	if err := resp.Unpack(raw); err != nil {
+		return nil, p.dnsError(fmt.Sprintf("invalid response: %v", err), false)
+	}
+	switch {
+	case !resp.Response || resp.ID != id:
+		return nil, p.dnsError("response does not match the query", false)
+	case resp.Truncated && network == "udp":
+		return p.exchange(ctx, "tcp")
+	case resp.RCode != dnsmessage.RCodeSuccess:
+		return nil, p.dnsError(resp.RCode.String(), resp.RCode == dnsmessage.RCodeNameError)
+	}
+
+	var answers []string
+	for _, rr := range resp.Answers {
+		if rr.Header.Type != qtype {
+			continue // e.g. the CNAME chain leading to A records
+		}
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			answers = append(answers, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			answers = append(answers, net.IP(body.AAAA[:]).String())
+		case *dnsmessage.CNAMEResource:
+			answers = append(answers, body.CNAME.String())
+		case *dnsmessage.MXResource:
+			answers = append(answers, body.MX.String())
+		case *dnsmessage.NSResource:
+			answers = append(answers, body.NS.String())
+		case *dnsmessage.TXTResource:
+			answers = append(answers, strings.Join(body.TXT, ""))
+		case *dnsmessage.PTRResource:
+			answers = append(answers, body.PTR.String())
+		}
+	}
+	if len(answers) == 0 {
+		return nil, p.dnsError("no answer", true)
+	}
+	return answers, nil
+}
+
+// questionName returns the fully qualified name to query: the query itself,
+// or for PTR the reverse lookup name of the query's address.
+func (p *dnsProbe) questionName() (dnsmessage.Name, error) {
+	q := p.cfg.Query
+	if p.qtype == "PTR" {
+		ip := net.ParseIP(q)
+		if ip == nil {
+			return dnsmessage.Name{}, p.dnsError("unrecognized address", false)
+		}
+		q = reverseName(ip)
+	}
+	if !strings.HasSuffix(q, ".") {
+		q += "."
+	}
+	name, err := dnsmessage.NewName(q)
+	if err != nil {
+		return dnsmessage.Name{}, p.dnsError(fmt.Sprintf("invalid name: %v", err), false)
+	}
+	return name, nil
+}
+
+func (p *dnsProbe) dnsError(msg string, notFound bool) error {
+	return &net.DNSError{Err: msg, Name: p.cfg.Query, Server: p.server, IsNotFound: notFound}
+}
+
+// roundTrip writes a DNS message and reads the response, using the two-byte
+// length prefix DNS uses over TCP.
+func roundTrip(conn net.Conn, network string, msg []byte) ([]byte, error) {
+	if network != "tcp" {
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 64*1024)
+		n, err := conn.Read(buf)
+		return buf[:n], err
+	}
+
+	if _, err := conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(msg)))); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size[:]))
+	_, err := io.ReadFull(conn, buf)
+	return buf, err
+}
+
+// reverseName returns the in-addr.arpa or ip6.arpa name for ip.
+func reverseName(ip net.IP) string {
+	var sb strings.Builder
+	if v4 := ip.To4(); v4 != nil {
+		for i := len(v4) - 1; i >= 0; i-- {
+			fmt.Fprintf(&sb, "%d.", v4[i])
+		}
+		sb.WriteString("in-addr.arpa.")
+		return sb.String()
+	}
+	const hex = "0123456789abcdef"
+	for i := len(ip) - 1; i >= 0; i-- {
+		sb.WriteByte(hex[ip[i]&0xf])
+		sb.WriteByte('.')
+		sb.WriteByte(hex[ip[i]>>4])
+		sb.WriteByte('.')
+	}
+	sb.WriteString("ip6.arpa.")
+	return sb.String()
+}
+
+// normalizeAnswer puts an answer in a form that compares equal regardless
+// of case, trailing dot or IP address notation.
+func normalizeAnswer(s string) string {
+	if ip := net.ParseIP(s); ip != nil {
+		return ip.String()
+	}
+	return strings.TrimSuffix(strings.ToLower(s), ".")
+}
@@ -0,0 +1,271 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// Format is the output format of an exec command.
+type Format string
+
+const (
+	// FormatInflux is InfluxDB line protocol, one metric per line.
+	FormatInflux Format = "influx"
+	// FormatJSON is a JSON object or array of objects (see ExecConfig).
+	FormatJSON Format = "json"
+	// FormatNagios is Nagios plugin output: a status line with optional
+	// performance data, and the exit code as the service state.
+	FormatNagios Format = "nagios"
+)
+
+// Valid reports whether the format is one of the known formats.
+func (f Format) Valid() bool {
+	switch f {
+	case FormatInflux, FormatJSON, FormatNagios:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxStderr bounds how much of a command's stderr is kept in its error.
+const maxStderr = 1024
+
+// maxOutput bounds how much of a command's stdout is read. A command
+// writing more fails the collection.
+const maxOutput = 1 << 20
+
+// waitDelay is how long to wait for a killed command's output to close.
+// Without it a grandchild holding stdout open would block the collection.
+const waitDelay = time.Second
+
+// ExecConfig configures an exec collector.
+//
+// For FormatJSON the output is either a flat object of fields, reported
+// under Measurement:
+//
+//	{"used": 123, "free": 456, "healthy": true}
+//
+// or one or more explicit metrics:
+//
+//	[{"measurement": "disk", "tags": {"mount": "/"}, "fields": {"used": 123},
+//	  "timestamp": "2024-01-01T00:00:00Z"}]
+//
+// Numbers are always read as floats so a field keeps its type from run to
+// run, nested objects are flattened with "_" and null values are dropped.
+// The timestamp may be an RFC 3339 string or a number of Unix seconds.
+//
+// For FormatNagios, exit codes 0 to 3 (OK, WARNING, CRITICAL, UNKNOWN) are
+// reported as the "state" field rather than as errors. Each performance
+// data label becomes a field, with its warn, crit, min and max thresholds as
+// <label>_warn, <label>_crit, <label>_min and <label>_max. Time and byte
+// units are converted to seconds and bytes, and "c" marks a counter.
+type ExecConfig struct {
+	// Command is the program and its arguments. It is run directly, not
+	// through a shell.
+	Command []string `toml:"command"`
+	// Format of the command's stdout. Defaults to FormatInflux.
+	Format Format `toml:"format"`
+	// Timeout bounds each run, on top of the collection timeout.
+	Timeout monitor.Duration `toml:"timeout"`
+	// Dir is the working directory; empty means the daemon's.
+	Dir string `toml:"dir"`
+	// Env adds to (or overrides) the daemon's environment.
+	Env map[string]string `toml:"env"`
+	// Tags are added to every metric that does not already set them.
+	Tags map[string]string `toml:"tags"`
+	// Measurement names the metric for JSON objects without a measurement
+	// and for Nagios output. Defaults to the base name of the program.
+	Measurement string `toml:"measurement"`
+	// Precision of line protocol timestamps. Defaults to nanoseconds.
+	Precision monitor.Precision `toml:"precision"`
+}
+
+// Validate checks the configuration.
+func (c ExecConfig) Validate() error {
+	var errs monitor.ValidationErrors
+	if len(c.Command) == 0 || c.Command[0] == "" {
+		errs = append(errs, monitor.ValidationError{
+			Field:   "exec.command",
+			Message: "command is required",
+		})
+	}
+	if c.Format != "" && !c.Format.Valid() {
+		errs = append(errs, monitor.ValidationError{
+			Field:   "exec.format",
+			Message: fmt.Sprintf("must be %q, %q or %q, got %q", FormatInflux, FormatJSON, FormatNagios, c.Format),
+		})
+	}
+	if c.Timeout.Duration < 0 {
+		errs = append(errs, monitor.ValidationError{
+			Field:   "exec.timeout",
+			Message: "must not be negative",
+		})
+	}
+	if c.Precision != "" && !c.Precision.Valid() {
+		errs = append(errs, monitor.ValidationError{
+			Field:   "exec.precision",
+			Message: fmt.Sprintf("must be h, m, s, ms, us or ns, got %q", c.Precision),
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ExitError is returned when a command exits unsuccessfully.
+type ExitError struct {
+	Command string
+	Code    int    // exit code, or -1 if the command was killed by a signal
+	Stderr  string // start of the command's stderr, trimmed
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("%s exited with status %d", e.Command, e.Code)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+type execCollector struct {
+	cfg  ExecConfig
+	name string
+	env  []string
+}
+
+// Exec returns a CollectFunc that runs an external command on every
+// collection and parses its stdout into metrics. A command that fails to
+// start, exits with a non-zero status, runs past its timeout or writes more
+// than 1 MiB to stdout fails the collection, with stderr included in the
+// error so it shows up in the collector's PollStats.
+func Exec(cfg ExecConfig) (monitor.CollectFunc, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatInflux
+	}
+	if cfg.Precision == "" {
+		cfg.Precision = monitor.PrecisionNanoseconds
+	}
+
+	e := &execCollector{
+		cfg:  cfg,
+		name: filepath.Base(cfg.Command[0]),
+	}
+	if e.cfg.Measurement == "" {
+		e.cfg.Measurement = e.name
+	}
+	if len(cfg.Env) > 0 {
+		e.env = os.Environ()
+		keys := make([]string, 0, len(cfg.Env))
+		for k := range cfg.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e.env = append(e.env, k+"="+cfg.Env[k])
+		}
+	}
+	return e.collect, nil
+}
+
+func (e *execCollector) collect(ctx context.Context) ([]*monitor.Metric, error) {
+	if e.cfg.Timeout.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.cfg.Timeout.Duration)
+		defer cancel()
+	}
+
+	stdout := &cappedBuffer{max: maxOutput}
+	stderr := &cappedBuffer{max: maxStderr}
+	cmd := exec.CommandContext(ctx, e.cfg.Command[0], e.cfg.Command[1:]...)
+	cmd.Dir = e.cfg.Dir
+	cmd.Env = e.env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("%s: %w", e.name, ctxErr)
+	}
+
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("running %s: %w", e.name, err)
+		}
+		code = exitErr.ExitCode()
+	}
+	if code != 0 && !(e.cfg.Format == FormatNagios && code >= 0 && code <= 3) {
+		return nil, &ExitError{Command: e.name, Code: code, Stderr: stderr.trimmed()}
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("%s: output exceeds %d bytes", e.name, maxOutput)
+	}
+
+	var metrics []*monitor.Metric
+	switch e.cfg.Format {
+	case FormatJSON:
+		metrics, err = parseJSON(stdout.buf.Bytes(), e.cfg.Measurement)
+	case FormatNagios:
+		metrics, err = parseNagios(stdout.buf.String(), e.cfg.Measurement, code)
+	default:
+		metrics, err = monitor.ParseLineProtocol(&stdout.buf, e.cfg.Precision)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s output: %w", e.name, err)
+	}
+
+	for _, m := range metrics {
+		for k, v := range e.cfg.Tags {
+			if _, ok := m.Tags[k]; !ok {
+				m.Tags[k] = v
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the
+// rest, so a command writing without end cannot exhaust memory. Writes
+// always succeed so the command is not killed by a broken pipe.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.max - b.buf.Len(); n > room {
+		p = p[:room]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// trimmed returns the buffer as text for an error message, marked with
+// "..." if output was discarded.
+func (b *cappedBuffer) trimmed() string {
+	s := strings.TrimSpace(b.buf.String())
+	if b.truncated {
+		s += "..."
+	}
+	return s
+}
@@ -0,0 +1,337 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+func shell(script string) []string {
+	return []string{"sh", "-c", script}
+}
+
+func TestExecConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ExecConfig
+		wantErr string
+	}{
+		{"valid", ExecConfig{Command: []string{"true"}}, ""},
+		{"no command", ExecConfig{}, "exec.command"},
+		{"bad format", ExecConfig{Command: []string{"true"}, Format: "xml"}, "exec.format"},
+		{"negative timeout", ExecConfig{Command: []string{"true"}, Timeout: monitor.Duration{Duration: -time.Second}}, "exec.timeout"},
+		{"bad precision", ExecConfig{Command: []string{"true"}, Precision: "d"}, "exec.precision"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecInflux(t *testing.T) {
+	collect, err := Exec(ExecConfig{
+		Command:   shell(`printf 'disk,mount=/ used=10i,free=5i 1704067200\nload,host=custom v=0.5 1704067200\n'`),
+		Tags:      map[string]string{"host": "server1", "dc": "east"},
+		Precision: monitor.PrecisionSeconds,
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+
+	metrics, err := collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(metrics))
+	}
+	if got := metrics[0].Fields["used"]; got != int64(10) {
+		t.Errorf("used = %#v, want int64(10)", got)
+	}
+	if !metrics[0].Timestamp.Equal(time.Unix(1704067200, 0)) {
+		t.Errorf("Timestamp = %v", metrics[0].Timestamp)
+	}
+	wantTags := map[string]string{"mount": "/", "host": "server1", "dc": "east"}
+	if !reflect.DeepEqual(metrics[0].Tags, wantTags) {
+		t.Errorf("Tags = %v, want %v", metrics[0].Tags, wantTags)
+	}
+	if metrics[1].Tags["host"] != "custom" {
+		t.Errorf("default tag overrode metric tag: host = %q", metrics[1].Tags["host"])
+	}
+}
+
+func TestExecEnvAndDir(t *testing.T) {
+	dir := t.TempDir()
+	collect, err := Exec(ExecConfig{
+		Command: shell(`echo "env,dir=$(basename "$PWD") v=\"$GREETING\""`),
+		Env:     map[string]string{"GREETING": "hello"},
+		Dir:     dir,
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+
+	metrics, err := collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	if got := metrics[0].Fields["v"]; got != "hello" {
+		t.Errorf("v = %v, want hello", got)
+	}
+	if got, want := metrics[0].Tags["dir"], dir[strings.LastIndex(dir, "/")+1:]; got != want {
+		t.Errorf("dir = %q, want %q", got, want)
+	}
+}
+
+func TestExecNonZeroExit(t *testing.T) {
+	collect, err := Exec(ExecConfig{
+		Command: shell(`echo 'm v=1'; echo 'disk not mounted' >&2; exit 3`),
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+
+	_, err = collect(context.Background())
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("error = %v, want *ExitError", err)
+	}
+	if exitErr.Code != 3 {
+		t.Errorf("Code = %d, want 3", exitErr.Code)
+	}
+	if exitErr.Stderr != "disk not mounted" {
+		t.Errorf("Stderr = %q", exitErr.Stderr)
+	}
+	if !strings.Contains(err.Error(), "disk not mounted") {
+		t.Errorf("Error() = %q, want stderr included", err.Error())
+	}
+}
+
+func TestExecOutputLimit(t *testing.T) {
+	collect, err := Exec(ExecConfig{
+		Command: shell(`yes 'm v=1' | head -c 2000000; yes x | head -c 5000 >&2`),
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if _, err := collect(context.Background()); err == nil || !strings.Contains(err.Error(), "output exceeds") {
+		t.Errorf("error = %v, want output limit exceeded", err)
+	}
+
+	collect, _ = Exec(ExecConfig{Command: shell(`yes x | head -c 5000 >&2; exit 1`)})
+	_, err = collect(context.Background())
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("error = %v, want *ExitError", err)
+	}
+	if len(exitErr.Stderr) > maxStderr+3 || !strings.HasSuffix(exitErr.Stderr, "...") {
+		t.Errorf("Stderr is %d bytes, want at most %d and marked as truncated", len(exitErr.Stderr), maxStderr)
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	collect, err := Exec(ExecConfig{
+		Command: []string{"sleep", "10"},
+		Timeout: monitor.Duration{Duration: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+
+	start := time.Now()
+	_, err = collect(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("collect took %v, want prompt timeout", elapsed)
+	}
+}
+
+func TestExecCommandNotFound(t *testing.T) {
+	collect, err := Exec(ExecConfig{Command: []string{"/nonexistent/script"}})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if _, err := collect(context.Background()); err == nil {
+		t.Fatal("expected error for missing command")
+	}
+}
+
+func TestExecParseError(t *testing.T) {
+	collect, err := Exec(ExecConfig{Command: shell(`echo 'not line protocol'`)})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	_, err = collect(context.Background())
+	var perr *monitor.ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("error = %v, want *monitor.ParseError", err)
+	}
+}
+
+func TestExecJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		checkTime bool
+		want      []*monitor.Metric
+	}{
+		{
+			"flat object",
+			`{"used": 10, "healthy": true, "mem": {"rss": 5, "heap": null}}`,
+			false,
+			[]*monitor.Metric{
+				monitor.NewMetric("check").WithFields(map[string]interface{}{
+					"used": 10.0, "healthy": true, "mem_rss": 5.0,
+				}),
+			},
+		},
+		{
+			"explicit metrics",
+			`[{"measurement": "disk", "tags": {"mount": "/", "id": 3}, "fields": {"used": 1.5}, "timestamp": "2024-01-01T00:00:00Z"},
+			  {"fields": {"v": "ok"}, "timestamp": 1704067200.5}]`,
+			true,
+			[]*monitor.Metric{
+				monitor.NewMetric("disk").
+					WithTags(map[string]string{"mount": "/", "id": "3"}).
+					WithField("used", 1.5).
+					WithTimestamp(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+				monitor.NewMetric("check").
+					WithField("v", "ok").
+					WithTimestamp(time.Unix(1704067200, 5e8)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJSON([]byte(tt.output), "check")
+			if err != nil {
+				t.Fatalf("parseJSON() error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d metrics, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Measurement != tt.want[i].Measurement {
+					t.Errorf("[%d] Measurement = %q, want %q", i, got[i].Measurement, tt.want[i].Measurement)
+				}
+				if !reflect.DeepEqual(got[i].Tags, tt.want[i].Tags) {
+					t.Errorf("[%d] Tags = %v, want %v", i, got[i].Tags, tt.want[i].Tags)
+				}
+				if !reflect.DeepEqual(got[i].Fields, tt.want[i].Fields) {
+					t.Errorf("[%d] Fields = %v, want %v", i, got[i].Fields, tt.want[i].Fields)
+				}
+				if tt.checkTime && !got[i].Timestamp.Equal(tt.want[i].Timestamp) {
+					t.Errorf("[%d] Timestamp = %v, want %v", i, got[i].Timestamp, tt.want[i].Timestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestExecJSONErrors(t *testing.T) {
+	for _, output := range []string{
+		`not json`,
+		`{"list": [1, 2]}`,
+		`{}`,
+		`{"fields": {"v": 1}, "timestamp": "yesterday"}`,
+	} {
+		if _, err := parseJSON([]byte(output), "check"); err == nil {
+			t.Errorf("parseJSON(%s) expected error", output)
+		}
+	}
+}
+
+func TestExecNagios(t *testing.T) {
+	collect, err := Exec(ExecConfig{
+		Command:     shell(`echo "DISK WARNING - free space low | /=2643MB;5948;5958;0;5968 'inode use'=45%;80:90"; echo "/ 3326 MB"; echo "long text | time=12ms;;;0 errors=7c load=U"; exit 1`),
+		Format:      FormatNagios,
+		Measurement: "check_disk",
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+
+	metrics, err := collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(metrics))
+	}
+	m := metrics[0]
+	if m.Measurement != "check_disk" {
+		t.Errorf("Measurement = %q", m.Measurement)
+	}
+	const mb = 1 << 20
+	want := map[string]interface{}{
+		"state":     int64(1),
+		"/":         2643.0 * mb,
+		"/_warn":    5948.0 * mb,
+		"/_crit":    5958.0 * mb,
+		"/_min":     0.0,
+		"/_max":     5968.0 * mb,
+		"inode use": 45.0,
+		"time":      0.012,
+		"time_min":  0.0,
+		"errors":    7.0,
+	}
+	if !reflect.DeepEqual(m.Fields, want) {
+		t.Errorf("Fields = %v, want %v", m.Fields, want)
+	}
+	if m.FieldUnit("/") != monitor.UnitBytes || m.FieldUnit("time") != monitor.UnitSeconds {
+		t.Errorf("units = %q, %q", m.FieldUnit("/"), m.FieldUnit("time"))
+	}
+	if m.FieldKind("errors") != monitor.KindCounter {
+		t.Errorf("errors kind = %v, want counter", m.FieldKind("errors"))
+	}
+}
+
+func TestExecNagiosFailure(t *testing.T) {
+	collect, err := Exec(ExecConfig{
+		Command: shell(`echo "plugin crashed" >&2; exit 4`),
+		Format:  FormatNagios,
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	_, err = collect(context.Background())
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 4 {
+		t.Fatalf("error = %v, want exit status 4", err)
+	}
+}
+
+func TestExecDefaultMeasurement(t *testing.T) {
+	collect, err := Exec(ExecConfig{
+		Command: []string{"/bin/echo", `{"v": 1}`},
+		Format:  FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	metrics, err := collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	if metrics[0].Measurement != "echo" {
+		t.Errorf("Measurement = %q, want echo", metrics[0].Measurement)
+	}
+}
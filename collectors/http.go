@@ -0,0 +1,293 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// maxProbeBody bounds how much of a response body is kept for the body
+// regex and JSON assertions. The rest is read and counted but discarded.
+const maxProbeBody = 1 << 20
+
+type httpProbe struct {
+	cfg     monitor.HTTPProbeConfig
+	name    string
+	method  string
+	timeout time.Duration
+	regex   *regexp.Regexp
+	path    []string
+	client  *http.Client
+}
+
+// HTTP returns a CollectFunc that probes every target in cfg.HTTP
+// concurrently and reports an "http_probe" metric per target, tagged with
+// the target's name (if it has one), url and method. Fields:
+//
+//	result                   the outcome, such as "success" or "timeout"
+//	success                  whether every check passed
+//	status_code              HTTP status of the final response
+//	content_length           response body size in bytes
+//	dns_time, connect_time,  phase latencies in seconds (from httptrace);
+//	tls_time, first_byte_time  phases that did not happen are left out
+//	total_time               time until the body was read, in seconds
+//	cert_expires_in          seconds until the server certificate expires
+//	body_match, json_match   outcome of the body_regex and json_path checks
+//
+// A failed probe is reported, not returned as an error.
+func HTTP(cfg monitor.ProbesConfig) (monitor.CollectFunc, error) {
+	probes, err := httpProbes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return runProbes(probes), nil
+}
+
+func httpProbes(cfg monitor.ProbesConfig) ([]prober, error) {
+	probes := make([]prober, 0, len(cfg.HTTP))
+	for i, pc := range cfg.HTTP {
+		p := &httpProbe{
+			cfg:     pc,
+			name:    pc.Name,
+			method:  strings.ToUpper(pc.Method),
+			timeout: probeTimeout(pc.Timeout, cfg.Timeout),
+		}
+		if p.method == "" {
+			p.method = http.MethodGet
+		}
+		if pc.BodyRegex != "" {
+			re, err := regexp.Compile(pc.BodyRegex)
+			if err != nil {
+				return nil, fmt.Errorf("probes.http[%d].body_regex: %w", i, err)
+			}
+			p.regex = re
+		}
+		if pc.JSONPath != "" {
+			p.path = strings.Split(pc.JSONPath, ".")
+		}
+		p.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				// A fresh connection per probe, so connect and TLS times are
+				// measured every time.
+				DisableKeepAlives: true,
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: pc.InsecureSkipVerify},
+			},
+		}
+		probes = append(probes, p)
+	}
+	return probes, nil
+}
+
+// traceTimes records when each phase of a request started and finished.
+// Connection attempts can run in parallel, so access is locked.
+type traceTimes struct {
+	mu                     sync.Mutex
+	dnsStart, dnsDone      time.Time
+	connectStart, connDone time.Time
+	tlsStart, tlsDone      time.Time
+	firstByte              time.Time
+}
+
+func (t *traceTimes) set(field *time.Time) {
+	t.mu.Lock()
+	*field = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *traceTimes) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { t.set(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.set(&t.connDone) },
+		TLSHandshakeStart:    func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+}
+
+// record adds the latency of each completed phase to m.
+func (t *traceTimes) record(m *monitor.Metric, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phase := func(name string, from, to time.Time) {
+		if !from.IsZero() && !to.IsZero() {
+			setLatency(m, name, to.Sub(from))
+		}
+	}
+	phase("dns_time", t.dnsStart, t.dnsDone)
+	phase("connect_time", t.connectStart, t.connDone)
+	phase("tls_time", t.tlsStart, t.tlsDone)
+	phase("first_byte_time", start, t.firstByte)
+}
+
+func (p *httpProbe) probe(ctx context.Context) *monitor.Metric {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	m := probeMetric("http_probe", p.cfg.Tags, p.name).
+		WithTags(map[string]string{"url": p.cfg.URL, "method": p.method})
+
+	var times traceTimes
+	var body io.Reader
+	if p.cfg.Body != "" {
+		body = strings.NewReader(p.cfg.Body)
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, times.trace()), p.method, p.cfg.URL, body)
+	if err != nil {
+		return finish(m, ResultConnectionFailed)
+	}
+	for k, v := range p.cfg.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		times.record(m, start)
+		return finish(m, classify(err))
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(resp.Body, maxProbeBody))
+	if err == nil {
+		var rest int64
+		rest, err = io.Copy(io.Discard, resp.Body)
+		n += rest
+	}
+	total := time.Since(start)
+
+	times.record(m, start)
+	m.WithGauge("status_code", int64(resp.StatusCode)).
+		WithGauge("content_length", n).
+		WithFieldMeta("content_length", "", monitor.UnitBytes)
+	setLatency(m, "total_time", total)
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expiresIn := time.Until(resp.TLS.PeerCertificates[0].NotAfter)
+		m.WithGauge("cert_expires_in", expiresIn.Seconds()).
+			WithFieldMeta("cert_expires_in", "Seconds until the server certificate expires.", monitor.UnitSeconds)
+	}
+	if err != nil {
+		return finish(m, classify(err))
+	}
+
+	result := ResultSuccess
+	if !p.statusOK(resp.StatusCode) {
+		result = ResultStatusMismatch
+	}
+	if p.regex != nil {
+		match := p.regex.Match(buf.Bytes())
+		m.WithGauge("body_match", match)
+		if !match && result == ResultSuccess {
+			result = ResultBodyMismatch
+		}
+	}
+	if p.path != nil {
+		match := p.jsonMatch(buf.Bytes())
+		m.WithGauge("json_match", match)
+		if !match && result == ResultSuccess {
+			result = ResultJSONMismatch
+		}
+	}
+
+	return finish(m, result)
+}
+
+func (p *httpProbe) statusOK(code int) bool {
+	if len(p.cfg.ExpectStatus) == 0 {
+		return code >= 200 && code < 400
+	}
+	for _, c := range p.cfg.ExpectStatus {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonMatch reports whether the body is JSON in which the probe's path
+// resolves, to the expected value if one is configured.
+func (p *httpProbe) jsonMatch(body []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return false
+	}
+	v, ok := jsonLookup(doc, p.path)
+	if !ok {
+		return false
+	}
+	return p.cfg.JSONValue == "" || jsonString(v) == p.cfg.JSONValue
+}
+
+// jsonLookup follows a path of object keys and array indexes through a
+// decoded JSON document.
+func jsonLookup(v interface{}, path []string) (interface{}, bool) {
+	for _, seg := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[seg]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonString formats a decoded JSON value for comparison with JSONValue:
+// strings and numbers as written, everything else as JSON.
+func jsonString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+// classify maps a request error to a probe result.
+func classify(err error) string {
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthority) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidCert) {
+		return ResultTLSError
+	}
+	return classifyNetError(err)
+}
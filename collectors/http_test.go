@@ -0,0 +1,233 @@
+package collectors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+func probeOnce(t *testing.T, cfg monitor.ProbesConfig) []*monitor.Metric {
+	t.Helper()
+	collect, err := HTTP(cfg)
+	if err != nil {
+		t.Fatalf("HTTP() error: %v", err)
+	}
+	metrics, err := collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	if len(metrics) != len(cfg.HTTP) {
+		t.Fatalf("got %d metrics, want %d", len(metrics), len(cfg.HTTP))
+	}
+	return metrics
+}
+
+func probeConfig(targets ...monitor.HTTPProbeConfig) monitor.ProbesConfig {
+	return monitor.ProbesConfig{
+		Timeout: monitor.Duration{Duration: 2 * time.Second},
+		HTTP:    targets,
+	}
+}
+
+func TestHTTPProbeSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"status": "healthy", "echo": "`+string(body)+`", "items": [{"id": 7}]}`)
+	}))
+	defer srv.Close()
+
+	metrics := probeOnce(t, probeConfig(monitor.HTTPProbeConfig{
+		Name:      "api",
+		URL:       srv.URL,
+		Method:    "post",
+		Headers:   map[string]string{"X-Token": "secret"},
+		Body:      "ping",
+		BodyRegex: `"echo": "ping"`,
+		JSONPath:  "items.0.id",
+		JSONValue: "7",
+		Tags:      map[string]string{"team": "core"},
+	}))
+	m := metrics[0]
+
+	if m.Measurement != "http_probe" {
+		t.Errorf("Measurement = %q", m.Measurement)
+	}
+	wantTags := map[string]string{"target": "api", "url": srv.URL, "method": "POST", "team": "core"}
+	for k, v := range wantTags {
+		if m.Tags[k] != v {
+			t.Errorf("tag %s = %q, want %q", k, m.Tags[k], v)
+		}
+	}
+	if m.Fields["result"] != ResultSuccess || m.Fields["success"] != true || m.Fields["status_code"] != int64(200) {
+		t.Errorf("result = %v, success = %v, status_code = %v", m.Fields["result"], m.Fields["success"], m.Fields["status_code"])
+	}
+	if m.Fields["body_match"] != true || m.Fields["json_match"] != true {
+		t.Errorf("body_match = %v, json_match = %v", m.Fields["body_match"], m.Fields["json_match"])
+	}
+	if n, _ := m.Fields["content_length"].(int64); n == 0 {
+		t.Errorf("content_length = %v, want > 0", m.Fields["content_length"])
+	}
+	for _, f := range []string{"connect_time", "first_byte_time", "total_time"} {
+		if _, ok := m.Fields[f].(float64); !ok {
+			t.Errorf("missing %s", f)
+		}
+		if m.FieldUnit(f) != monitor.UnitSeconds {
+			t.Errorf("%s unit = %q", f, m.FieldUnit(f))
+		}
+	}
+	for _, f := range []string{"dns_time", "tls_time", "cert_expires_in"} {
+		if _, ok := m.Fields[f]; ok {
+			t.Errorf("unexpected %s for a plain HTTP IP target", f)
+		}
+	}
+}
+
+func TestHTTPProbeChecks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		default:
+			io.WriteString(w, `{"status": "degraded"}`)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		probe  monitor.HTTPProbeConfig
+		result string
+	}{
+		{"status mismatch", monitor.HTTPProbeConfig{URL: srv.URL + "/missing"}, ResultStatusMismatch},
+		{"expected status", monitor.HTTPProbeConfig{URL: srv.URL + "/teapot", ExpectStatus: []int{418}}, ResultSuccess},
+		{"body mismatch", monitor.HTTPProbeConfig{URL: srv.URL, BodyRegex: "healthy"}, ResultBodyMismatch},
+		{"json value mismatch", monitor.HTTPProbeConfig{URL: srv.URL, JSONPath: "status", JSONValue: "healthy"}, ResultJSONMismatch},
+		{"json path missing", monitor.HTTPProbeConfig{URL: srv.URL, JSONPath: "checks.db"}, ResultJSONMismatch},
+		{"json path exists", monitor.HTTPProbeConfig{URL: srv.URL, JSONPath: "status"}, ResultSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := probeOnce(t, probeConfig(tt.probe))[0]
+			if m.Fields["result"] != tt.result {
+				t.Errorf("result = %q, want %q", m.Fields["result"], tt.result)
+			}
+			if m.Fields["success"] != (tt.result == ResultSuccess) {
+				t.Errorf("success = %v", m.Fields["success"])
+			}
+		})
+	}
+}
+
+func TestHTTPProbeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	m := probeOnce(t, probeConfig(monitor.HTTPProbeConfig{
+		URL:     srv.URL,
+		Timeout: monitor.Duration{Duration: 50 * time.Millisecond},
+	}))[0]
+	if m.Fields["result"] != ResultTimeout || m.Fields["success"] != false {
+		t.Errorf("result = %q, success = %v", m.Fields["result"], m.Fields["success"])
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("probe took %v, want about 50ms", elapsed)
+	}
+}
+
+func TestHTTPProbeConnectionRefused(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	m := probeOnce(t, probeConfig(monitor.HTTPProbeConfig{URL: url}))[0]
+	if m.Fields["result"] != ResultConnectionFailed {
+		t.Errorf("result = %q, want %q", m.Fields["result"], ResultConnectionFailed)
+	}
+	if _, ok := m.Fields["status_code"]; ok {
+		t.Error("status_code should be absent when no response was received")
+	}
+}
+
+func TestHTTPProbeTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	metrics := probeOnce(t, probeConfig(
+		monitor.HTTPProbeConfig{Name: "trusted", URL: srv.URL, InsecureSkipVerify: true},
+		monitor.HTTPProbeConfig{Name: "untrusted", URL: srv.URL},
+	))
+
+	trusted := metrics[0]
+	if trusted.Fields["result"] != ResultSuccess {
+		t.Fatalf("result = %q, want success", trusted.Fields["result"])
+	}
+	expiresIn, ok := trusted.Fields["cert_expires_in"].(float64)
+	if !ok || expiresIn <= 0 {
+		t.Errorf("cert_expires_in = %v, want > 0", trusted.Fields["cert_expires_in"])
+	}
+	if trusted.FieldKind("cert_expires_in") != monitor.KindGauge || trusted.FieldUnit("cert_expires_in") != monitor.UnitSeconds ||
+		trusted.FieldHelp("cert_expires_in") == "" {
+		t.Error("cert_expires_in should be a documented gauge in seconds")
+	}
+	if _, ok := trusted.Fields["tls_time"].(float64); !ok {
+		t.Error("missing tls_time")
+	}
+
+	if untrusted := metrics[1]; untrusted.Fields["result"] != ResultTLSError {
+		t.Errorf("untrusted result = %q, want %q", untrusted.Fields["result"], ResultTLSError)
+	}
+}
+
+func TestHTTPProbeDefaults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	m := probeOnce(t, monitor.ProbesConfig{HTTP: []monitor.HTTPProbeConfig{{URL: srv.URL}}})[0]
+	if _, ok := m.Tags["target"]; ok || m.Tags["method"] != http.MethodGet {
+		t.Errorf("tags = %v, want no target for an unnamed probe and GET", m.Tags)
+	}
+}
+
+func TestHTTPInvalidRegex(t *testing.T) {
+	_, err := HTTP(probeConfig(monitor.HTTPProbeConfig{URL: "http://localhost", BodyRegex: "("}))
+	if err == nil {
+		t.Fatal("expected error for invalid body_regex")
+	}
+}
+
+func TestJSONLookup(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": []interface{}{map[string]interface{}{"b": "x"}},
+	}
+	if v, ok := jsonLookup(doc, []string{"a", "0", "b"}); !ok || v != "x" {
+		t.Errorf("a.0.b = %v, %v", v, ok)
+	}
+	for _, path := range [][]string{{"a", "1"}, {"a", "x"}, {"c"}, {"a", "0", "b", "c"}} {
+		if _, ok := jsonLookup(doc, path); ok {
+			t.Errorf("path %v should not resolve", path)
+		}
+	}
+}
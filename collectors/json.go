@@ -0,0 +1,116 @@
+package collectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// parseJSON parses exec JSON output: a flat object of fields, an explicit
+// metric object, or an array of either.
+func parseJSON(data []byte, measurement string) ([]*monitor.Metric, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var objects []map[string]interface{}
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &objects); err != nil {
+			return nil, err
+		}
+	} else {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	metrics := make([]*monitor.Metric, 0, len(objects))
+	for i, obj := range objects {
+		m, err := jsonMetric(obj, measurement)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %w", i, err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func jsonMetric(obj map[string]interface{}, measurement string) (*monitor.Metric, error) {
+	fields, explicit := obj["fields"].(map[string]interface{})
+	if !explicit {
+		m := monitor.NewMetric(measurement)
+		if err := flattenJSON(m, "", obj); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	if name, ok := obj["measurement"].(string); ok && name != "" {
+		measurement = name
+	}
+	m := monitor.NewMetric(measurement)
+	if err := flattenJSON(m, "", fields); err != nil {
+		return nil, err
+	}
+
+	if tags, ok := obj["tags"].(map[string]interface{}); ok {
+		for k, v := range tags {
+			switch val := v.(type) {
+			case string:
+				m.Tags[k] = val
+			case nil:
+			default:
+				m.Tags[k] = fmt.Sprint(val)
+			}
+		}
+	}
+
+	switch ts := obj["timestamp"].(type) {
+	case nil:
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", ts)
+		}
+		m.Timestamp = t
+	case float64:
+		sec := int64(ts)
+		m.Timestamp = time.Unix(sec, int64((ts-float64(sec))*1e9))
+	default:
+		return nil, fmt.Errorf("invalid timestamp %v", ts)
+	}
+	return m, nil
+}
+
+// flattenJSON adds the values of obj to m as fields, joining nested object
+// keys with "_".
+func flattenJSON(m *monitor.Metric, prefix string, obj map[string]interface{}) error {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "_" + k
+		}
+		switch val := v.(type) {
+		case float64, bool, string:
+			m.Fields[key] = val
+		case nil:
+		case map[string]interface{}:
+			if err := flattenJSON(m, key, val); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("field %q: unsupported value %v", key, val)
+		}
+	}
+	if prefix == "" && len(m.Fields) == 0 {
+		return errors.New("no fields")
+	}
+	return nil
+}
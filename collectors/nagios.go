@@ -0,0 +1,140 @@
+package collectors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// nagiosUnits maps performance data units to a scale factor and unit.
+var nagiosUnits = map[string]struct {
+	scale float64
+	unit  monitor.Unit
+}{
+	"s":  {1, monitor.UnitSeconds},
+	"ms": {1e-3, monitor.UnitSeconds},
+	"us": {1e-6, monitor.UnitSeconds},
+	"B":  {1, monitor.UnitBytes},
+	"KB": {1 << 10, monitor.UnitBytes},
+	"MB": {1 << 20, monitor.UnitBytes},
+	"GB": {1 << 30, monitor.UnitBytes},
+	"TB": {1 << 40, monitor.UnitBytes},
+}
+
+// parseNagios parses Nagios plugin output into a single metric with the
+// exit code as the "state" field and a field per performance data label.
+// Performance data follows the first "|" on the first line and on any line
+// after a later "|".
+func parseNagios(output, measurement string, code int) ([]*monitor.Metric, error) {
+	m := monitor.NewMetric(measurement).WithField("state", int64(code))
+
+	var perf []string
+	lines := strings.Split(output, "\n")
+	if _, data, ok := strings.Cut(lines[0], "|"); ok {
+		perf = append(perf, data)
+	}
+	for i, line := range lines[1:] {
+		if _, data, ok := strings.Cut(line, "|"); ok {
+			perf = append(perf, data)
+			perf = append(perf, lines[i+2:]...)
+			break
+		}
+	}
+
+	for _, data := range perf {
+		if err := parsePerfData(m, data); err != nil {
+			return nil, err
+		}
+	}
+	return []*monitor.Metric{m}, nil
+}
+
+// parsePerfData parses space-separated 'label'=value[UOM];warn;crit;min;max
+// entries into fields of m.
+func parsePerfData(m *monitor.Metric, data string) error {
+	for data = strings.TrimSpace(data); data != ""; data = strings.TrimSpace(data) {
+		label, rest, err := perfLabel(data)
+		if err != nil {
+			return err
+		}
+		entry, remaining, _ := strings.Cut(rest, " ")
+		data = remaining
+
+		parts := strings.Split(entry, ";")
+		value, uom := splitUOM(parts[0])
+		if value == "U" || value == "" {
+			continue // undetermined
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("perfdata %q: invalid value %q", label, parts[0])
+		}
+
+		scale := 1.0
+		if u, ok := nagiosUnits[uom]; ok {
+			scale = u.scale
+			m.WithFieldMeta(label, "", u.unit)
+		}
+		if uom == "c" {
+			m.WithCounter(label, v)
+		} else {
+			m.WithField(label, v*scale)
+		}
+
+		for i, suffix := range []string{"_warn", "_crit", "_min", "_max"} {
+			if i+1 >= len(parts) {
+				break
+			}
+			// Threshold ranges (10:20, @10:20, ~:10) have no single value.
+			if t, err := strconv.ParseFloat(parts[i+1], 64); err == nil {
+				m.WithField(label+suffix, t*scale)
+			}
+		}
+	}
+	return nil
+}
+
+// perfLabel reads a label up to its "=". Quoted labels are wrapped in
+// single quotes, with a doubled single quote standing for a literal one.
+func perfLabel(data string) (label, rest string, err error) {
+	if !strings.HasPrefix(data, "'") {
+		label, rest, ok := strings.Cut(data, "=")
+		if !ok || label == "" || strings.Contains(label, " ") {
+			return "", "", fmt.Errorf("invalid perfdata %q", data)
+		}
+		return label, rest, nil
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(data); i++ {
+		if data[i] != '\'' {
+			sb.WriteByte(data[i])
+			continue
+		}
+		if i+1 < len(data) && data[i+1] == '\'' {
+			sb.WriteByte('\'')
+			i++
+			continue
+		}
+		if i+1 >= len(data) || data[i+1] != '=' {
+			return "", "", fmt.Errorf("invalid perfdata %q", data)
+		}
+		return sb.String(), data[i+2:], nil
+	}
+	return "", "", fmt.Errorf("unterminated perfdata label %q", data)
+}
+
+// splitUOM splits a value such as "12.5ms" into its number and unit.
+func splitUOM(s string) (value, uom string) {
+	i := len(s)
+	for i > 0 && !isNumberByte(s[i-1]) {
+		i--
+	}
+	return s[:i], s[i:]
+}
+
+func isNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || c == '.'
+}
@@ -0,0 +1,125 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// defaultProbeTimeout applies when neither the probe nor the probes section
+// sets a timeout.
+const defaultProbeTimeout = 5 * time.Second
+
+// maxProbeRead bounds how much data a TCP or UDP probe reads while waiting
+// for its expected response.
+const maxProbeRead = 64 << 10
+
+// Probe results, reported in the "result" field. It is a field rather than
+// a tag so a target stays a single series whatever its outcome.
+const (
+	ResultSuccess          = "success"
+	ResultTimeout          = "timeout"
+	ResultDNSError         = "dns_error"
+	ResultTLSError         = "tls_error"
+	ResultConnectionFailed = "connection_failed"
+	ResultReadError        = "read_error"
+	ResultStatusMismatch   = "status_code_mismatch"
+	ResultBodyMismatch     = "body_mismatch"
+	ResultJSONMismatch     = "json_mismatch"
+	ResultExpectMismatch   = "expect_mismatch"
+	ResultAnswerMismatch   = "answer_mismatch"
+)
+
+// prober checks a single target and reports the outcome as a metric.
+type prober interface {
+	probe(ctx context.Context) *monitor.Metric
+}
+
+// Probes returns a CollectFunc that runs every HTTP, TCP, UDP and DNS probe
+// in cfg concurrently, reporting a metric per target.
+func Probes(cfg monitor.ProbesConfig) (monitor.CollectFunc, error) {
+	var probes []prober
+	for _, build := range []func(monitor.ProbesConfig) ([]prober, error){httpProbes, tcpProbes, udpProbes, dnsProbes} {
+		p, err := build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, p...)
+	}
+	return runProbes(probes), nil
+}
+
+// runProbes returns a CollectFunc that runs the probes concurrently. A failed
+// probe is reported in its metric, never returned as an error, so one
+// unreachable target does not hide the others.
+func runProbes(probes []prober) monitor.CollectFunc {
+	return func(ctx context.Context) ([]*monitor.Metric, error) {
+		metrics := make([]*monitor.Metric, len(probes))
+		var wg sync.WaitGroup
+		for i, p := range probes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				metrics[i] = p.probe(ctx)
+			}()
+		}
+		wg.Wait()
+		return metrics, nil
+	}
+}
+
+// probeTimeout returns the probe's own timeout, falling back to the probes
+// section's and then to defaultProbeTimeout.
+func probeTimeout(own, section monitor.Duration) time.Duration {
+	if own.Duration > 0 {
+		return own.Duration
+	}
+	if section.Duration > 0 {
+		return section.Duration
+	}
+	return defaultProbeTimeout
+}
+
+// probeMetric starts a probe's metric with its common tags, including the
+// target's name when it has one.
+func probeMetric(measurement string, tags map[string]string, target string) *monitor.Metric {
+	m := monitor.NewMetric(measurement).WithTags(tags)
+	if target != "" {
+		m.WithTag("target", target)
+	}
+	return m
+}
+
+// finish sets the result and success fields of a probe's metric.
+func finish(m *monitor.Metric, result string) *monitor.Metric {
+	return m.WithField("result", result).WithGauge("success", result == ResultSuccess)
+}
+
+// setLatency adds a latency field in seconds.
+func setLatency(m *monitor.Metric, name string, d time.Duration) {
+	m.WithGauge(name, d.Seconds())
+	m.WithFieldMeta(name, "", monitor.UnitSeconds)
+}
+
+// classifyNetError maps a dial, read or write error to a probe result.
+func classifyNetError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ResultTimeout
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsTimeout {
+			return ResultTimeout
+		}
+		return ResultDNSError
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ResultTimeout
+	default:
+		return ResultConnectionFailed
+	}
+}
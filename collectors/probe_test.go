@@ -0,0 +1,335 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+func runProbeConfig(t *testing.T, build func(monitor.ProbesConfig) (monitor.CollectFunc, error), cfg monitor.ProbesConfig) []*monitor.Metric {
+	t.Helper()
+	if cfg.Timeout.Duration == 0 {
+		cfg.Timeout = monitor.Duration{Duration: 2 * time.Second}
+	}
+	collect, err := build(cfg)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	metrics, err := collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	return metrics
+}
+
+func checkResult(t *testing.T, m *monitor.Metric, want string) {
+	t.Helper()
+	if m.Fields["result"] != want {
+		t.Errorf("%s: result = %q, want %q", m.Tags["target"], m.Fields["result"], want)
+	}
+	if m.Fields["success"] != (want == ResultSuccess) {
+		t.Errorf("%s: success = %v", m.Tags["target"], m.Fields["success"])
+	}
+}
+
+// startTCPEcho starts a line-oriented server that greets each client and
+// answers "PING" with "PONG".
+func startTCPEcho(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("220 ready\r\n"))
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err == nil && strings.TrimSpace(line) == "PING" {
+					conn.Write([]byte("PONG\r\n"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestTCPProbe(t *testing.T) {
+	addr := startTCPEcho(t)
+	closed := func() string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		return ln.Addr().String()
+	}()
+
+	metrics := runProbeConfig(t, TCP, monitor.ProbesConfig{TCP: []monitor.TCPProbeConfig{
+		{Name: "connect", Address: addr},
+		{Name: "banner", Address: addr, Expect: `^220 `},
+		{Name: "ping", Address: addr, Send: "PING\r\n", Expect: "PONG"},
+		{Name: "mismatch", Address: addr, Send: "PING\r\n", Expect: "HELLO"},
+		{Name: "closed", Address: closed},
+	}})
+
+	checkResult(t, metrics[0], ResultSuccess)
+	checkResult(t, metrics[1], ResultSuccess)
+	checkResult(t, metrics[2], ResultSuccess)
+	checkResult(t, metrics[3], ResultExpectMismatch)
+	checkResult(t, metrics[4], ResultConnectionFailed)
+
+	ping := metrics[2]
+	if ping.Measurement != "tcp_probe" || ping.Tags["address"] != addr {
+		t.Errorf("metric = %s %v", ping.Measurement, ping.Tags)
+	}
+	for _, f := range []string{"connect_time", "response_time", "total_time"} {
+		if _, ok := ping.Fields[f].(float64); !ok {
+			t.Errorf("missing %s", f)
+		}
+	}
+	if _, ok := metrics[0].Fields["response_time"]; ok {
+		t.Error("response_time should be absent without send or expect")
+	}
+}
+
+func TestTCPProbeTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept and stay silent.
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	metrics := runProbeConfig(t, TCP, monitor.ProbesConfig{TCP: []monitor.TCPProbeConfig{
+		{Address: ln.Addr().String(), Expect: "hello", Timeout: monitor.Duration{Duration: 50 * time.Millisecond}},
+	}})
+	checkResult(t, metrics[0], ResultTimeout)
+	if _, ok := metrics[0].Tags["target"]; ok || metrics[0].Tags["address"] != ln.Addr().String() {
+		t.Errorf("tags = %v, want the address and no target for an unnamed probe", metrics[0].Tags)
+	}
+}
+
+func TestUDPProbe(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if string(buf[:n]) == "ping" {
+				pc.WriteTo([]byte("pong"), addr)
+			}
+		}
+	}()
+	addr := pc.LocalAddr().String()
+
+	metrics := runProbeConfig(t, UDP, monitor.ProbesConfig{UDP: []monitor.UDPProbeConfig{
+		{Name: "any", Address: addr, Send: "ping"},
+		{Name: "expect", Address: addr, Send: "ping", Expect: "^pong$"},
+		{Name: "mismatch", Address: addr, Send: "ping", Expect: "^PONG$"},
+		{Name: "silent", Address: addr, Send: "hello", Timeout: monitor.Duration{Duration: 50 * time.Millisecond}},
+	}})
+
+	checkResult(t, metrics[0], ResultSuccess)
+	checkResult(t, metrics[1], ResultSuccess)
+	checkResult(t, metrics[2], ResultExpectMismatch)
+	checkResult(t, metrics[3], ResultTimeout)
+	if _, ok := metrics[1].Fields["response_time"].(float64); !ok {
+		t.Error("missing response_time")
+	}
+	if metrics[1].Measurement != "udp_probe" {
+		t.Errorf("Measurement = %q", metrics[1].Measurement)
+	}
+}
+
+func TestUDPProbeRequiresSend(t *testing.T) {
+	if _, err := UDP(monitor.ProbesConfig{UDP: []monitor.UDPProbeConfig{{Address: "127.0.0.1:53"}}}); err == nil {
+		t.Fatal("expected error without send")
+	}
+}
+
+// startDNS starts a minimal DNS server on UDP that answers A queries for
+// example.test, TXT queries for txt.test and PTR queries for 192.0.2.1, and
+// NXDOMAIN otherwise.
+func startDNS(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := dnsAnswer(buf[:n]); resp != nil {
+				pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func dnsAnswer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	// Question: labels up to the zero byte, then type and class.
+	end := 12
+	var labels []string
+	for end < len(query) && query[end] != 0 {
+		l := int(query[end])
+		labels = append(labels, string(query[end+1:end+1+l]))
+		end += 1 + l
+	}
+	end++
+	if end+4 > len(query) {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[end:])
+	question := query[12 : end+4]
+	name := strings.ToLower(strings.Join(labels, "."))
+
+	var rdata [][]byte
+	switch {
+	case name == "example.test" && qtype == 1: // A
+		rdata = [][]byte{{192, 0, 2, 1}, {192, 0, 2, 2}}
+	case name == "txt.test" && qtype == 16: // TXT
+		rdata = [][]byte{append([]byte{7}, "v=test1"...)}
+	case name == "1.2.0.192.in-addr.arpa" && qtype == 12: // PTR
+		rdata = [][]byte{append(append([]byte{4}, "host"...), append([]byte{4}, "test\x00"...)...)}
+	}
+
+	resp := []byte{query[0], query[1], 0x81, 0x80, 0, 1, 0, byte(len(rdata)), 0, 0, 0, 0}
+	if rdata == nil && name != "example.test" && name != "txt.test" {
+		resp[3] |= 3 // NXDOMAIN
+	}
+	resp = append(resp, question...)
+	for _, rd := range rdata {
+		resp = append(resp, 0xc0, 12) // pointer to the question name
+		resp = binary.BigEndian.AppendUint16(resp, qtype)
+		resp = append(resp, 0, 1, 0, 0, 0, 60)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(rd)))
+		resp = append(resp, rd...)
+	}
+	return resp
+}
+
+func TestDNSProbe(t *testing.T) {
+	server := startDNS(t)
+
+	metrics := runProbeConfig(t, DNS, monitor.ProbesConfig{DNS: []monitor.DNSProbeConfig{
+		{Name: "a", Server: server, Query: "example.test.", Expect: []string{"192.0.2.2"}},
+		{Name: "txt", Server: server, Query: "txt.test.", Type: "txt", Expect: []string{"v=test1"}},
+		{Name: "wrong", Server: server, Query: "example.test.", Expect: []string{"192.0.2.9"}},
+		{Name: "nxdomain", Server: server, Query: "missing.test."},
+		{Name: "relative", Server: server, Query: "example.test"},
+		{Name: "ptr", Server: server, Query: "192.0.2.1", Type: "PTR", Expect: []string{"host.test"}},
+		// The server is asked even for names the hosts file knows.
+		{Name: "hosts", Server: server, Query: "localhost"},
+	}})
+
+	checkResult(t, metrics[0], ResultSuccess)
+	checkResult(t, metrics[1], ResultSuccess)
+	checkResult(t, metrics[2], ResultAnswerMismatch)
+	checkResult(t, metrics[3], ResultDNSError)
+	checkResult(t, metrics[4], ResultSuccess)
+	checkResult(t, metrics[5], ResultSuccess)
+	checkResult(t, metrics[6], ResultDNSError)
+
+	a := metrics[0]
+	if a.Measurement != "dns_probe" {
+		t.Errorf("Measurement = %q", a.Measurement)
+	}
+	wantTags := map[string]string{"server": server, "query": "example.test.", "type": "A"}
+	for k, v := range wantTags {
+		if a.Tags[k] != v {
+			t.Errorf("tag %s = %q, want %q", k, a.Tags[k], v)
+		}
+	}
+	if a.Fields["answers"] != int64(2) {
+		t.Errorf("answers = %v, want 2", a.Fields["answers"])
+	}
+	if _, ok := a.Fields["query_time"].(float64); !ok {
+		t.Error("missing query_time")
+	}
+}
+
+func TestDNSProbeDefaultPort(t *testing.T) {
+	probes, err := dnsProbes(monitor.ProbesConfig{DNS: []monitor.DNSProbeConfig{{Server: "192.0.2.53", Query: "x"}}})
+	if err != nil {
+		t.Fatalf("dnsProbes() error: %v", err)
+	}
+	if got := probes[0].(*dnsProbe).server; got != "192.0.2.53:53" {
+		t.Errorf("server = %q, want 192.0.2.53:53", got)
+	}
+}
+
+func TestNormalizeAnswer(t *testing.T) {
+	tests := map[string]string{
+		"Mail.Example.COM.":    "mail.example.com",
+		"2001:DB8:0:0:0:0:0:1": "2001:db8::1",
+		"192.0.2.1":            "192.0.2.1",
+	}
+	for in, want := range tests {
+		if got := normalizeAnswer(in); got != want {
+			t.Errorf("normalizeAnswer(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProbesAllTypes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	addr := startTCPEcho(t)
+	dns := startDNS(t)
+
+	metrics := runProbeConfig(t, Probes, monitor.ProbesConfig{
+		HTTP: []monitor.HTTPProbeConfig{{URL: srv.URL}},
+		TCP:  []monitor.TCPProbeConfig{{Address: addr}},
+		DNS:  []monitor.DNSProbeConfig{{Server: dns, Query: "example.test."}},
+	})
+
+	measurements := make(map[string]bool)
+	for _, m := range metrics {
+		measurements[m.Measurement] = true
+		checkResult(t, m, ResultSuccess)
+	}
+	for _, want := range []string{"http_probe", "tcp_probe", "dns_probe"} {
+		if !measurements[want] {
+			t.Errorf("missing %s metric", want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+type tcpProbe struct {
+	cfg     monitor.TCPProbeConfig
+	name    string
+	timeout time.Duration
+	expect  *regexp.Regexp
+}
+
+// TCP returns a CollectFunc that probes every target in cfg.TCP concurrently
+// and reports a "tcp_probe" metric per target, tagged with the target's
+// name (if it has one) and address. Each probe connects, writes send if set,
+// and reads until the data matches expect if set. Fields:
+//
+//	result         the outcome, such as "success" or "timeout"
+//	success        whether the connection (and expect) succeeded
+//	connect_time   seconds to establish the connection
+//	response_time  seconds from sending until expect matched
+//	total_time     seconds for the whole probe
+func TCP(cfg monitor.ProbesConfig) (monitor.CollectFunc, error) {
+	probes, err := tcpProbes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return runProbes(probes), nil
+}
+
+func tcpProbes(cfg monitor.ProbesConfig) ([]prober, error) {
+	probes := make([]prober, 0, len(cfg.TCP))
+	for i, pc := range cfg.TCP {
+		p := &tcpProbe{
+			cfg:     pc,
+			name:    pc.Name,
+			timeout: probeTimeout(pc.Timeout, cfg.Timeout),
+		}
+		if pc.Expect != "" {
+			re, err := regexp.Compile(pc.Expect)
+			if err != nil {
+				return nil, fmt.Errorf("probes.tcp[%d].expect: %w", i, err)
+			}
+			p.expect = re
+		}
+		probes = append(probes, p)
+	}
+	return probes, nil
+}
+
+func (p *tcpProbe) probe(ctx context.Context) *monitor.Metric {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	m := probeMetric("tcp_probe", p.cfg.Tags, p.name).WithTag("address", p.cfg.Address)
+	start := time.Now()
+	defer func() { setLatency(m, "total_time", time.Since(start)) }()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.cfg.Address)
+	if err != nil {
+		return finish(m, classifyNetError(err))
+	}
+	defer conn.Close()
+	setLatency(m, "connect_time", time.Since(start))
+
+	if p.cfg.Send == "" && p.expect == nil {
+		return finish(m, ResultSuccess)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sent := time.Now()
+	if p.cfg.Send != "" {
+		if _, err := io.WriteString(conn, p.cfg.Send); err != nil {
+			return finish(m, classifyNetError(err))
+		}
+	}
+	if p.expect != nil {
+		if result := readExpect(conn, p.expect); result != ResultSuccess {
+			return finish(m, result)
+		}
+	}
+	setLatency(m, "response_time", time.Since(sent))
+	return finish(m, ResultSuccess)
+}
+
+// readExpect reads from r until the data read so far matches re. It gives
+// up with a mismatch at EOF or after maxProbeRead bytes.
+func readExpect(r io.Reader, re *regexp.Regexp) string {
+	buf := make([]byte, 0, 4096)
+	for len(buf) < maxProbeRead {
+		if len(buf) == cap(buf) {
+			buf = append(buf, 0)[:len(buf)]
+		}
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if re.Match(buf) {
+			return ResultSuccess
+		}
+		if errors.Is(err, io.EOF) {
+			return ResultExpectMismatch
+		}
+		if err != nil {
+			if result := classifyNetError(err); result == ResultTimeout {
+				return result
+			}
+			return ResultReadError
+		}
+	}
+	return ResultExpectMismatch
+}
@@ -0,0 +1,94 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+type udpProbe struct {
+	cfg     monitor.UDPProbeConfig
+	name    string
+	timeout time.Duration
+	expect  *regexp.Regexp
+}
+
+// UDP returns a CollectFunc that probes every target in cfg.UDP concurrently
+// and reports a "udp_probe" metric per target, tagged with the target's
+// name (if it has one) and address. Each probe sends one datagram and waits
+// for a response, which must match expect if set. Fields:
+//
+//	result         the outcome, such as "success" or "timeout"
+//	success        whether a (matching) response arrived in time
+//	response_time  seconds from sending until the response arrived
+//	total_time     seconds for the whole probe
+func UDP(cfg monitor.ProbesConfig) (monitor.CollectFunc, error) {
+	probes, err := udpProbes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return runProbes(probes), nil
+}
+
+func udpProbes(cfg monitor.ProbesConfig) ([]prober, error) {
+	probes := make([]prober, 0, len(cfg.UDP))
+	for i, pc := range cfg.UDP {
+		if pc.Send == "" {
+			return nil, fmt.Errorf("probes.udp[%d].send: is required", i)
+		}
+		p := &udpProbe{
+			cfg:     pc,
+			name:    pc.Name,
+			timeout: probeTimeout(pc.Timeout, cfg.Timeout),
+		}
+		if pc.Expect != "" {
+			re, err := regexp.Compile(pc.Expect)
+			if err != nil {
+				return nil, fmt.Errorf("probes.udp[%d].expect: %w", i, err)
+			}
+			p.expect = re
+		}
+		probes = append(probes, p)
+	}
+	return probes, nil
+}
+
+func (p *udpProbe) probe(ctx context.Context) *monitor.Metric {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	m := probeMetric("udp_probe", p.cfg.Tags, p.name).WithTag("address", p.cfg.Address)
+	start := time.Now()
+	defer func() { setLatency(m, "total_time", time.Since(start)) }()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", p.cfg.Address)
+	if err != nil {
+		return finish(m, classifyNetError(err))
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sent := time.Now()
+	if _, err := conn.Write([]byte(p.cfg.Send)); err != nil {
+		return finish(m, classifyNetError(err))
+	}
+	buf := make([]byte, maxProbeRead)
+	n, err := conn.Read(buf)
+	if err != nil {
+		// A closed port shows up as a refused connection on the read.
+		return finish(m, classifyNetError(err))
+	}
+	setLatency(m, "response_time", time.Since(sent))
+
+	if p.expect != nil && !p.expect.Match(buf[:n]) {
+		return finish(m, ResultExpectMismatch)
+	}
+	return finish(m, ResultSuccess)
+}
@@ -10,18 +10,50 @@
 
 // Config represents the common monitoring configuration.
 type Config struct {
-	Global     GlobalConfig     `toml:"global"`
-	InfluxDB   InfluxDBConfig   `toml:"influxdb"`
-	Prometheus PrometheusConfig `toml:"prometheus"`
+	Global         GlobalConfig         `toml:"global"`
+	InfluxDB       InfluxDBConfig       `toml:"influxdb"`
+	Prometheus     PrometheusConfig     `toml:"prometheus"`
+	Spool          SpoolConfig          `toml:"spool"`
+	Telemetry      TelemetryConfig      `toml:"telemetry"`
+	Admin          AdminConfig          `toml:"admin"`
+	Host           HostConfig           `toml:"host"`
+	Probes         ProbesConfig         `toml:"probes"`
+	Scrape         ScrapeConfig         `toml:"scrape"`
+	StatsD         StatsDConfig         `toml:"statsd"`
+	InfluxListener InfluxListenerConfig `toml:"influx_listener"`
+	Processors     ProcessorsConfig     `toml:"processors"`
+	// Filters holds a Filter per backend, keyed by backend name under
+	// [filters.<backend>].
+	Filters    map[string]Filter          `toml:"filters"`
+	Collectors map[string]CollectorConfig `toml:"collectors"`
 }
 
 // GlobalConfig contains global application settings.
 type GlobalConfig struct {
-	PollInterval  Duration `toml:"poll_interval"`
-	LogLevel      string   `toml:"log_level"`
-	BatchSize     int      `toml:"batch_size"`
-	RetryAttempts int      `toml:"retry_attempts"`
-	RetryDelay    Duration `toml:"retry_delay"`
+	PollInterval     Duration       `toml:"poll_interval"`
+	CollectTimeout   Duration       `toml:"collect_timeout"`
+	OverlapPolicy    OverlapPolicy  `toml:"overlap_policy"`
+	LogLevel         string         `toml:"log_level"`
+	BatchSize        int            `toml:"batch_size"`
+	RetryAttempts    int            `toml:"retry_attempts"`
+	RetryDelay       Duration       `toml:"retry_delay"`
+	RetryMaxDelay    Duration       `toml:"retry_max_delay"`
+	QueueSize        int            `toml:"queue_size"`
+	BreakerThreshold int            `toml:"breaker_threshold"`
+	BreakerCooldown  Duration       `toml:"breaker_cooldown"`
+	MaxBufferSize    int            `toml:"max_buffer_size"`
+	OverflowPolicy   OverflowPolicy `toml:"overflow_policy"`
+	BlockTimeout     Duration       `toml:"block_timeout"`
+	TripOnPanic      bool           `toml:"trip_on_panic"`
+}
+
+// CollectorConfig contains per-collector overrides, keyed by collector name
+// under [collectors.<name>].
+type CollectorConfig struct {
+	PollInterval   Duration      `toml:"poll_interval"`
+	CollectTimeout Duration      `toml:"collect_timeout"`
+	OverlapPolicy  OverlapPolicy `toml:"overlap_policy"`
+	Disabled       bool          `toml:"disabled"`
 }
 
 // InfluxDBConfig contains InfluxDB connection settings.
@@ -31,6 +63,8 @@ type InfluxDBConfig struct {
 	Token   string `toml:"token"`
 	Org     string `toml:"org"`
 	Bucket  string `toml:"bucket"`
+	// UnitTag, when set, is the tag that carries a point's unit.
+	UnitTag string `toml:"unit_tag"`
 }
 
 // PrometheusConfig contains Prometheus exporter settings.
@@ -40,6 +74,254 @@ type PrometheusConfig struct {
 	Path    string `toml:"path"`
 }
 
+// SpoolConfig contains on-disk spool settings for undelivered batches.
+// Each backend spools into its own subdirectory of Dir.
+type SpoolConfig struct {
+	Enabled bool     `toml:"enabled"`
+	Dir     string   `toml:"dir"`
+	MaxSize int64    `toml:"max_size"`
+	MaxAge  Duration `toml:"max_age"`
+}
+
+// TelemetryConfig controls the built-in collector that reports the
+// monitor's own health through the pipeline.
+type TelemetryConfig struct {
+	Enabled      bool     `toml:"enabled"`
+	PollInterval Duration `toml:"poll_interval"`
+	Prefix       string   `toml:"prefix"`
+}
+
+// AdminConfig contains settings for the admin HTTP server.
+type AdminConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Addr    string `toml:"addr"`
+	// ReadyFailures is how many consecutive failed polls of any collector
+	// make the monitor report not ready.
+	ReadyFailures int `toml:"ready_failures"`
+}
+
+// HostConfig contains settings for the hostmetrics collectors.
+type HostConfig struct {
+	Enabled bool `toml:"enabled"`
+	// ProcRoot is where procfs is mounted, e.g. /host/proc in a container.
+	ProcRoot string `toml:"proc_root"`
+	// RootFS is prepended to mount points before reading filesystem usage.
+	RootFS string `toml:"rootfs"`
+	// Collectors lists the collectors to run; empty means all of them.
+	Collectors       []string `toml:"collectors"`
+	PerCPU           bool     `toml:"per_cpu"`
+	IgnoreDevices    []string `toml:"ignore_devices"`
+	IgnoreInterfaces []string `toml:"ignore_interfaces"`
+	IgnoreFSTypes    []string `toml:"ignore_fs_types"`
+	// PIDs are other processes, such as sidecars, that the process
+	// collector reports alongside the monitor itself.
+	PIDs []int `toml:"pids"`
+}
+
+// ProbesConfig contains the targets of the probe collectors.
+type ProbesConfig struct {
+	// Timeout bounds each probe that does not set its own.
+	Timeout Duration          `toml:"timeout"`
+	HTTP    []HTTPProbeConfig `toml:"http"`
+	TCP     []TCPProbeConfig  `toml:"tcp"`
+	UDP     []UDPProbeConfig  `toml:"udp"`
+	DNS     []DNSProbeConfig  `toml:"dns"`
+}
+
+// HTTPProbeConfig describes an HTTP(S) endpoint to probe.
+type HTTPProbeConfig struct {
+	Name    string            `toml:"name"`
+	URL     string            `toml:"url"`
+	Method  string            `toml:"method"`
+	Headers map[string]string `toml:"headers"`
+	Body    string            `toml:"body"`
+	Timeout Duration          `toml:"timeout"`
+	// ExpectStatus lists the status codes that count as success; empty
+	// means any 2xx or 3xx.
+	ExpectStatus []int `toml:"expect_status"`
+	// BodyRegex, when set, must match the response body.
+	BodyRegex string `toml:"body_regex"`
+	// JSONPath, when set, must resolve in the JSON response body, and to
+	// JSONValue if that is set too. Path segments are separated by dots;
+	// numeric segments index arrays.
+	JSONPath           string            `toml:"json_path"`
+	JSONValue          string            `toml:"json_value"`
+	InsecureSkipVerify bool              `toml:"insecure_skip_verify"`
+	Tags               map[string]string `toml:"tags"`
+}
+
+// TCPProbeConfig describes a TCP endpoint to probe. The probe connects and,
+// optionally, sends a request and waits for a response matching Expect.
+type TCPProbeConfig struct {
+	Name    string `toml:"name"`
+	Address string `toml:"address"`
+	Send    string `toml:"send"`
+	// Expect is a regular expression the data read back must match.
+	Expect  string            `toml:"expect"`
+	Timeout Duration          `toml:"timeout"`
+	Tags    map[string]string `toml:"tags"`
+}
+
+// UDPProbeConfig describes a UDP endpoint to probe with a request datagram.
+type UDPProbeConfig struct {
+	Name    string `toml:"name"`
+	Address string `toml:"address"`
+	Send    string `toml:"send"`
+	// Expect is a regular expression the response must match; empty means
+	// any response.
+	Expect  string            `toml:"expect"`
+	Timeout Duration          `toml:"timeout"`
+	Tags    map[string]string `toml:"tags"`
+}
+
+// DNSProbeConfig describes a DNS lookup to perform against a resolver.
+type DNSProbeConfig struct {
+	Name string `toml:"name"`
+	// Server is the resolver's address; the port defaults to 53. The
+	// query is sent to it directly, bypassing the hosts file and search
+	// domains. Empty means the system resolver.
+	Server string `toml:"server"`
+	Query  string `toml:"query"`
+	// Type is the record type: A (default), AAAA, CNAME, MX, NS, TXT or PTR.
+	Type string `toml:"type"`
+	// Network is "udp" (default) or "tcp".
+	Network string `toml:"network"`
+	// Expect lists answers that must all be present.
+	Expect  []string          `toml:"expect"`
+	Timeout Duration          `toml:"timeout"`
+	Tags    map[string]string `toml:"tags"`
+}
+
+// ScrapeConfig contains the Prometheus exposition endpoints to scrape.
+type ScrapeConfig struct {
+	// Timeout bounds each scrape that does not set its own.
+	Timeout Duration             `toml:"timeout"`
+	Targets []ScrapeTargetConfig `toml:"targets"`
+}
+
+// ScrapeTargetConfig describes a Prometheus exposition endpoint.
+type ScrapeTargetConfig struct {
+	// Name is reported as the "job" tag when set.
+	Name               string            `toml:"name"`
+	URL                string            `toml:"url"`
+	Headers            map[string]string `toml:"headers"`
+	Timeout            Duration          `toml:"timeout"`
+	InsecureSkipVerify bool              `toml:"insecure_skip_verify"`
+	// Tags are added to every scraped metric that lacks a label of the
+	// same name.
+	Tags map[string]string `toml:"tags"`
+}
+
+// StatsDConfig contains settings for the StatsD/DogStatsD listener.
+type StatsDConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Addr    string `toml:"addr"`
+	// FlushInterval is how often aggregated metrics are pushed.
+	FlushInterval Duration `toml:"flush_interval"`
+	// Percentiles are reported for timers, histograms and distributions.
+	Percentiles []float64 `toml:"percentiles"`
+	// DeleteGauges stops reporting a gauge after a flush interval without
+	// updates; by default its last value is reported every flush.
+	DeleteGauges bool `toml:"delete_gauges"`
+	// MaxSamples caps the values kept per timer series each interval for
+	// computing percentiles; counts, sums and extremes stay exact. Zero
+	// means no limit.
+	MaxSamples int               `toml:"max_samples"`
+	Tags       map[string]string `toml:"tags"`
+}
+
+// InfluxListenerConfig contains settings for the InfluxDB line protocol
+// listener.
+type InfluxListenerConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Addr is the HTTP listen address; empty disables HTTP.
+	Addr string `toml:"addr"`
+	// UDPAddr, when set, also accepts line protocol datagrams.
+	UDPAddr string `toml:"udp_addr"`
+	// UDPPrecision is the timestamp precision of UDP datagrams.
+	UDPPrecision Precision `toml:"udp_precision"`
+	// MaxBodySize limits a write request in bytes, after decompression.
+	MaxBodySize int64 `toml:"max_body_size"`
+	// Token, when set, must be sent as "Authorization: Token <token>".
+	Token string `toml:"token"`
+}
+
+// ProcessorsConfig lists the processors that transform metrics before
+// delivery, each section under [[processors.<type>]]. See Processor.
+type ProcessorsConfig struct {
+	Rename  []RenameProcessorConfig  `toml:"rename"`
+	Tags    []TagsProcessorConfig    `toml:"tags"`
+	Fields  []FieldsProcessorConfig  `toml:"fields"`
+	Regex   []RegexProcessorConfig   `toml:"regex"`
+	Convert []ConvertProcessorConfig `toml:"convert"`
+	Scale   []ScaleProcessorConfig   `toml:"scale"`
+}
+
+// ProcessorScope holds the settings shared by every processor section.
+type ProcessorScope struct {
+	// Order places the processor in its chain; lower runs first. Ties run
+	// in the order of the sections in ProcessorsConfig, then as listed.
+	Order int `toml:"order"`
+	// Measurements restricts the processor to measurements matching one of
+	// these glob patterns; empty means every measurement.
+	Measurements []string `toml:"measurements"`
+	// Backends, when set, makes the processor apply only to what is
+	// delivered to the named backends instead of to everything collected.
+	Backends []string `toml:"backends"`
+}
+
+// RenameProcessorConfig renames measurements, tags and fields, each map
+// going from the old name to the new one.
+type RenameProcessorConfig struct {
+	ProcessorScope
+	Measurement map[string]string `toml:"measurement"`
+	Tags        map[string]string `toml:"tags"`
+	Fields      map[string]string `toml:"fields"`
+}
+
+// TagsProcessorConfig drops and adds tags. Drop takes glob patterns.
+type TagsProcessorConfig struct {
+	ProcessorScope
+	Add       map[string]string `toml:"add"`
+	Drop      []string          `toml:"drop"`
+	Overwrite bool              `toml:"overwrite"`
+}
+
+// FieldsProcessorConfig drops and adds fields. Drop takes glob patterns.
+type FieldsProcessorConfig struct {
+	ProcessorScope
+	Add       map[string]interface{} `toml:"add"`
+	Drop      []string               `toml:"drop"`
+	Overwrite bool                   `toml:"overwrite"`
+}
+
+// RegexProcessorConfig rewrites a tag's value with a regular expression.
+type RegexProcessorConfig struct {
+	ProcessorScope
+	Tag     string `toml:"tag"`
+	Pattern string `toml:"pattern"`
+	// Replacement may refer to capture groups as $1 or ${name}.
+	Replacement string `toml:"replacement"`
+	// ResultTag, when set, receives the result instead of Tag.
+	ResultTag string `toml:"result_tag"`
+}
+
+// ConvertProcessorConfig converts fields, named by glob patterns, to Type.
+type ConvertProcessorConfig struct {
+	ProcessorScope
+	Fields []string  `toml:"fields"`
+	Type   FieldType `toml:"type"`
+}
+
+// ScaleProcessorConfig multiplies numeric fields, named by glob patterns,
+// by Factor and adds Offset.
+type ScaleProcessorConfig struct {
+	ProcessorScope
+	Fields []string `toml:"fields"`
+	Factor float64  `toml:"factor"`
+	Offset float64  `toml:"offset"`
+}
+
 // Duration is a wrapper around time.Duration that supports TOML parsing.
 type Duration struct {
 	time.Duration
@@ -61,11 +343,19 @@ func (d Duration) MarshalText() ([]byte, error) {
 func DefaultConfig() *Config {
 	return &Config{
 		Global: GlobalConfig{
-			PollInterval:  Duration{10 * time.Second},
-			LogLevel:      "info",
-			BatchSize:     10,
-			RetryAttempts: 3,
-			RetryDelay:    Duration{1 * time.Second},
+			PollInterval:     Duration{10 * time.Second},
+			OverlapPolicy:    OverlapSkip,
+			LogLevel:         "info",
+			BatchSize:        10,
+			RetryAttempts:    3,
+			RetryDelay:       Duration{1 * time.Second},
+			RetryMaxDelay:    Duration{30 * time.Second},
+			QueueSize:        100,
+			BreakerThreshold: 3,
+			BreakerCooldown:  Duration{30 * time.Second},
+			MaxBufferSize:    10000,
+			OverflowPolicy:   OverflowDropOldest,
+			BlockTimeout:     Duration{1 * time.Second},
 		},
 		InfluxDB: InfluxDBConfig{
 			Enabled: false,
@@ -75,6 +365,47 @@ func DefaultConfig() *Config {
 			Port:    9090,
 			Path:    "/metrics",
 		},
+		Spool: SpoolConfig{
+			Enabled: false,
+			MaxSize: 100 * 1024 * 1024,
+			MaxAge:  Duration{24 * time.Hour},
+		},
+		Telemetry: TelemetryConfig{
+			Prefix: "gomonitor",
+		},
+		Admin: AdminConfig{
+			Addr:          "127.0.0.1:8081",
+			ReadyFailures: 3,
+		},
+		Host: HostConfig{
+			ProcRoot:         "/proc",
+			PerCPU:           true,
+			IgnoreDevices:    []string{"loop*", "ram*"},
+			IgnoreInterfaces: []string{"lo"},
+			IgnoreFSTypes: []string{
+				"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs",
+				"debugfs", "devpts", "devtmpfs", "fusectl", "hugetlbfs", "mqueue",
+				"nsfs", "overlay", "proc", "pstore", "rpc_pipefs", "securityfs",
+				"squashfs", "sysfs", "tmpfs", "tracefs",
+			},
+		},
+		Probes: ProbesConfig{
+			Timeout: Duration{5 * time.Second},
+		},
+		Scrape: ScrapeConfig{
+			Timeout: Duration{10 * time.Second},
+		},
+		StatsD: StatsDConfig{
+			Addr:          ":8125",
+			FlushInterval: Duration{10 * time.Second},
+			Percentiles:   []float64{50, 90, 95, 99},
+			MaxSamples:    10000,
+		},
+		InfluxListener: InfluxListenerConfig{
+			Addr:         ":8186",
+			UDPPrecision: PrecisionNanoseconds,
+			MaxBodySize:  32 * 1024 * 1024,
+		},
 	}
 }
 
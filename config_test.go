@@ -22,6 +22,9 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Global.RetryAttempts != 3 {
 		t.Errorf("RetryAttempts = %d, want 3", cfg.Global.RetryAttempts)
 	}
+	if cfg.Global.QueueSize != 100 {
+		t.Errorf("QueueSize = %d, want 100", cfg.Global.QueueSize)
+	}
 	if cfg.InfluxDB.Enabled {
 		t.Error("InfluxDB should be disabled by default")
 	}
@@ -233,3 +236,757 @@ func TestValidationDisabledBackendsSkipped(t *testing.T) {
 		t.Errorf("Validate() should pass for default config, got %v", err)
 	}
 }
+
+func TestLoadConfigSpool(t *testing.T) {
+	data := `
+[spool]
+enabled = true
+dir = "/var/spool/monitor"
+max_size = 1048576
+max_age = "6h"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if !cfg.Spool.Enabled {
+		t.Error("Spool should be enabled")
+	}
+	if cfg.Spool.Dir != "/var/spool/monitor" {
+		t.Errorf("Spool.Dir = %q, want %q", cfg.Spool.Dir, "/var/spool/monitor")
+	}
+	if cfg.Spool.MaxSize != 1048576 {
+		t.Errorf("Spool.MaxSize = %d, want 1048576", cfg.Spool.MaxSize)
+	}
+	if cfg.Spool.MaxAge.Duration != 6*time.Hour {
+		t.Errorf("Spool.MaxAge = %v, want 6h", cfg.Spool.MaxAge.Duration)
+	}
+}
+
+func TestValidationSpoolRequiresDir(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Spool.Enabled = true
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should error when spool enabled without a dir")
+	}
+
+	errs := err.(ValidationErrors)
+	if len(errs) != 1 || errs[0].Field != "spool.dir" {
+		t.Errorf("Expected a single spool.dir error, got %v", errs)
+	}
+}
+
+func TestLoadConfigHost(t *testing.T) {
+	data := `
+[host]
+enabled = true
+proc_root = "/host/proc"
+rootfs = "/host"
+collectors = ["cpu", "memory"]
+per_cpu = false
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if !cfg.Host.Enabled {
+		t.Error("Host should be enabled")
+	}
+	if cfg.Host.ProcRoot != "/host/proc" || cfg.Host.RootFS != "/host" {
+		t.Errorf("Host roots = %q, %q", cfg.Host.ProcRoot, cfg.Host.RootFS)
+	}
+	if len(cfg.Host.Collectors) != 2 || cfg.Host.Collectors[1] != "memory" {
+		t.Errorf("Host.Collectors = %v", cfg.Host.Collectors)
+	}
+	if cfg.Host.PerCPU {
+		t.Error("Host.PerCPU should be false")
+	}
+	if len(cfg.Host.IgnoreInterfaces) != 1 || cfg.Host.IgnoreInterfaces[0] != "lo" {
+		t.Errorf("Host.IgnoreInterfaces = %v, want default [lo]", cfg.Host.IgnoreInterfaces)
+	}
+}
+
+func TestValidationHostRequiresProcRoot(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Host.Enabled = true
+	cfg.Host.ProcRoot = ""
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should error when host metrics enabled without a proc_root")
+	}
+
+	errs := err.(ValidationErrors)
+	if len(errs) != 1 || errs[0].Field != "host.proc_root" {
+		t.Errorf("Expected a single host.proc_root error, got %v", errs)
+	}
+}
+
+func TestValidationHostPIDs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Host.Enabled = true
+	cfg.Host.PIDs = []int{42, 0}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject a non-positive pid")
+	}
+
+	errs := err.(ValidationErrors)
+	if len(errs) != 1 || errs[0].Field != "host.pids" {
+		t.Errorf("Expected a single host.pids error, got %v", errs)
+	}
+}
+
+func TestLoadConfigProbes(t *testing.T) {
+	data := `
+[probes]
+timeout = "3s"
+
+[[probes.http]]
+name = "api"
+url = "https://api.example.com/health"
+expect_status = [200, 204]
+json_path = "status"
+json_value = "ok"
+timeout = "1s"
+
+[probes.http.headers]
+Authorization = "Bearer x"
+
+[[probes.http]]
+url = "http://localhost:8080/"
+body_regex = "ready"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if cfg.Probes.Timeout.Duration != 3*time.Second {
+		t.Errorf("Probes.Timeout = %v, want 3s", cfg.Probes.Timeout.Duration)
+	}
+	if len(cfg.Probes.HTTP) != 2 {
+		t.Fatalf("got %d HTTP probes, want 2", len(cfg.Probes.HTTP))
+	}
+	api := cfg.Probes.HTTP[0]
+	if api.Name != "api" || api.JSONValue != "ok" || api.Timeout.Duration != time.Second {
+		t.Errorf("probe = %+v", api)
+	}
+	if len(api.ExpectStatus) != 2 || api.Headers["Authorization"] != "Bearer x" {
+		t.Errorf("expect_status = %v, headers = %v", api.ExpectStatus, api.Headers)
+	}
+	if cfg.Probes.HTTP[1].BodyRegex != "ready" {
+		t.Errorf("BodyRegex = %q", cfg.Probes.HTTP[1].BodyRegex)
+	}
+}
+
+func TestValidationProbes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Probes.HTTP = []HTTPProbeConfig{
+		{Name: "a", URL: "http://ok"},
+		{Name: "a", URL: "ftp://host"},
+		{URL: "http://ok", BodyRegex: "(", JSONValue: "x", ExpectStatus: []int{99}},
+		{Timeout: Duration{-time.Second}},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject invalid probes")
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range err.(ValidationErrors) {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{
+		"probes.http[1].name",
+		"probes.http[1].url",
+		"probes.http[2].body_regex",
+		"probes.http[2].json_value",
+		"probes.http[2].expect_status",
+		"probes.http[3].url",
+		"probes.http[3].timeout",
+	} {
+		if !fields[f] {
+			t.Errorf("missing validation error for %s (got %v)", f, fields)
+		}
+	}
+	if fields["probes.http[0].url"] {
+		t.Error("valid probe should not be rejected")
+	}
+}
+
+func TestLoadConfigNetworkProbes(t *testing.T) {
+	data := `
+[[probes.tcp]]
+name = "smtp"
+address = "mail.example.com:25"
+expect = "^220 "
+
+[[probes.udp]]
+address = "10.0.0.1:161"
+send = "ping"
+timeout = "500ms"
+
+[[probes.dns]]
+server = "8.8.8.8"
+query = "example.com"
+type = "MX"
+network = "tcp"
+expect = ["mail.example.com"]
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if len(cfg.Probes.TCP) != 1 || cfg.Probes.TCP[0].Expect != "^220 " {
+		t.Errorf("TCP = %+v", cfg.Probes.TCP)
+	}
+	if len(cfg.Probes.UDP) != 1 || cfg.Probes.UDP[0].Timeout.Duration != 500*time.Millisecond {
+		t.Errorf("UDP = %+v", cfg.Probes.UDP)
+	}
+	if len(cfg.Probes.DNS) != 1 {
+		t.Fatalf("got %d DNS probes, want 1", len(cfg.Probes.DNS))
+	}
+	dns := cfg.Probes.DNS[0]
+	if dns.Type != "MX" || dns.Network != "tcp" || len(dns.Expect) != 1 {
+		t.Errorf("DNS = %+v", dns)
+	}
+}
+
+func TestValidationNetworkProbes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Probes.HTTP = []HTTPProbeConfig{{Name: "web", URL: "http://ok"}}
+	cfg.Probes.TCP = []TCPProbeConfig{
+		{Name: "web", Address: "host:80"},
+		{Address: "host", Expect: "("},
+	}
+	cfg.Probes.UDP = []UDPProbeConfig{{Address: "host:161"}}
+	cfg.Probes.DNS = []DNSProbeConfig{
+		{Query: "example.com", Type: "mx"},
+		{Type: "SRV", Network: "quic"},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject invalid probes")
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range err.(ValidationErrors) {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{
+		"probes.tcp[0].name",
+		"probes.tcp[1].address",
+		"probes.tcp[1].expect",
+		"probes.udp[0].send",
+		"probes.dns[1].query",
+		"probes.dns[1].type",
+		"probes.dns[1].network",
+	} {
+		if !fields[f] {
+			t.Errorf("missing validation error for %s (got %v)", f, fields)
+		}
+	}
+	if fields["probes.dns[0].type"] {
+		t.Error("record type should be case-insensitive")
+	}
+}
+
+func TestLoadConfigScrape(t *testing.T) {
+	data := `
+[scrape]
+timeout = "4s"
+
+[[scrape.targets]]
+name = "app"
+url = "http://localhost:9100/metrics"
+tags = { env = "prod" }
+
+[scrape.targets.headers]
+Authorization = "Bearer x"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if cfg.Scrape.Timeout.Duration != 4*time.Second {
+		t.Errorf("Scrape.Timeout = %v, want 4s", cfg.Scrape.Timeout.Duration)
+	}
+	if len(cfg.Scrape.Targets) != 1 {
+		t.Fatalf("got %d targets, want 1", len(cfg.Scrape.Targets))
+	}
+	target := cfg.Scrape.Targets[0]
+	if target.Name != "app" || target.Tags["env"] != "prod" || target.Headers["Authorization"] != "Bearer x" {
+		t.Errorf("target = %+v", target)
+	}
+}
+
+func TestValidationScrape(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scrape.Targets = []ScrapeTargetConfig{
+		{Name: "a", URL: "http://host/metrics"},
+		{Name: "b", URL: "http://host/metrics"},
+		{Name: "a", URL: "http://host/metrics"},
+		{URL: "localhost:9100"},
+		{Timeout: Duration{-time.Second}},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject invalid targets")
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range err.(ValidationErrors) {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{
+		"scrape.targets[2].url",
+		"scrape.targets[3].url",
+		"scrape.targets[4].url",
+		"scrape.targets[4].timeout",
+	} {
+		if !fields[f] {
+			t.Errorf("missing validation error for %s (got %v)", f, fields)
+		}
+	}
+	if fields["scrape.targets[1].url"] {
+		t.Error("the same URL under another name should be accepted")
+	}
+}
+
+func TestLoadConfigStatsD(t *testing.T) {
+	data := `
+[statsd]
+enabled = true
+addr = "127.0.0.1:9125"
+percentiles = [50, 99.9]
+delete_gauges = true
+tags = { dc = "east" }
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	s := cfg.StatsD
+	if !s.Enabled || s.Addr != "127.0.0.1:9125" || !s.DeleteGauges || s.Tags["dc"] != "east" {
+		t.Errorf("StatsD = %+v", s)
+	}
+	if len(s.Percentiles) != 2 || s.Percentiles[1] != 99.9 {
+		t.Errorf("Percentiles = %v", s.Percentiles)
+	}
+	if s.FlushInterval.Duration != 10*time.Second || s.MaxSamples != 10000 {
+		t.Errorf("defaults not kept: flush_interval = %v, max_samples = %d", s.FlushInterval.Duration, s.MaxSamples)
+	}
+}
+
+func TestValidationStatsD(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StatsD.Enabled = true
+	cfg.StatsD.Addr = "8125"
+	cfg.StatsD.FlushInterval = Duration{0}
+	cfg.StatsD.Percentiles = []float64{0, 50, 100}
+	cfg.StatsD.MaxSamples = -1
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject invalid StatsD settings")
+	}
+
+	counts := make(map[string]int)
+	for _, e := range err.(ValidationErrors) {
+		counts[e.Field]++
+	}
+	want := map[string]int{
+		"statsd.addr":           1,
+		"statsd.flush_interval": 1,
+		"statsd.percentiles":    2,
+		"statsd.max_samples":    1,
+	}
+	for f, n := range want {
+		if counts[f] != n {
+			t.Errorf("%s: got %d errors, want %d", f, counts[f], n)
+		}
+	}
+}
+
+func TestLoadConfigInfluxListener(t *testing.T) {
+	data := `
+[influx_listener]
+enabled = true
+udp_addr = "127.0.0.1:8089"
+udp_precision = "s"
+token = "secret"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	l := cfg.InfluxListener
+	if !l.Enabled || l.UDPAddr != "127.0.0.1:8089" || l.UDPPrecision != PrecisionSeconds || l.Token != "secret" {
+		t.Errorf("InfluxListener = %+v", l)
+	}
+	if l.Addr != ":8186" || l.MaxBodySize != 32*1024*1024 {
+		t.Errorf("defaults not kept: addr = %q, max_body_size = %d", l.Addr, l.MaxBodySize)
+	}
+}
+
+func TestValidationInfluxListener(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.InfluxListener = InfluxListenerConfig{
+		Enabled:      true,
+		UDPAddr:      "8089",
+		UDPPrecision: "d",
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject invalid listener settings")
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range err.(ValidationErrors) {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{
+		"influx_listener.udp_addr",
+		"influx_listener.udp_precision",
+		"influx_listener.max_body_size",
+	} {
+		if !fields[f] {
+			t.Errorf("missing validation error for %s (got %v)", f, fields)
+		}
+	}
+
+	cfg.InfluxListener = InfluxListenerConfig{Enabled: true, MaxBodySize: 1}
+	err = cfg.Validate()
+	if err == nil || err.(ValidationErrors)[0].Field != "influx_listener.addr" {
+		t.Errorf("expected influx_listener.addr error without any address, got %v", err)
+	}
+}
+
+func TestLoadConfigProcessors(t *testing.T) {
+	data := `
+[[processors.rename]]
+order = 2
+measurements = ["cpu*"]
+measurement = { cpu = "host_cpu" }
+tags = { hostname = "host" }
+
+[[processors.regex]]
+order = 1
+tag = "path"
+pattern = '^/users/\d+'
+replacement = "/users/:id"
+
+[[processors.fields]]
+backends = ["influxdb"]
+add = { version = 3 }
+drop = ["debug_*"]
+
+[[processors.convert]]
+fields = ["status"]
+type = "integer"
+
+[[processors.scale]]
+fields = ["*_bytes"]
+factor = 0.001
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	p := cfg.Processors
+	if len(p.Rename) != 1 || p.Rename[0].Order != 2 || p.Rename[0].Measurements[0] != "cpu*" ||
+		p.Rename[0].Measurement["cpu"] != "host_cpu" || p.Rename[0].Tags["hostname"] != "host" {
+		t.Errorf("Rename = %+v", p.Rename)
+	}
+	if len(p.Regex) != 1 || p.Regex[0].Pattern != `^/users/\d+` || p.Regex[0].Order != 1 {
+		t.Errorf("Regex = %+v", p.Regex)
+	}
+	if len(p.Fields) != 1 || p.Fields[0].Backends[0] != "influxdb" || p.Fields[0].Add["version"] != int64(3) {
+		t.Errorf("Fields = %+v", p.Fields)
+	}
+	if len(p.Convert) != 1 || p.Convert[0].Type != FieldTypeInteger {
+		t.Errorf("Convert = %+v", p.Convert)
+	}
+	if len(p.Scale) != 1 || p.Scale[0].Factor != 0.001 {
+		t.Errorf("Scale = %+v", p.Scale)
+	}
+}
+
+func TestValidationProcessors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Processors = ProcessorsConfig{
+		Rename:  []RenameProcessorConfig{{Tags: map[string]string{"a": ""}}, {}},
+		Tags:    []TagsProcessorConfig{{Drop: []string{"["}}},
+		Fields:  []FieldsProcessorConfig{{ProcessorScope: ProcessorScope{Backends: []string{""}}}},
+		Regex:   []RegexProcessorConfig{{Pattern: "("}},
+		Convert: []ConvertProcessorConfig{{Type: "decimal"}},
+		Scale:   []ScaleProcessorConfig{{ProcessorScope: ProcessorScope{Measurements: []string{"[a"}}, Fields: []string{"x"}}},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject invalid processors")
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range err.(ValidationErrors) {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{
+		"processors.rename[0].tags",
+		"processors.rename[1]",
+		"processors.tags[0].drop",
+		"processors.fields[0]",
+		"processors.fields[0].backends",
+		"processors.regex[0].tag",
+		"processors.regex[0].pattern",
+		"processors.convert[0].fields",
+		"processors.convert[0].type",
+		"processors.scale[0].measurements",
+		"processors.scale[0].factor",
+	} {
+		if !fields[f] {
+			t.Errorf("missing validation error for %s (got %v)", f, fields)
+		}
+	}
+}
+
+func TestLoadConfigFilters(t *testing.T) {
+	data := `
+[filters.prometheus]
+include_measurements = ["http_*", "cpu"]
+exclude_tags = { env = ["dev*"] }
+
+[filters.influxdb]
+exclude_fields = ["debug_*"]
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	prom := cfg.Filters["prometheus"]
+	if len(prom.IncludeMeasurements) != 2 || prom.ExcludeTags["env"][0] != "dev*" {
+		t.Errorf("prometheus filter = %+v", prom)
+	}
+	if influx := cfg.Filters["influxdb"]; len(influx.ExcludeFields) != 1 {
+		t.Errorf("influxdb filter = %+v", influx)
+	}
+}
+
+func TestValidationFilters(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Filters = map[string]Filter{
+		"prometheus": {
+			IncludeMeasurements: []string{"[bad"},
+			IncludeTags:         map[string][]string{"env": nil},
+		},
+		"influxdb": {ExcludeFields: []string{"a[", "ok"}},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should reject invalid filters")
+	}
+
+	errs := err.(ValidationErrors)
+	want := []string{
+		"filters.influxdb.exclude_fields",
+		"filters.prometheus.include_measurements",
+		"filters.prometheus.include_tags",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %v, want errors for %v", errs, want)
+	}
+	for i, f := range want {
+		if errs[i].Field != f {
+			t.Errorf("error %d field = %q, want %q", i, errs[i].Field, f)
+		}
+	}
+}
+
+func TestLoadConfigOverflow(t *testing.T) {
+	data := `
+[global]
+max_buffer_size = 500
+overflow_policy = "block"
+block_timeout = "250ms"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if cfg.Global.MaxBufferSize != 500 {
+		t.Errorf("MaxBufferSize = %d, want 500", cfg.Global.MaxBufferSize)
+	}
+	if cfg.Global.OverflowPolicy != OverflowBlock {
+		t.Errorf("OverflowPolicy = %q, want %q", cfg.Global.OverflowPolicy, OverflowBlock)
+	}
+	if cfg.Global.BlockTimeout.Duration != 250*time.Millisecond {
+		t.Errorf("BlockTimeout = %v, want 250ms", cfg.Global.BlockTimeout.Duration)
+	}
+}
+
+func TestLoadConfigRetryDelayAboveDefaultMax(t *testing.T) {
+	data := `
+[global]
+retry_delay = "45s"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+	if cfg.Global.RetryDelay.Duration != 45*time.Second {
+		t.Errorf("RetryDelay = %v, want 45s", cfg.Global.RetryDelay.Duration)
+	}
+
+	// The pipeline raises the cap to the delay instead.
+	p := NewPipeline(PipelineConfig{
+		RetryDelay:    cfg.Global.RetryDelay.Duration,
+		RetryMaxDelay: cfg.Global.RetryMaxDelay.Duration,
+	})
+	if p.retryMaxDelay != 45*time.Second {
+		t.Errorf("retryMaxDelay = %v, want it raised to retry_delay", p.retryMaxDelay)
+	}
+}
+
+func TestValidationOverflowPolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Global.OverflowPolicy = "drop-everything"
+	cfg.Global.MaxBufferSize = -1
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should error for unknown overflow policy and negative buffer")
+	}
+
+	errs := err.(ValidationErrors)
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 validation errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestLoadConfigBatchSizeAboveDefaultBuffer(t *testing.T) {
+	data := `
+[global]
+batch_size = 20000
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	// The pipeline raises the buffer to hold at least one batch.
+	p := NewPipeline(PipelineConfig{
+		BatchSize:     cfg.Global.BatchSize,
+		MaxBufferSize: cfg.Global.MaxBufferSize,
+	})
+	if p.maxBuffer != 20000 {
+		t.Errorf("maxBuffer = %d, want it raised to batch_size", p.maxBuffer)
+	}
+}
+
+func TestLoadConfigCollectors(t *testing.T) {
+	data := `
+[collectors.inventory]
+poll_interval = "5m"
+
+[collectors.legacy]
+disabled = true
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if got := cfg.Collectors["inventory"].PollInterval.Duration; got != 5*time.Minute {
+		t.Errorf("collectors.inventory.poll_interval = %v, want 5m", got)
+	}
+	if !cfg.Collectors["legacy"].Disabled {
+		t.Error("collectors.legacy should be disabled")
+	}
+}
+
+func TestValidationCollectorInterval(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Collectors = map[string]CollectorConfig{
+		"bad": {PollInterval: Duration{-1 * time.Second}},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should error for a negative collector interval")
+	}
+	if errs := err.(ValidationErrors); errs[0].Field != "collectors.bad.poll_interval" {
+		t.Errorf("Field = %q, want %q", errs[0].Field, "collectors.bad.poll_interval")
+	}
+}
+
+func TestLoadConfigCollectTimeout(t *testing.T) {
+	data := `
+[global]
+collect_timeout = "5s"
+overlap_policy = "queue"
+
+[collectors.inventory]
+collect_timeout = "1m"
+overlap_policy = "cancel"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	if cfg.Global.CollectTimeout.Duration != 5*time.Second {
+		t.Errorf("CollectTimeout = %v, want 5s", cfg.Global.CollectTimeout.Duration)
+	}
+	if cfg.Global.OverlapPolicy != OverlapQueue {
+		t.Errorf("OverlapPolicy = %q, want %q", cfg.Global.OverlapPolicy, OverlapQueue)
+	}
+
+	c := &collector{name: "inventory"}
+	if got := c.timeoutFor(cfg); got != time.Minute {
+		t.Errorf("timeoutFor() = %v, want 1m", got)
+	}
+	if got := c.overlapFor(cfg); got != OverlapCancel {
+		t.Errorf("overlapFor() = %q, want %q", got, OverlapCancel)
+	}
+
+	other := &collector{name: "other", interval: time.Hour}
+	if got := other.timeoutFor(cfg); got != 5*time.Second {
+		t.Errorf("timeoutFor() = %v, want global 5s", got)
+	}
+}
+
+func TestValidationOverlapPolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Global.OverlapPolicy = "pile-up"
+	cfg.Global.CollectTimeout = Duration{-1 * time.Second}
+	cfg.Collectors = map[string]CollectorConfig{
+		"bad": {OverlapPolicy: "wait"},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should error for unknown overlap policies and a negative timeout")
+	}
+	if errs := err.(ValidationErrors); len(errs) != 3 {
+		t.Errorf("Expected 3 validation errors, got %d: %v", len(errs), errs)
+	}
+}
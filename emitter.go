@@ -0,0 +1,64 @@
+package monitor
+
+import "errors"
+
+// ErrMonitorStopped is returned by Emit once the monitor's Run has returned.
+var ErrMonitorStopped = errors.New("monitor stopped")
+
+// Emitter submits metrics to a Monitor as they happen, for event-driven code
+// such as webhook handlers and log watchers that does not fit a polled
+// CollectFunc. Emitted metrics go through the same processors, validation
+// and pipeline as collected ones and are counted in PollStats.EmittedMetrics.
+//
+// An Emitter is safe for concurrent use. It may be used before Run starts,
+// in which case metrics are buffered until the pipeline starts, but not
+// after Run returns.
+type Emitter struct {
+	m *Monitor
+}
+
+// Emitter returns the monitor's Emitter.
+func (m *Monitor) Emitter() *Emitter {
+	return &Emitter{m: m}
+}
+
+// Emit submits a metric. It returns the metric's validation error,
+// ErrBufferFull if the pipeline had no room for it, or ErrMonitorStopped.
+func (e *Emitter) Emit(metric *Metric) error {
+	m := e.m
+	m.emitMu.RLock()
+	defer m.emitMu.RUnlock()
+	if m.stopped {
+		return ErrMonitorStopped
+	}
+
+	m.stats.recordEmitted()
+	return m.publish([]*Metric{metric})
+}
+
+// Push implements Sink, so an Emitter can be handed to code written for
+// inputs. Emit's error is discarded.
+func (e *Emitter) Push(metric *Metric) {
+	e.Emit(metric)
+}
+
+// publish runs collected or emitted metrics through the processors and
+// hands the result to the pipeline, returning the first error.
+func (m *Monitor) publish(metrics []*Metric) error {
+	metrics = runProcessors(m.processors, metrics)
+
+	var first error
+	for _, metric := range metrics {
+		if err := m.pipeline.push(metric); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// closeEmitter makes further Emit calls fail, waiting for those in progress.
+func (m *Monitor) closeEmitter() {
+	m.emitMu.Lock()
+	m.stopped = true
+	m.emitMu.Unlock()
+}
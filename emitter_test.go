@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEmitterBeforeAndDuringRun(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: true}
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) {
+		return []*Metric{NewMetric("polled").WithField("value", 1)}, nil
+	},
+		WithConfig(&Config{
+			Global: GlobalConfig{
+				PollInterval:  Duration{time.Hour},
+				LogLevel:      "error",
+				BatchSize:     100,
+				RetryAttempts: 1,
+				RetryDelay:    Duration{time.Millisecond},
+			},
+		}),
+		WithBackend(backend),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	emitter := m.Emitter()
+	if err := emitter.Emit(NewMetric("early").WithField("value", 1)); err != nil {
+		t.Fatalf("Emit() before Run error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				emitter.Emit(NewMetric("event").WithField("value", j))
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, batch := range backend.written {
+		for _, metric := range batch {
+			counts[metric.Measurement]++
+		}
+	}
+	if counts["early"] != 1 || counts["event"] != 40 || counts["polled"] == 0 {
+		t.Errorf("delivered = %v, want early, every event and polled metrics", counts)
+	}
+	if got := m.Stats().EmittedMetrics; got != 41 {
+		t.Errorf("EmittedMetrics = %d, want 41", got)
+	}
+
+	if err := emitter.Emit(NewMetric("late").WithField("value", 1)); !errors.Is(err, ErrMonitorStopped) {
+		t.Errorf("Emit() after Run = %v, want ErrMonitorStopped", err)
+	}
+	if err := m.Run(context.Background()); err == nil {
+		t.Error("a second Run should fail")
+	}
+}
+
+func TestEmitterErrors(t *testing.T) {
+	m, err := New("test", nil,
+		WithConfig(&Config{
+			Global: GlobalConfig{
+				PollInterval:   Duration{time.Hour},
+				LogLevel:       "error",
+				BatchSize:      1,
+				MaxBufferSize:  1,
+				OverflowPolicy: OverflowDropNewest,
+			},
+		}),
+		WithInput(&fakeInput{name: "fake"}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	emitter := m.Emitter()
+
+	if err := emitter.Emit(NewMetric("")); err == nil {
+		t.Error("Emit() should return the validation error of an invalid metric")
+	}
+	if err := emitter.Emit(NewMetric("a").WithField("value", 1)); err != nil {
+		t.Fatalf("Emit() error: %v", err)
+	}
+	if err := emitter.Emit(NewMetric("b").WithField("value", 1)); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("Emit() into a full buffer = %v, want ErrBufferFull", err)
+	}
+	if stats := m.pipeline.Stats(); stats.InvalidMetrics != 1 || stats.DroppedMetrics != 1 {
+		t.Errorf("pipeline stats = %+v, want one invalid and one dropped", stats)
+	}
+}
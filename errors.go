@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// PermanentError marks an error that retrying cannot fix, such as a backend
+// rejecting a malformed batch. The pipeline does not retry or spool batches
+// that fail with a permanent error.
+type PermanentError struct {
+	Err error
+}
+
+// Permanent wraps err as a PermanentError. Returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable always returns false for a PermanentError.
+func (e *PermanentError) Retryable() bool {
+	return false
+}
+
+// IsRetryable reports whether an operation that failed with err may succeed
+// if tried again. Errors are retryable unless something in their chain
+// implements Retryable() bool and returns false.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	return true
+}
+
+// PanicError is returned in place of a panic recovered from user code, such
+// as a CollectFunc or a Backend's Write. It is never retryable: the same
+// input is likely to panic again.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Retryable always returns false for a PanicError.
+func (e *PanicError) Retryable() bool {
+	return false
+}
+
+// recoverPanic converts a recovered panic into a *PanicError stored in *err.
+// Use it as defer recoverPanic(&err) so recover sees the panic.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
+
+// asPanic returns the PanicError in err's chain, if any.
+func asPanic(err error) (*PanicError, bool) {
+	var pe *PanicError
+	ok := errors.As(err, &pe)
+	return pe, ok
+}
@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type retryableErr bool
+
+func (e retryableErr) Error() string   { return "retryable error" }
+func (e retryableErr) Retryable() bool { return bool(e) }
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+
+	base := fmt.Errorf("bad request")
+	err := Permanent(base)
+	if err.Error() != "bad request" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "bad request")
+	}
+	if !errors.Is(err, base) {
+		t.Error("Permanent error should unwrap to the original error")
+	}
+
+	var perm *PermanentError
+	if !errors.As(fmt.Errorf("write: %w", err), &perm) {
+		t.Error("wrapped PermanentError should be found with errors.As")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", fmt.Errorf("timeout"), true},
+		{"permanent", Permanent(fmt.Errorf("bad request")), false},
+		{"wrapped permanent", fmt.Errorf("write: %w", Permanent(fmt.Errorf("bad"))), false},
+		{"custom retryable", retryableErr(true), true},
+		{"custom non-retryable", retryableErr(false), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	fn := func() (err error) {
+		defer recoverPanic(&err)
+		panic("boom")
+	}
+
+	err := fn()
+	pe, ok := asPanic(fmt.Errorf("write: %w", err))
+	if !ok {
+		t.Fatalf("asPanic() should find the PanicError, got %v", err)
+	}
+	if pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("PanicError = %v with %d byte stack, want boom with a stack", pe.Value, len(pe.Stack))
+	}
+	if err.Error() != "panic: boom" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "panic: boom")
+	}
+	if IsRetryable(err) {
+		t.Error("PanicError should not be retryable")
+	}
+}
@@ -0,0 +1,119 @@
+package monitor
+
+import "context"
+
+// Filter selects the metrics and fields that reach a backend. Every rule
+// takes glob patterns and an empty rule has no effect; a metric passes when
+// it is matched by every include rule and by no exclude rule.
+//
+// IncludeTags requires each listed tag to be present with a value matching
+// one of its patterns; ExcludeTags rejects a metric with any listed tag whose
+// value matches. The field rules remove fields rather than metrics, and a
+// metric left without fields is dropped.
+//
+// Configured per backend under [filters.<backend>], or set up in Go with
+// NewFilteredBackend. A Filter is also a Processor, for filtering
+// everything with WithProcessor.
+type Filter struct {
+	IncludeMeasurements []string            `toml:"include_measurements"`
+	ExcludeMeasurements []string            `toml:"exclude_measurements"`
+	IncludeTags         map[string][]string `toml:"include_tags"`
+	ExcludeTags         map[string][]string `toml:"exclude_tags"`
+	IncludeFields       []string            `toml:"include_fields"`
+	ExcludeFields       []string            `toml:"exclude_fields"`
+}
+
+// Process returns the metrics that pass the filter. Metrics that lose
+// fields are copied, so the metrics passed in are never modified.
+func (f *Filter) Process(metrics []*Metric) []*Metric {
+	var kept []*Metric
+	for _, m := range metrics {
+		if !f.matches(m) {
+			continue
+		}
+		if m = f.filterFields(m); m != nil {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// matches applies the measurement and tag rules.
+func (f *Filter) matches(m *Metric) bool {
+	if len(f.IncludeMeasurements) > 0 && !matchAny(f.IncludeMeasurements, m.Measurement) {
+		return false
+	}
+	if matchAny(f.ExcludeMeasurements, m.Measurement) {
+		return false
+	}
+	for key, patterns := range f.IncludeTags {
+		v, ok := m.Tags[key]
+		if !ok || !matchAny(patterns, v) {
+			return false
+		}
+	}
+	for key, patterns := range f.ExcludeTags {
+		if v, ok := m.Tags[key]; ok && matchAny(patterns, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterFields applies the field rules, returning m itself if every field
+// passes, a copy without the others if some do, and nil if none do.
+func (f *Filter) filterFields(m *Metric) *Metric {
+	if len(f.IncludeFields) == 0 && len(f.ExcludeFields) == 0 {
+		return m
+	}
+
+	var drop []string
+	for k := range m.Fields {
+		if (len(f.IncludeFields) > 0 && !matchAny(f.IncludeFields, k)) || matchAny(f.ExcludeFields, k) {
+			drop = append(drop, k)
+		}
+	}
+	switch len(drop) {
+	case 0:
+		return m
+	case len(m.Fields):
+		return nil
+	}
+
+	m = m.Clone()
+	for _, k := range drop {
+		m.takeField(k)
+	}
+	return m
+}
+
+// empty reports whether the filter has no rules.
+func (f *Filter) empty() bool {
+	return len(f.IncludeMeasurements)+len(f.ExcludeMeasurements)+
+		len(f.IncludeTags)+len(f.ExcludeTags)+
+		len(f.IncludeFields)+len(f.ExcludeFields) == 0
+}
+
+// NewFilteredBackend wraps b so only the metrics and fields passing f are
+// written to it. A batch with nothing left is not written.
+func NewFilteredBackend(b Backend, f Filter) Backend {
+	return &filteredBackend{Backend: b, filter: &f}
+}
+
+type filteredBackend struct {
+	Backend
+	filter *Filter
+}
+
+func (b *filteredBackend) Write(ctx context.Context, metrics []*Metric) error {
+	metrics = b.filter.Process(metrics)
+	if len(metrics) == 0 {
+		return nil
+	}
+	return b.Backend.Write(ctx, metrics)
+}
+
+// Unwrap returns the wrapped backend.
+func (b *filteredBackend) Unwrap() Backend {
+	return b.Backend
+}
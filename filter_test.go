@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+)
+
+func measurements(metrics []*Metric) []string {
+	names := make([]string, len(metrics))
+	for i, m := range metrics {
+		names[i] = m.Measurement
+	}
+	return names
+}
+
+func TestFilterMeasurementsAndTags(t *testing.T) {
+	metrics := []*Metric{
+		NewMetric("http_requests").WithTag("env", "prod").WithField("v", 1),
+		NewMetric("http_debug").WithTag("env", "prod").WithField("v", 1),
+		NewMetric("http_errors").WithTag("env", "dev").WithField("v", 1),
+		NewMetric("http_latency").WithField("v", 1),
+		NewMetric("cpu").WithTag("env", "prod").WithField("v", 1),
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"empty", Filter{}, []string{"http_requests", "http_debug", "http_errors", "http_latency", "cpu"}},
+		{
+			"measurements",
+			Filter{IncludeMeasurements: []string{"http_*"}, ExcludeMeasurements: []string{"*_debug"}},
+			[]string{"http_requests", "http_errors", "http_latency"},
+		},
+		{"include tags", Filter{IncludeTags: map[string][]string{"env": {"prod", "stag*"}}}, []string{"http_requests", "http_debug", "cpu"}},
+		{"exclude tags", Filter{ExcludeTags: map[string][]string{"env": {"dev"}}}, []string{"http_requests", "http_debug", "http_latency", "cpu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := measurements(tt.filter.Process(metrics))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestFilterFields(t *testing.T) {
+	full := NewMetric("app").WithField("requests", 1).WithField("debug_a", 2).WithCounter("debug_b", 3)
+	debugOnly := NewMetric("dbg").WithField("debug_x", 1)
+	clean := NewMetric("clean").WithField("requests", 1)
+
+	f := &Filter{ExcludeFields: []string{"debug_*"}}
+	got := f.Process([]*Metric{full, debugOnly, clean})
+	if len(got) != 2 {
+		t.Fatalf("got %v, want a metric without fields left dropped", measurements(got))
+	}
+	if len(got[0].Fields) != 1 || got[0].Fields["requests"] != 1 {
+		t.Errorf("fields = %v", got[0].Fields)
+	}
+	if got[0] == full || len(full.Fields) != 3 || full.FieldKind("debug_b") != KindCounter {
+		t.Error("a metric losing fields should be copied, not modified")
+	}
+	if got[1] != clean {
+		t.Error("a metric keeping all its fields need not be copied")
+	}
+
+	f = &Filter{IncludeFields: []string{"requests"}}
+	if got := f.Process([]*Metric{full}); len(got) != 1 || len(got[0].Fields) != 1 {
+		t.Errorf("include_fields kept %v", got[0].Fields)
+	}
+}
+
+func TestFilteredBackend(t *testing.T) {
+	inner := &probingBackend{mockBackend: mockBackend{name: "prometheus", healthy: true}}
+	b := NewFilteredBackend(inner, Filter{IncludeMeasurements: []string{"http_*"}})
+
+	if _, ok := asProber(b); !ok || b.Name() != "prometheus" {
+		t.Error("the filtered backend should keep the wrapped backend's name and prober")
+	}
+
+	ctx := context.Background()
+	b.Write(ctx, []*Metric{NewMetric("debug").WithField("v", 1)})
+	if len(inner.written) != 0 {
+		t.Error("a batch with nothing passing should not be written")
+	}
+	b.Write(ctx, []*Metric{NewMetric("debug").WithField("v", 1), NewMetric("http_requests").WithField("v", 1)})
+	if len(inner.written) != 1 || len(inner.written[0]) != 1 || inner.written[0][0].Measurement != "http_requests" {
+		t.Errorf("written = %v", inner.written)
+	}
+}
+
+func TestMonitorFilters(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Filters = map[string]Filter{
+		"prometheus": {IncludeMeasurements: []string{"http_*"}},
+		"influxdb":   {},
+	}
+	cfg.Processors.Tags = []TagsProcessorConfig{{
+		ProcessorScope: ProcessorScope{Backends: []string{"prometheus"}},
+		Add:            map[string]string{"via": "prom"},
+	}}
+	cfg.Processors.Rename = []RenameProcessorConfig{{
+		ProcessorScope: ProcessorScope{Backends: []string{"prometheus"}},
+		Measurement:    map[string]string{"http_requests": "requests"},
+	}}
+
+	prom := &mockBackend{name: "prometheus", healthy: true}
+	influx := &mockBackend{name: "influxdb", healthy: true}
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) {
+		return []*Metric{
+			NewMetric("http_requests").WithField("v", 1),
+			NewMetric("debug_trace").WithTag("id", "abc").WithField("v", 1),
+		}, nil
+	}, WithConfig(cfg), WithRunOnce(true), WithBackend(prom), WithBackend(influx))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	var promGot, influxGot []*Metric
+	for _, batch := range prom.written {
+		promGot = append(promGot, batch...)
+	}
+	for _, batch := range influx.written {
+		influxGot = append(influxGot, batch...)
+	}
+	if len(promGot) != 1 || promGot[0].Measurement != "requests" || promGot[0].Tags["via"] != "prom" {
+		t.Errorf("prometheus received %v, want the filtered then processed metric", measurements(promGot))
+	}
+	if len(influxGot) != 2 {
+		t.Errorf("influxdb received %v, want everything", measurements(influxGot))
+	}
+}
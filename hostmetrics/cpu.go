@@ -0,0 +1,100 @@
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// cpuFields names the time columns of a cpu line in /proc/stat, in order.
+var cpuFields = []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal", "guest", "guest_nice"}
+
+// kernelFields maps /proc/stat counters to kernel field names.
+var kernelFields = map[string]string{
+	"ctxt":      "context_switches",
+	"intr":      "interrupts",
+	"processes": "processes_forked",
+}
+
+// CollectCPU reports CPU time from /proc/stat as the "cpu" measurement,
+// tagged cpu=cpu-total for the whole machine and cpu=cpuN for each CPU when
+// per_cpu is set. Times are cumulative seconds. Kernel activity (context
+// switches, interrupts, forks and process states) is reported as "kernel".
+func (h *Host) CollectCPU(ctx context.Context) ([]*monitor.Metric, error) {
+	now := time.Now()
+	var metrics []*monitor.Metric
+	kernel := monitor.NewMetric("kernel").WithTimestamp(now)
+
+	err := h.readLines("stat", func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil
+		}
+		key := fields[0]
+
+		if strings.HasPrefix(key, "cpu") {
+			if key != "cpu" && !h.cfg.PerCPU {
+				return nil
+			}
+			m, err := cpuMetric(key, fields[1:])
+			if err != nil {
+				return err
+			}
+			metrics = append(metrics, m.WithTimestamp(now))
+			return nil
+		}
+
+		switch key {
+		case "ctxt", "intr", "processes":
+			v, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			kernel.WithCounter(kernelFields[key], v)
+		case "procs_running", "procs_blocked":
+			v, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			kernel.WithGauge(key, v)
+		case "btime":
+			v, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			kernel.WithGauge("boot_time", v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(kernel.Fields) > 0 {
+		metrics = append(metrics, kernel)
+	}
+	return metrics, nil
+}
+
+func cpuMetric(name string, values []string) (*monitor.Metric, error) {
+	tag := name
+	if name == "cpu" {
+		tag = "cpu-total"
+	}
+	m := monitor.NewMetric("cpu").WithTag("cpu", tag).WithUnit(monitor.UnitSeconds)
+
+	for i, v := range values {
+		if i >= len(cpuFields) {
+			break
+		}
+		ticks, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", name, cpuFields[i], err)
+		}
+		m.WithCounter(cpuFields[i], float64(ticks)/userHZ)
+	}
+	return m, nil
+}
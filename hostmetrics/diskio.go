@@ -0,0 +1,85 @@
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// sectorSize is the unit of the sector counts in /proc/diskstats, which is
+// always 512 bytes regardless of the device.
+const sectorSize = 512
+
+// diskstats columns, counted from the device name.
+const (
+	dsReads = iota + 1
+	dsReadsMerged
+	dsSectorsRead
+	dsReadTime
+	dsWrites
+	dsWritesMerged
+	dsSectorsWritten
+	dsWriteTime
+	dsInProgress
+	dsIOTime
+	dsWeightedIOTime
+)
+
+// CollectDiskIO reports block device activity from /proc/diskstats as the
+// "diskio" measurement, tagged by device. Devices matching ignore_devices
+// are skipped. Counts and bytes are cumulative; times are cumulative seconds.
+func (h *Host) CollectDiskIO(ctx context.Context) ([]*monitor.Metric, error) {
+	var metrics []*monitor.Metric
+	err := h.readLines("diskstats", func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) < 3+dsWeightedIOTime {
+			return nil
+		}
+		device := fields[2]
+		if ignored(device, h.cfg.IgnoreDevices) {
+			return nil
+		}
+
+		cols := fields[2:]
+		var vals [dsWeightedIOTime + 1]uint64
+		for i := dsReads; i <= dsWeightedIOTime; i++ {
+			v, err := strconv.ParseUint(cols[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("device %s: %w", device, err)
+			}
+			vals[i] = v
+		}
+
+		m := monitor.NewMetric("diskio").WithTag("device", device).
+			WithCounter("reads", vals[dsReads]).
+			WithCounter("reads_merged", vals[dsReadsMerged]).
+			WithCounter("read_bytes", vals[dsSectorsRead]*sectorSize).
+			WithCounter("read_time", msToSeconds(vals[dsReadTime])).
+			WithCounter("writes", vals[dsWrites]).
+			WithCounter("writes_merged", vals[dsWritesMerged]).
+			WithCounter("write_bytes", vals[dsSectorsWritten]*sectorSize).
+			WithCounter("write_time", msToSeconds(vals[dsWriteTime])).
+			WithGauge("ios_in_progress", vals[dsInProgress]).
+			WithCounter("io_time", msToSeconds(vals[dsIOTime])).
+			WithCounter("weighted_io_time", msToSeconds(vals[dsWeightedIOTime]))
+		for _, f := range []string{"read_bytes", "write_bytes"} {
+			m.WithFieldMeta(f, "", monitor.UnitBytes)
+		}
+		for _, f := range []string{"read_time", "write_time", "io_time", "weighted_io_time"} {
+			m.WithFieldMeta(f, "", monitor.UnitSeconds)
+		}
+		metrics = append(metrics, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func msToSeconds(ms uint64) float64 {
+	return float64(ms) / 1000
+}
@@ -0,0 +1,45 @@
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// CollectFileDescriptors reports system-wide file handle usage from
+// /proc/sys/fs/file-nr as the "fd" measurement: allocated, unused and max
+// handles, plus used (allocated minus unused) and used_ratio (used over max).
+func (h *Host) CollectFileDescriptors(ctx context.Context) ([]*monitor.Metric, error) {
+	data, err := os.ReadFile(h.procPath("sys/fs/file-nr"))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("file-nr: unexpected format %q", strings.TrimSpace(string(data)))
+	}
+	var vals [3]uint64
+	for i := range vals {
+		if vals[i], err = strconv.ParseUint(fields[i], 10, 64); err != nil {
+			return nil, fmt.Errorf("file-nr: %w", err)
+		}
+	}
+	allocated, unused, max := vals[0], vals[1], vals[2]
+
+	m := monitor.NewMetric("fd").
+		WithGauge("allocated", allocated).
+		WithGauge("unused", unused).
+		WithGauge("max", max)
+	if unused <= allocated {
+		used := allocated - unused
+		m.WithGauge("used", used).
+			WithGauge("used_ratio", ratio(used, max)).
+			WithFieldMeta("used_ratio", "", monitor.UnitRatio)
+	}
+	return []*monitor.Metric{m}, nil
+}
@@ -0,0 +1,106 @@
+package hostmetrics
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// fsUsage is the capacity of a mounted filesystem.
+type fsUsage struct {
+	total, free, avail      uint64 // bytes
+	inodesTotal, inodesFree uint64
+}
+
+type mount struct {
+	device, path, fstype string
+}
+
+// CollectFilesystem reports the usage of each mounted filesystem as the
+// "filesystem" measurement, tagged by path, device and fstype. Mounts are
+// read from /proc/mounts; filesystems matching ignore_fs_types are skipped,
+// as are mounts that cannot be read. free is the space available to
+// unprivileged users and used_ratio matches what df reports.
+func (h *Host) CollectFilesystem(ctx context.Context) ([]*monitor.Metric, error) {
+	mounts, err := h.mounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []*monitor.Metric
+	for _, mnt := range mounts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		usage, err := statfs(filepath.Join(h.cfg.RootFS, mnt.path))
+		if err != nil {
+			h.logger.Debug("skipping filesystem", "path", mnt.path, "error", err)
+			continue
+		}
+
+		used := usage.total - usage.free
+		m := monitor.NewMetric("filesystem").
+			WithTags(map[string]string{"path": mnt.path, "device": mnt.device, "fstype": mnt.fstype}).
+			WithGauge("total", usage.total).
+			WithGauge("free", usage.avail).
+			WithGauge("used", used).
+			WithGauge("used_ratio", ratio(used, used+usage.avail)).
+			WithGauge("inodes_total", usage.inodesTotal).
+			WithGauge("inodes_free", usage.inodesFree).
+			WithGauge("inodes_used", usage.inodesTotal-min(usage.inodesFree, usage.inodesTotal))
+		for _, f := range []string{"total", "free", "used"} {
+			m.WithFieldMeta(f, "", monitor.UnitBytes)
+		}
+		m.WithFieldMeta("used_ratio", "", monitor.UnitRatio)
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// mounts returns the mounted filesystems, skipping ignored types and
+// repeated mount points.
+func (h *Host) mounts() ([]mount, error) {
+	var mounts []mount
+	seen := make(map[string]bool)
+	err := h.readLines("mounts", func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil
+		}
+		mnt := mount{
+			device: unescapeMount(fields[0]),
+			path:   unescapeMount(fields[1]),
+			fstype: fields[2],
+		}
+		if ignored(mnt.fstype, h.cfg.IgnoreFSTypes) || seen[mnt.path] {
+			return nil
+		}
+		seen[mnt.path] = true
+		mounts = append(mounts, mnt)
+		return nil
+	})
+	return mounts, err
+}
+
+// unescapeMount decodes the octal escapes (\040 for a space, ...) the
+// kernel uses for whitespace and backslashes in /proc/mounts.
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
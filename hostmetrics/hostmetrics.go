@@ -0,0 +1,146 @@
+package hostmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// Collector names, as used in HostConfig.Collectors. Each collector is
+// registered with the monitor as "host_<name>".
+const (
+	CPU             = "cpu"
+	Memory          = "memory"
+	DiskIO          = "diskio"
+	Net             = "net"
+	Load            = "load"
+	Filesystem      = "filesystem"
+	FileDescriptors = "fd"
+	Process         = "process"
+)
+
+// Names lists every collector in registration order.
+var Names = []string{CPU, Memory, DiskIO, Net, Load, Filesystem, FileDescriptors, Process}
+
+// userHZ is the kernel's USER_HZ, the unit of the CPU times in /proc/stat.
+// It is 100 on every Linux architecture Go supports.
+const userHZ = 100
+
+// Host collects metrics about the machine from procfs.
+type Host struct {
+	cfg    monitor.HostConfig
+	logger *slog.Logger
+	names  []string
+}
+
+// New creates host metric collectors. It returns an error if cfg names an
+// unknown collector.
+func New(cfg monitor.HostConfig, logger *slog.Logger) (*Host, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.ProcRoot == "" {
+		cfg.ProcRoot = "/proc"
+	}
+
+	names := cfg.Collectors
+	if len(names) == 0 {
+		names = Names
+	}
+	for _, name := range names {
+		if !known(name) {
+			return nil, fmt.Errorf("unknown host collector %q", name)
+		}
+	}
+
+	return &Host{cfg: cfg, logger: logger, names: names}, nil
+}
+
+func known(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Collector returns the CollectFunc for the named collector, or nil if the
+// name is unknown.
+func (h *Host) Collector(name string) monitor.CollectFunc {
+	switch name {
+	case CPU:
+		return h.CollectCPU
+	case Memory:
+		return h.CollectMemory
+	case DiskIO:
+		return h.CollectDiskIO
+	case Net:
+		return h.CollectNet
+	case Load:
+		return h.CollectLoad
+	case Filesystem:
+		return h.CollectFilesystem
+	case FileDescriptors:
+		return h.CollectFileDescriptors
+	case Process:
+		return h.CollectProcess
+	default:
+		return nil
+	}
+}
+
+// Option registers each configured collector with the monitor as
+// "host_<name>", polled every interval (zero means global.poll_interval).
+func (h *Host) Option(interval time.Duration) monitor.Option {
+	return func(m *monitor.Monitor) {
+		for _, name := range h.names {
+			monitor.WithCollector("host_"+name, h.Collector(name), interval)(m)
+		}
+	}
+}
+
+func (h *Host) procPath(name string) string {
+	return filepath.Join(h.cfg.ProcRoot, name)
+}
+
+// readLines calls fn for each line of a file under the proc root.
+func (h *Host) readLines(name string, fn func(line string) error) error {
+	f, err := os.Open(h.procPath(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// ignored reports whether name matches any of the glob patterns.
+func ignored(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ratio returns part/whole, or 0 when whole is 0.
+func ratio(part, whole uint64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
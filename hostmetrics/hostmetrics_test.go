@@ -0,0 +1,279 @@
+package hostmetrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+func testHost(t *testing.T, mutate func(*monitor.HostConfig)) *Host {
+	t.Helper()
+	cfg := monitor.DefaultConfig().Host
+	cfg.ProcRoot = "testdata/proc"
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	h, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return h
+}
+
+func collect(t *testing.T, fn monitor.CollectFunc) []*monitor.Metric {
+	t.Helper()
+	metrics, err := fn(context.Background())
+	if err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	for _, m := range metrics {
+		if err := m.Validate(); err != nil {
+			t.Errorf("invalid metric %s: %v", m.Measurement, err)
+		}
+	}
+	return metrics
+}
+
+func find(metrics []*monitor.Metric, measurement, tag, value string) *monitor.Metric {
+	for _, m := range metrics {
+		if m.Measurement == measurement && (tag == "" || m.Tags[tag] == value) {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestNewUnknownCollector(t *testing.T) {
+	cfg := monitor.DefaultConfig().Host
+	cfg.Collectors = []string{"cpu", "gpu"}
+	if _, err := New(cfg, nil); err == nil || !strings.Contains(err.Error(), "gpu") {
+		t.Fatalf("New() error = %v, want unknown collector gpu", err)
+	}
+}
+
+func TestCollectorNames(t *testing.T) {
+	h := testHost(t, nil)
+	for _, name := range Names {
+		if h.Collector(name) == nil {
+			t.Errorf("Collector(%q) = nil", name)
+		}
+	}
+	if h.Collector("gpu") != nil {
+		t.Error("Collector(gpu) should be nil")
+	}
+}
+
+func TestCollectCPU(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).CollectCPU)
+
+	total := find(metrics, "cpu", "cpu", "cpu-total")
+	if total == nil {
+		t.Fatal("missing cpu-total metric")
+	}
+	if got := total.Fields["user"]; got != 101321.53 {
+		t.Errorf("user = %v, want 101321.53", got)
+	}
+	if total.FieldKind("idle") != monitor.KindCounter || total.FieldUnit("idle") != monitor.UnitSeconds {
+		t.Errorf("idle kind/unit = %v/%v", total.FieldKind("idle"), total.FieldUnit("idle"))
+	}
+	if len(total.Fields) != len(cpuFields) {
+		t.Errorf("got %d cpu fields, want %d", len(total.Fields), len(cpuFields))
+	}
+	if find(metrics, "cpu", "cpu", "cpu1") == nil {
+		t.Error("missing per-CPU metric for cpu1")
+	}
+
+	kernel := find(metrics, "kernel", "", "")
+	if kernel == nil {
+		t.Fatal("missing kernel metric")
+	}
+	want := map[string]interface{}{
+		"context_switches": uint64(38014093),
+		"interrupts":       uint64(1462898),
+		"processes_forked": uint64(26442),
+		"procs_running":    uint64(3),
+		"procs_blocked":    uint64(1),
+		"boot_time":        int64(1704067200),
+	}
+	if !reflect.DeepEqual(kernel.Fields, want) {
+		t.Errorf("kernel fields = %v, want %v", kernel.Fields, want)
+	}
+}
+
+func TestCollectCPUTotalOnly(t *testing.T) {
+	metrics := collect(t, testHost(t, func(c *monitor.HostConfig) { c.PerCPU = false }).CollectCPU)
+	if find(metrics, "cpu", "cpu", "cpu0") != nil {
+		t.Error("per-CPU metrics should be skipped")
+	}
+	if find(metrics, "cpu", "cpu", "cpu-total") == nil {
+		t.Error("missing cpu-total metric")
+	}
+}
+
+func TestCollectMemory(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).CollectMemory)
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(metrics))
+	}
+	m := metrics[0]
+	const kb = 1024
+	checks := map[string]interface{}{
+		"total":      uint64(16384000 * kb),
+		"available":  uint64(8192000 * kb),
+		"used":       uint64(8192000 * kb),
+		"used_ratio": 0.5,
+		"swap_used":  uint64(1024000 * kb),
+		"dirty":      uint64(1024 * kb),
+	}
+	for k, want := range checks {
+		if got := m.Fields[k]; got != want {
+			t.Errorf("%s = %v, want %v", k, got, want)
+		}
+	}
+	if m.FieldUnit("total") != monitor.UnitBytes || m.FieldUnit("used_ratio") != monitor.UnitRatio {
+		t.Errorf("units = %v, %v", m.FieldUnit("total"), m.FieldUnit("used_ratio"))
+	}
+}
+
+func TestCollectDiskIO(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).CollectDiskIO)
+	if find(metrics, "diskio", "device", "loop0") != nil {
+		t.Error("loop0 should be ignored")
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("got %d metrics, want 3 (sda, sda1, nvme0n1)", len(metrics))
+	}
+
+	sda := find(metrics, "diskio", "device", "sda")
+	checks := map[string]interface{}{
+		"reads":           uint64(12345),
+		"read_bytes":      uint64(987654 * 512),
+		"read_time":       4.321,
+		"write_bytes":     uint64(1234567 * 512),
+		"ios_in_progress": uint64(2),
+		"io_time":         9.876,
+	}
+	for k, want := range checks {
+		if got := sda.Fields[k]; got != want {
+			t.Errorf("%s = %v, want %v", k, got, want)
+		}
+	}
+	if sda.FieldKind("ios_in_progress") != monitor.KindGauge || sda.FieldKind("reads") != monitor.KindCounter {
+		t.Error("unexpected field kinds")
+	}
+}
+
+func TestCollectNet(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).CollectNet)
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1 (lo ignored)", len(metrics))
+	}
+	eth0 := metrics[0]
+	if eth0.Tags["interface"] != "eth0" {
+		t.Errorf("interface = %q", eth0.Tags["interface"])
+	}
+	want := map[string]interface{}{
+		"bytes_recv":   uint64(987654321),
+		"packets_recv": uint64(654321),
+		"err_in":       uint64(1),
+		"drop_in":      uint64(2),
+		"bytes_sent":   uint64(123456789),
+		"packets_sent": uint64(321000),
+		"err_out":      uint64(3),
+		"drop_out":     uint64(4),
+	}
+	if !reflect.DeepEqual(eth0.Fields, want) {
+		t.Errorf("fields = %v, want %v", eth0.Fields, want)
+	}
+}
+
+func TestCollectLoad(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).CollectLoad)
+	want := map[string]interface{}{
+		"load1":         0.52,
+		"load5":         0.58,
+		"load15":        0.59,
+		"procs_running": uint64(2),
+		"procs_total":   uint64(1234),
+	}
+	if !reflect.DeepEqual(metrics[0].Fields, want) {
+		t.Errorf("fields = %v, want %v", metrics[0].Fields, want)
+	}
+}
+
+func TestCollectFileDescriptors(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).CollectFileDescriptors)
+	m := metrics[0]
+	if m.Fields["allocated"] != uint64(4128) || m.Fields["used"] != uint64(4128) {
+		t.Errorf("fields = %v", m.Fields)
+	}
+	if m.Fields["max"] != uint64(9223372036854775807) {
+		t.Errorf("max = %v", m.Fields["max"])
+	}
+}
+
+func TestCollectFilesystem(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("statfs is only implemented on linux")
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "mnt", "my disk"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := collect(t, testHost(t, func(c *monitor.HostConfig) { c.RootFS = root }).CollectFilesystem)
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2 (/ and /mnt/my disk)", len(metrics))
+	}
+
+	rootfs := find(metrics, "filesystem", "path", "/")
+	if rootfs == nil {
+		t.Fatal("missing / filesystem")
+	}
+	if rootfs.Tags["device"] != "/dev/sda1" || rootfs.Tags["fstype"] != "ext4" {
+		t.Errorf("tags = %v", rootfs.Tags)
+	}
+	total, _ := rootfs.Fields["total"].(uint64)
+	if total == 0 {
+		t.Errorf("total = %v, want > 0", rootfs.Fields["total"])
+	}
+	if r, _ := rootfs.Fields["used_ratio"].(float64); r < 0 || r > 1 {
+		t.Errorf("used_ratio = %v, want 0..1", r)
+	}
+	if find(metrics, "filesystem", "path", "/mnt/my disk") == nil {
+		t.Error("escaped mount point not decoded")
+	}
+}
+
+func TestMissingProcFile(t *testing.T) {
+	h := testHost(t, func(c *monitor.HostConfig) { c.ProcRoot = t.TempDir() })
+	for _, name := range Names {
+		if _, err := h.Collector(name)(context.Background()); err == nil {
+			t.Errorf("%s: expected error for missing proc file", name)
+		}
+	}
+}
+
+func TestOptionRegistersCollectors(t *testing.T) {
+	h := testHost(t, func(c *monitor.HostConfig) { c.Collectors = []string{Load, Memory} })
+	m, err := monitor.New("test", nil, monitor.WithConfig(monitor.DefaultConfig()), h.Option(0))
+	if err != nil {
+		t.Fatalf("monitor.New() error: %v", err)
+	}
+
+	stats := m.CollectorStats()
+	for _, name := range []string{"host_load", "host_memory"} {
+		if _, ok := stats[name]; !ok {
+			t.Errorf("collector %q not registered", name)
+		}
+	}
+	if len(stats) != 2 {
+		t.Errorf("got %d collectors, want 2", len(stats))
+	}
+}
@@ -0,0 +1,48 @@
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// CollectLoad reports the load averages and process counts from
+// /proc/loadavg as the "load" measurement.
+func (h *Host) CollectLoad(ctx context.Context) ([]*monitor.Metric, error) {
+	data, err := os.ReadFile(h.procPath("loadavg"))
+	if err != nil {
+		return nil, err
+	}
+
+	// 0.20 0.18 0.12 1/80 11206
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("loadavg: unexpected format %q", strings.TrimSpace(string(data)))
+	}
+
+	m := monitor.NewMetric("load")
+	for i, name := range []string{"load1", "load5", "load15"} {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("loadavg %s: %w", name, err)
+		}
+		m.WithGauge(name, v)
+	}
+
+	running, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return nil, fmt.Errorf("loadavg: unexpected process counts %q", fields[3])
+	}
+	for name, s := range map[string]string{"procs_running": running, "procs_total": total} {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("loadavg %s: %w", name, err)
+		}
+		m.WithGauge(name, v)
+	}
+	return []*monitor.Metric{m}, nil
+}
@@ -0,0 +1,79 @@
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// memFields maps /proc/meminfo keys to memory field names.
+var memFields = map[string]string{
+	"MemTotal":     "total",
+	"MemFree":      "free",
+	"MemAvailable": "available",
+	"Buffers":      "buffers",
+	"Cached":       "cached",
+	"Shmem":        "shared",
+	"Slab":         "slab",
+	"Dirty":        "dirty",
+	"SwapTotal":    "swap_total",
+	"SwapFree":     "swap_free",
+}
+
+// CollectMemory reports memory and swap usage from /proc/meminfo as the
+// "memory" measurement. Sizes are in bytes; used is total minus available
+// and used_ratio is used over total.
+func (h *Host) CollectMemory(ctx context.Context) ([]*monitor.Metric, error) {
+	values := make(map[string]uint64, len(memFields))
+	err := h.readLines("meminfo", func(line string) error {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil
+		}
+		name, ok := memFields[key]
+		if !ok {
+			return nil
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return fmt.Errorf("%s: missing value", key)
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		values[name] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m := monitor.NewMetric("memory").WithUnit(monitor.UnitBytes)
+	for name, v := range values {
+		m.WithGauge(name, v)
+	}
+
+	total := values["total"]
+	available, ok := values["available"]
+	if !ok {
+		// Kernels before 3.14 have no MemAvailable.
+		available = values["free"] + values["buffers"] + values["cached"]
+	}
+	if available <= total {
+		used := total - available
+		m.WithGauge("used", used)
+		m.WithGauge("used_ratio", ratio(used, total))
+		m.WithFieldMeta("used_ratio", "", monitor.UnitRatio)
+	}
+	if swapTotal, ok := values["swap_total"]; ok && values["swap_free"] <= swapTotal {
+		m.WithGauge("swap_used", swapTotal-values["swap_free"])
+	}
+	return []*monitor.Metric{m}, nil
+}
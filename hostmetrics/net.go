@@ -0,0 +1,61 @@
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// netFields names the /proc/net/dev columns that are reported, by column
+// index; the remaining columns (fifo, frame, compressed, ...) are skipped.
+var netFields = map[int]string{
+	0:  "bytes_recv",
+	1:  "packets_recv",
+	2:  "err_in",
+	3:  "drop_in",
+	8:  "bytes_sent",
+	9:  "packets_sent",
+	10: "err_out",
+	11: "drop_out",
+}
+
+// CollectNet reports network interface counters from /proc/net/dev as the
+// "net" measurement, tagged by interface. Interfaces matching
+// ignore_interfaces are skipped.
+func (h *Host) CollectNet(ctx context.Context) ([]*monitor.Metric, error) {
+	var metrics []*monitor.Metric
+	err := h.readLines("net/dev", func(line string) error {
+		iface, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil // header
+		}
+		iface = strings.TrimSpace(iface)
+		if ignored(iface, h.cfg.IgnoreInterfaces) {
+			return nil
+		}
+
+		cols := strings.Fields(rest)
+		if len(cols) < 16 {
+			return fmt.Errorf("interface %s: expected 16 columns, got %d", iface, len(cols))
+		}
+		m := monitor.NewMetric("net").WithTag("interface", iface)
+		for i, name := range netFields {
+			v, err := strconv.ParseUint(cols[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("interface %s: %w", iface, err)
+			}
+			m.WithCounter(name, v)
+		}
+		m.WithFieldMeta("bytes_recv", "", monitor.UnitBytes)
+		m.WithFieldMeta("bytes_sent", "", monitor.UnitBytes)
+		metrics = append(metrics, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
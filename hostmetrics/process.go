@@ -0,0 +1,186 @@
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// /proc/<pid>/stat columns, counted from the state after the command name.
+const (
+	psMinorFaults = 7
+	psMajorFaults = 9
+	psUserTime    = 11
+	psSystemTime  = 12
+	psThreads     = 17
+	psStartTime   = 19
+	psVirtual     = 20
+	psResident    = 21
+)
+
+// CollectProcess reports the resource usage of this process and of every
+// PID in host.pids as the "process" measurement, plus this process's Go
+// runtime statistics (see CollectRuntime). The collection fails if any of
+// the processes cannot be read; register ProcessCollector separately for
+// processes that come and go.
+func (h *Host) CollectProcess(ctx context.Context) ([]*monitor.Metric, error) {
+	metrics, err := h.ProcessCollector(0)(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pid := range h.cfg.PIDs {
+		m, err := h.ProcessCollector(pid)(ctx)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m...)
+	}
+	rt, err := CollectRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(metrics, rt...), nil
+}
+
+// ProcessCollector returns a CollectFunc reporting the resource usage of the
+// process with the given PID, or of this process when pid is 0, from
+// /proc/<pid>. The "process" measurement is tagged with the pid and the
+// command name, and has cpu_user and cpu_system time, rss and vms, threads,
+// page faults, start_time, and, when readable, open_fds, max_fds, and
+// read_bytes and write_bytes from /proc/<pid>/io.
+func (h *Host) ProcessCollector(pid int) monitor.CollectFunc {
+	dir := "self"
+	if pid != 0 {
+		dir = strconv.Itoa(pid)
+	} else {
+		pid = os.Getpid()
+	}
+
+	return func(ctx context.Context) ([]*monitor.Metric, error) {
+		data, err := os.ReadFile(h.procPath(filepath.Join(dir, "stat")))
+		if err != nil {
+			return nil, fmt.Errorf("process %d: %w", pid, err)
+		}
+		m, err := h.processStat(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("process %d: %w", pid, err)
+		}
+		m.WithTag("pid", strconv.Itoa(pid))
+
+		if entries, err := os.ReadDir(h.procPath(filepath.Join(dir, "fd"))); err == nil {
+			m.WithGauge("open_fds", uint64(len(entries)))
+		} else {
+			h.logger.Debug("cannot count open files", "pid", pid, "error", err)
+		}
+		if limit, ok := h.maxOpenFiles(dir); ok {
+			m.WithGauge("max_fds", limit)
+		}
+		h.processIO(dir, m)
+		return []*monitor.Metric{m}, nil
+	}
+}
+
+func (h *Host) processStat(data string) (*monitor.Metric, error) {
+	// The command name is in parentheses and may itself contain spaces and
+	// parentheses, so split around the first "(" and the last ")".
+	open := strings.IndexByte(data, '(')
+	end := strings.LastIndexByte(data, ')')
+	if open < 0 || end < open {
+		return nil, fmt.Errorf("stat: unexpected format")
+	}
+	name := data[open+1 : end]
+	fields := strings.Fields(data[end+1:])
+	if len(fields) <= psResident {
+		return nil, fmt.Errorf("stat: expected at least %d columns, got %d", psResident+1, len(fields))
+	}
+
+	var vals [psResident + 1]uint64
+	for _, i := range []int{psMinorFaults, psMajorFaults, psUserTime, psSystemTime, psThreads, psStartTime, psVirtual, psResident} {
+		v, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("stat: %w", err)
+		}
+		vals[i] = v
+	}
+
+	m := monitor.NewMetric("process").WithTag("name", name).
+		WithCounter("cpu_user", float64(vals[psUserTime])/userHZ).
+		WithCounter("cpu_system", float64(vals[psSystemTime])/userHZ).
+		WithGauge("rss", vals[psResident]*uint64(os.Getpagesize())).
+		WithGauge("vms", vals[psVirtual]).
+		WithGauge("threads", vals[psThreads]).
+		WithCounter("minor_faults", vals[psMinorFaults]).
+		WithCounter("major_faults", vals[psMajorFaults])
+	m.WithFieldMeta("cpu_user", "", monitor.UnitSeconds)
+	m.WithFieldMeta("cpu_system", "", monitor.UnitSeconds)
+	m.WithFieldMeta("rss", "", monitor.UnitBytes)
+	m.WithFieldMeta("vms", "", monitor.UnitBytes)
+
+	if boot, ok := h.bootTime(); ok {
+		m.WithGauge("start_time", float64(boot)+float64(vals[psStartTime])/userHZ)
+		m.WithFieldMeta("start_time", "", monitor.UnitSeconds)
+	}
+	return m, nil
+}
+
+// bootTime returns the system boot time in Unix seconds from /proc/stat.
+func (h *Host) bootTime() (uint64, bool) {
+	var boot uint64
+	var found bool
+	err := h.readLines("stat", func(line string) error {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return err
+			}
+			boot, found = n, true
+		}
+		return nil
+	})
+	return boot, err == nil && found
+}
+
+// maxOpenFiles returns the soft open file limit from /proc/<pid>/limits.
+func (h *Host) maxOpenFiles(dir string) (uint64, bool) {
+	var limit uint64
+	var found bool
+	err := h.readLines(filepath.Join(dir, "limits"), func(line string) error {
+		rest, ok := strings.CutPrefix(line, "Max open files")
+		if !ok {
+			return nil
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return nil
+		}
+		if n, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			limit, found = n, true
+		}
+		return nil
+	})
+	return limit, err == nil && found
+}
+
+// processIO adds the storage I/O counters from /proc/<pid>/io, which is only
+// readable for processes of the same user.
+func (h *Host) processIO(dir string, m *monitor.Metric) {
+	err := h.readLines(filepath.Join(dir, "io"), func(line string) error {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || (key != "read_bytes" && key != "write_bytes") {
+			return nil
+		}
+		if n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64); err == nil {
+			m.WithCounter(key, n)
+			m.WithFieldMeta(key, "", monitor.UnitBytes)
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Debug("cannot read process I/O", "process", dir, "error", err)
+	}
+}
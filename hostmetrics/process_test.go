@@ -0,0 +1,135 @@
+package hostmetrics
+
+import (
+	"context"
+	"math"
+	"os"
+	"runtime/metrics"
+	"strconv"
+	"testing"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+func TestProcessCollectorSelf(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).ProcessCollector(0))
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(metrics))
+	}
+	m := metrics[0]
+	if m.Measurement != "process" {
+		t.Errorf("Measurement = %q", m.Measurement)
+	}
+	if m.Tags["name"] != "my (weird) app" {
+		t.Errorf("name = %q, want %q", m.Tags["name"], "my (weird) app")
+	}
+	if m.Tags["pid"] != strconv.Itoa(os.Getpid()) {
+		t.Errorf("pid = %q, want own pid", m.Tags["pid"])
+	}
+
+	want := map[string]interface{}{
+		"cpu_user":     2.5,
+		"cpu_system":   0.75,
+		"rss":          uint64(2560 * os.Getpagesize()),
+		"vms":          uint64(104857600),
+		"threads":      uint64(8),
+		"minor_faults": uint64(1500),
+		"major_faults": uint64(12),
+		"start_time":   1704067200 + 123.45,
+		"open_fds":     uint64(3),
+		"max_fds":      uint64(1024),
+		"read_bytes":   uint64(4096),
+		"write_bytes":  uint64(8192),
+	}
+	for k, v := range want {
+		if got := m.Fields[k]; got != v {
+			t.Errorf("%s = %v, want %v", k, got, v)
+		}
+	}
+	if len(m.Fields) != len(want) {
+		t.Errorf("got %d fields, want %d: %v", len(m.Fields), len(want), m.Fields)
+	}
+	if m.FieldKind("cpu_user") != monitor.KindCounter || m.FieldUnit("rss") != monitor.UnitBytes {
+		t.Error("unexpected kind or unit")
+	}
+}
+
+func TestProcessCollectorOtherPID(t *testing.T) {
+	metrics := collect(t, testHost(t, nil).ProcessCollector(4242))
+	m := metrics[0]
+	if m.Tags["pid"] != "4242" || m.Tags["name"] != "sidecar" {
+		t.Errorf("tags = %v", m.Tags)
+	}
+	// No fd, limits or io files: those fields are left out.
+	for _, k := range []string{"open_fds", "max_fds", "read_bytes"} {
+		if _, ok := m.Fields[k]; ok {
+			t.Errorf("unexpected field %s", k)
+		}
+	}
+	if m.Fields["threads"] != uint64(2) {
+		t.Errorf("threads = %v, want 2", m.Fields["threads"])
+	}
+}
+
+func TestProcessCollectorMissingPID(t *testing.T) {
+	if _, err := testHost(t, nil).ProcessCollector(31337)(context.Background()); err == nil {
+		t.Fatal("expected error for missing process")
+	}
+}
+
+func TestCollectProcess(t *testing.T) {
+	h := testHost(t, func(c *monitor.HostConfig) { c.PIDs = []int{4242} })
+	metrics := collect(t, h.CollectProcess)
+
+	if find(metrics, "process", "pid", strconv.Itoa(os.Getpid())) == nil {
+		t.Error("missing own process metric")
+	}
+	if find(metrics, "process", "pid", "4242") == nil {
+		t.Error("missing sidecar process metric")
+	}
+	if find(metrics, "go_runtime", "", "") == nil {
+		t.Error("missing go_runtime metric")
+	}
+}
+
+func TestCollectRuntime(t *testing.T) {
+	metrics := collect(t, CollectRuntime)
+	m := metrics[0]
+
+	goroutines, _ := m.Fields["goroutines"].(uint64)
+	if goroutines == 0 {
+		t.Errorf("goroutines = %v, want > 0", m.Fields["goroutines"])
+	}
+	for _, k := range []string{"heap_alloc", "sys", "heap_goal", "gc_cycles", "alloc"} {
+		if _, ok := m.Fields[k]; !ok {
+			t.Errorf("missing field %s", k)
+		}
+	}
+	if m.FieldKind("alloc") != monitor.KindCounter || m.FieldUnit("heap_alloc") != monitor.UnitBytes {
+		t.Error("unexpected kind or unit")
+	}
+	if _, ok := m.Fields["gc_pause"].(monitor.Histogram); !ok {
+		t.Errorf("gc_pause = %T, want monitor.Histogram", m.Fields["gc_pause"])
+	}
+}
+
+func TestFoldHistogram(t *testing.T) {
+	h := &metrics.Float64Histogram{
+		Buckets: []float64{math.Inf(-1), 0, 1e-6, 1e-5, 1e-3, 1, math.Inf(1)},
+		Counts:  []uint64{0, 4, 3, 2, 1, 1},
+	}
+	got := foldHistogram(h, []float64{1e-5, 1e-3, 1})
+
+	if got.Count != 11 {
+		t.Errorf("Count = %d, want 11", got.Count)
+	}
+	want := map[float64]uint64{1e-5: 7, 1e-3: 9, 1: 10}
+	for b, n := range want {
+		if got.Buckets[b] != n {
+			t.Errorf("bucket %g = %d, want %d", b, got.Buckets[b], n)
+		}
+	}
+	if got.Sum <= 0 {
+		t.Errorf("Sum = %v, want estimate > 0", got.Sum)
+	}
+}
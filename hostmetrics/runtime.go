@@ -0,0 +1,120 @@
+package hostmetrics
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// runtimeField describes a runtime/metrics sample reported as a field.
+type runtimeField struct {
+	name string
+	kind monitor.Kind
+	unit monitor.Unit
+}
+
+// runtimeFields maps runtime/metrics names to go_runtime fields.
+var runtimeFields = map[string]runtimeField{
+	"/sched/goroutines:goroutines":        {"goroutines", monitor.KindGauge, ""},
+	"/sched/gomaxprocs:threads":           {"gomaxprocs", monitor.KindGauge, ""},
+	"/sched/threads/total:threads":        {"threads", monitor.KindGauge, ""},
+	"/memory/classes/total:bytes":         {"sys", monitor.KindGauge, monitor.UnitBytes},
+	"/memory/classes/heap/objects:bytes":  {"heap_alloc", monitor.KindGauge, monitor.UnitBytes},
+	"/memory/classes/heap/released:bytes": {"heap_released", monitor.KindGauge, monitor.UnitBytes},
+	"/memory/classes/heap/stacks:bytes":   {"stack_inuse", monitor.KindGauge, monitor.UnitBytes},
+	"/gc/heap/objects:objects":            {"heap_objects", monitor.KindGauge, ""},
+	"/gc/heap/goal:bytes":                 {"heap_goal", monitor.KindGauge, monitor.UnitBytes},
+	"/gc/heap/allocs:bytes":               {"alloc", monitor.KindCounter, monitor.UnitBytes},
+	"/gc/heap/allocs:objects":             {"alloc_objects", monitor.KindCounter, ""},
+	"/gc/cycles/total:gc-cycles":          {"gc_cycles", monitor.KindCounter, ""},
+	"/cpu/classes/gc/total:cpu-seconds":   {"gc_cpu", monitor.KindCounter, monitor.UnitSeconds},
+	"/cgo/go-to-c-calls:calls":            {"cgo_calls", monitor.KindCounter, ""},
+}
+
+// gcPauseMetric is the runtime/metrics histogram of stop-the-world GC
+// pauses.
+const gcPauseMetric = "/sched/pauses/total/gc:seconds"
+
+// gcPauseBounds are the bucket bounds, in seconds, that the runtime's
+// fine-grained pause histogram is folded into.
+var gcPauseBounds = []float64{10e-6, 50e-6, 100e-6, 500e-6, 1e-3, 5e-3, 10e-3, 50e-3, 100e-3, 500e-3, 1}
+
+// CollectRuntime reports the calling process's Go runtime statistics from
+// runtime/metrics as the "go_runtime" measurement: goroutines, threads,
+// heap and memory usage, allocations, GC cycles and GC CPU time, and a
+// gc_pause histogram of stop-the-world GC pauses. Metrics the running Go
+// version does not support are left out.
+func CollectRuntime(ctx context.Context) ([]*monitor.Metric, error) {
+	samples := make([]metrics.Sample, 0, len(runtimeFields)+1)
+	for name := range runtimeFields {
+		samples = append(samples, metrics.Sample{Name: name})
+	}
+	samples = append(samples, metrics.Sample{Name: gcPauseMetric})
+	metrics.Read(samples)
+
+	m := monitor.NewMetric("go_runtime")
+	for _, s := range samples {
+		if s.Name == gcPauseMetric {
+			if s.Value.Kind() == metrics.KindFloat64Histogram {
+				m.WithHistogram("gc_pause", foldHistogram(s.Value.Float64Histogram(), gcPauseBounds))
+				m.WithFieldMeta("gc_pause", "", monitor.UnitSeconds)
+			}
+			continue
+		}
+
+		f := runtimeFields[s.Name]
+		var value interface{}
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			value = s.Value.Uint64()
+		case metrics.KindFloat64:
+			value = s.Value.Float64()
+		default:
+			continue // not supported by this Go version
+		}
+		if f.kind == monitor.KindCounter {
+			m.WithCounter(f.name, value)
+		} else {
+			m.WithGauge(f.name, value)
+		}
+		if f.unit != "" {
+			m.WithFieldMeta(f.name, "", f.unit)
+		}
+	}
+	return []*monitor.Metric{m}, nil
+}
+
+// foldHistogram converts a runtime/metrics histogram into a cumulative
+// Histogram with the given bounds. A runtime bucket is counted under the
+// first bound at or above its upper edge. The runtime does not track the sum
+// of observations, so it is estimated from bucket midpoints.
+func foldHistogram(h *metrics.Float64Histogram, bounds []float64) monitor.Histogram {
+	out := monitor.Histogram{Buckets: make(map[float64]uint64, len(bounds))}
+	for _, b := range bounds {
+		out.Buckets[b] = 0
+	}
+
+	for i, n := range h.Counts {
+		if n == 0 {
+			continue
+		}
+		lo, hi := h.Buckets[i], h.Buckets[i+1]
+		out.Count += n
+		switch {
+		case math.IsInf(lo, -1):
+			out.Sum += float64(n) * hi
+		case math.IsInf(hi, 1):
+			out.Sum += float64(n) * lo
+		default:
+			out.Sum += float64(n) * (lo + hi) / 2
+		}
+		for _, b := range bounds {
+			if hi <= b {
+				out.Buckets[b] += n
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package hostmetrics
+
+import "syscall"
+
+func statfs(path string) (fsUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return fsUsage{}, err
+	}
+	bsize := uint64(st.Bsize)
+	return fsUsage{
+		total:       st.Blocks * bsize,
+		free:        st.Bfree * bsize,
+		avail:       st.Bavail * bsize,
+		inodesTotal: st.Files,
+		inodesFree:  st.Ffree,
+	}, nil
+}
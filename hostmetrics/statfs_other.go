@@ -0,0 +1,9 @@
+//go:build !linux
+
+package hostmetrics
+
+import "errors"
+
+func statfs(path string) (fsUsage, error) {
+	return fsUsage{}, errors.ErrUnsupported
+}
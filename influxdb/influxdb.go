@@ -2,13 +2,16 @@
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"sync"
 
 	monitor "github.com/danweinerdev/go-monitor"
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/api"
+	influxhttp "github.com/influxdata/influxdb-client-go/v2/api/http"
 	"github.com/influxdata/influxdb-client-go/v2/api/write"
 )
 
@@ -78,19 +81,18 @@ func (b *Backend) Write(ctx context.Context, metrics []*monitor.Metric) error {
 
 	points := make([]*write.Point, 0, len(metrics))
 	for _, m := range metrics {
-		point := influxdb2.NewPoint(
-			m.Measurement,
-			m.Tags,
-			m.Fields,
-			m.Timestamp,
-		)
-		points = append(points, point)
+		points = append(points, b.point(m))
 	}
 
 	if err := writer.WritePoint(ctx, points...); err != nil {
-		b.mu.Lock()
-		b.healthy = false
-		b.mu.Unlock()
+		err = classifyError(err)
+		if monitor.IsRetryable(err) {
+			// Only transient failures say anything about the server's health;
+			// a rejected batch does not.
+			b.mu.Lock()
+			b.healthy = false
+			b.mu.Unlock()
+		}
 		return fmt.Errorf("failed to write to InfluxDB: %w", err)
 	}
 
@@ -102,6 +104,66 @@ func (b *Backend) Write(ctx context.Context, metrics []*monitor.Metric) error {
 	return nil
 }
 
+// Probe checks the InfluxDB health endpoint, marking the backend healthy
+// again when it passes. The pipeline calls it to recover after an outage.
+func (b *Backend) Probe(ctx context.Context) error {
+	b.mu.RLock()
+	client := b.client
+	b.mu.RUnlock()
+
+	if client == nil {
+		return fmt.Errorf("InfluxDB not initialized")
+	}
+
+	health, err := client.Health(ctx)
+	if err != nil {
+		return fmt.Errorf("InfluxDB health check failed: %w", err)
+	}
+	if health.Status != "pass" {
+		return fmt.Errorf("InfluxDB health check failed: %s", health.Status)
+	}
+
+	b.mu.Lock()
+	b.healthy = true
+	b.mu.Unlock()
+	return nil
+}
+
+// point converts a metric to an InfluxDB point. InfluxDB has no distribution
+// types, so histograms and summaries become <field>_count, <field>_sum and
+// per-bucket fields. With unit_tag set, a point whose fields all share a unit
+// carries it in that tag.
+func (b *Backend) point(m *monitor.Metric) *write.Point {
+	m = m.Flatten()
+
+	tags := m.Tags
+	if b.cfg.UnitTag != "" {
+		if unit := commonUnit(m); unit != "" {
+			tags = make(map[string]string, len(m.Tags)+1)
+			for k, v := range m.Tags {
+				tags[k] = v
+			}
+			tags[b.cfg.UnitTag] = string(unit)
+		}
+	}
+
+	return influxdb2.NewPoint(m.Measurement, tags, m.Fields, m.Timestamp)
+}
+
+// commonUnit returns the unit shared by all of a metric's fields, or "" if
+// they have none or differ.
+func commonUnit(m *monitor.Metric) monitor.Unit {
+	var unit monitor.Unit
+	for k := range m.Fields {
+		u := m.FieldUnit(k)
+		if u == "" || (unit != "" && u != unit) {
+			return ""
+		}
+		unit = u
+	}
+	return unit
+}
+
 func (b *Backend) Close() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -123,5 +185,27 @@ func (b *Backend) Healthy() bool {
 	return b.healthy
 }
 
-// Compile-time check.
-var _ monitor.Backend = (*Backend)(nil)
+// classifyError marks responses rejecting the batch itself (400, 413 and
+// 422) as permanent. Everything else stays retryable, including 401, 403 and
+// 404, which fixing the token or bucket resolves without losing the batch.
+func classifyError(err error) error {
+	var herr *influxhttp.Error
+	if !errors.As(err, &herr) {
+		return err
+	}
+
+	switch herr.StatusCode {
+	case http.StatusBadRequest,
+		http.StatusRequestEntityTooLarge,
+		http.StatusUnprocessableEntity:
+		return monitor.Permanent(err)
+	default:
+		return err
+	}
+}
+
+// Compile-time checks.
+var (
+	_ monitor.Backend = (*Backend)(nil)
+	_ monitor.Prober  = (*Backend)(nil)
+)
@@ -1,9 +1,14 @@
 package influxdb
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	monitor "github.com/danweinerdev/go-monitor"
+	influxhttp "github.com/influxdata/influxdb-client-go/v2/api/http"
 )
 
 func TestNewBackend(t *testing.T) {
@@ -63,3 +68,181 @@ func TestBackendCloseNilClient(t *testing.T) {
 		t.Errorf("Close() with nil client should not error, got %v", err)
 	}
 }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"bad request", &influxhttp.Error{StatusCode: http.StatusBadRequest}, false},
+		{"too large", &influxhttp.Error{StatusCode: http.StatusRequestEntityTooLarge}, false},
+		{"unprocessable", &influxhttp.Error{StatusCode: http.StatusUnprocessableEntity}, false},
+		{"unauthorized", &influxhttp.Error{StatusCode: http.StatusUnauthorized}, true},
+		{"forbidden", &influxhttp.Error{StatusCode: http.StatusForbidden}, true},
+		{"bucket not found", &influxhttp.Error{StatusCode: http.StatusNotFound}, true},
+		{"request timeout", &influxhttp.Error{StatusCode: http.StatusRequestTimeout}, true},
+		{"rate limited", &influxhttp.Error{StatusCode: http.StatusTooManyRequests}, true},
+		{"service unavailable", &influxhttp.Error{StatusCode: http.StatusServiceUnavailable}, true},
+		{"network error", fmt.Errorf("connection refused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := monitor.IsRetryable(classifyError(tt.err))
+			if got != tt.retryable {
+				t.Errorf("IsRetryable(classifyError()) = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestBackendWriteRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass","version":"2.7.0","checks":[]}`))
+		case "/api/v2/write":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"invalid","message":"unable to parse points"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	b := New(monitor.InfluxDBConfig{
+		URL:    server.URL,
+		Token:  "test-token",
+		Org:    "test-org",
+		Bucket: "test-bucket",
+	}, nil)
+
+	ctx := context.Background()
+	if err := b.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	defer b.Close()
+
+	err := b.Write(ctx, []*monitor.Metric{monitor.NewMetric("test").WithField("value", 1)})
+	if err == nil {
+		t.Fatal("Write() should fail on a 400 response")
+	}
+	if monitor.IsRetryable(err) {
+		t.Errorf("400 response should be permanent, got %v", err)
+	}
+	if !b.Healthy() {
+		t.Error("A rejected batch should not mark the backend unhealthy")
+	}
+}
+
+func TestBackendProbe(t *testing.T) {
+	status := "pass"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			if status != "pass" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			fmt.Fprintf(w, `{"name":"influxdb","status":%q,"version":"2.7.0","checks":[]}`, status)
+		case "/api/v2/write":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	b := New(monitor.InfluxDBConfig{
+		URL:    server.URL,
+		Token:  "test-token",
+		Org:    "test-org",
+		Bucket: "test-bucket",
+	}, nil)
+
+	ctx := context.Background()
+	if err := b.Probe(ctx); err == nil {
+		t.Error("Probe() should fail before Initialize()")
+	}
+	if err := b.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error: %v", err)
+	}
+	defer b.Close()
+
+	// A 503 on write marks the backend unhealthy.
+	if err := b.Write(ctx, []*monitor.Metric{monitor.NewMetric("test").WithField("value", 1)}); err == nil {
+		t.Fatal("Write() should fail on a 503 response")
+	}
+	if b.Healthy() {
+		t.Fatal("Backend should be unhealthy after a failed write")
+	}
+
+	status = "fail"
+	if err := b.Probe(ctx); err == nil {
+		t.Error("Probe() should fail while the health check fails")
+	}
+
+	status = "pass"
+	if err := b.Probe(ctx); err != nil {
+		t.Fatalf("Probe() error: %v", err)
+	}
+	if !b.Healthy() {
+		t.Error("Backend should be healthy after a passing probe")
+	}
+}
+
+func TestBackendPointUnitTag(t *testing.T) {
+	b := New(monitor.InfluxDBConfig{UnitTag: "unit"}, nil)
+
+	tests := []struct {
+		name   string
+		metric *monitor.Metric
+		want   string
+	}{
+		{
+			"metric unit",
+			monitor.NewMetric("disk").WithUnit(monitor.UnitBytes).WithField("used", 1).WithField("free", 2),
+			"bytes",
+		},
+		{
+			"shared field unit",
+			monitor.NewMetric("http").WithField("p50", 0.1).WithFieldMeta("p50", "", monitor.UnitSeconds),
+			"seconds",
+		},
+		{
+			"mixed units",
+			monitor.NewMetric("net").WithUnit(monitor.UnitBytes).WithField("rx", 1).
+				WithField("errors", 0).WithFieldMeta("errors", "", "errors"),
+			"",
+		},
+		{
+			"no unit",
+			monitor.NewMetric("cpu").WithField("usage", 1.0),
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			for _, tag := range b.point(tt.metric).TagList() {
+				if tag.Key == "unit" {
+					got = tag.Value
+				}
+			}
+			if got != tt.want {
+				t.Errorf("unit tag = %q, want %q", got, tt.want)
+			}
+			if _, ok := tt.metric.Tags["unit"]; ok {
+				t.Error("point() should not modify the metric's tags")
+			}
+		})
+	}
+
+	// Without unit_tag, no tag is added.
+	plain := New(monitor.InfluxDBConfig{}, nil)
+	if len(plain.point(tests[0].metric).TagList()) != 0 {
+		t.Error("point() should not add a unit tag when unit_tag is unset")
+	}
+}
@@ -0,0 +1,390 @@
+package influxlistener
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// maxDatagramSize is the largest UDP datagram read.
+const maxDatagramSize = 64 * 1024
+
+// errTooLarge is returned when a decompressed body exceeds MaxBodySize.
+var errTooLarge = errors.New("request body too large")
+
+// Listener is a monitor.Input that accepts InfluxDB line protocol writes
+// over HTTP, as InfluxDB does, and optionally over UDP, and pushes the
+// parsed metrics into the pipeline.
+//
+// The HTTP server implements:
+//
+//	POST /api/v2/write  InfluxDB 2.x writes; precision=ns|us|ms|s
+//	POST /write         InfluxDB 1.x writes; precision=n|ns|u|us|ms|s|m|h
+//	GET  /ping          204, for clients that check the server first
+//	GET  /health        InfluxDB 2.x health check
+//
+// A write succeeds with 204 once every line has been parsed and pushed. A
+// body containing a malformed line is rejected as a whole with 400 naming
+// the line, a body over MaxBodySize with 413 and a missing or wrong token
+// with 401. A write the pipeline has no room for fails with 429, and one
+// arriving after the monitor stopped with 503; the lines before the first
+// rejected metric have been accepted, so a retried write may repeat them.
+// Bodies may be gzip-compressed. The org, bucket and db parameters are
+// accepted and ignored: everything goes to the pipeline.
+type Listener struct {
+	cfg    monitor.InfluxListenerConfig
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	sink   monitor.Sink
+	server *http.Server
+	http   net.Listener
+	udp    net.PacketConn
+	wg     sync.WaitGroup
+}
+
+// New creates a line protocol listener. It does not listen until started.
+func New(cfg monitor.InfluxListenerConfig, logger *slog.Logger) *Listener {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 32 * 1024 * 1024
+	}
+	return &Listener{cfg: cfg, logger: logger}
+}
+
+func (l *Listener) Name() string {
+	return "influx_listener"
+}
+
+// Start opens the configured HTTP and UDP listeners.
+func (l *Listener) Start(ctx context.Context, sink monitor.Sink) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sink = sink
+
+	if l.cfg.Addr != "" {
+		ln, err := net.Listen("tcp", l.cfg.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for line protocol over HTTP: %w", err)
+		}
+		l.logger.Info("line protocol HTTP listener started", "addr", ln.Addr().String())
+		server := &http.Server{
+			Handler:           l.handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		l.http, l.server = ln, server
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+				l.logger.Error("line protocol HTTP listener error", "error", err)
+			}
+		}()
+	}
+
+	if l.cfg.UDPAddr != "" {
+		conn, err := net.ListenPacket("udp", l.cfg.UDPAddr)
+		if err != nil {
+			if l.server != nil {
+				l.server.Close()
+				l.server, l.http = nil, nil
+			}
+			return fmt.Errorf("failed to listen for line protocol over UDP: %w", err)
+		}
+		l.udp = conn
+		l.wg.Add(1)
+		go l.readUDP(conn)
+		l.logger.Info("line protocol UDP listener started", "addr", conn.LocalAddr().String())
+	}
+	return nil
+}
+
+// Stop shuts the listeners down, letting in-flight HTTP writes finish.
+func (l *Listener) Stop() error {
+	l.mu.Lock()
+	server, udp := l.server, l.udp
+	l.server, l.http, l.udp = nil, nil, nil
+	l.mu.Unlock()
+
+	var errs []error
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		errs = append(errs, server.Shutdown(ctx))
+	}
+	if udp != nil {
+		errs = append(errs, udp.Close())
+	}
+	l.wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Addr returns the HTTP listener's address, or nil if it is not running.
+func (l *Listener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.http == nil {
+		return nil
+	}
+	return l.http.Addr()
+}
+
+// UDPAddr returns the UDP socket's address, or nil if it is not running.
+func (l *Listener) UDPAddr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.udp == nil {
+		return nil
+	}
+	return l.udp.LocalAddr()
+}
+
+func (l *Listener) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/write", func(w http.ResponseWriter, r *http.Request) {
+		l.write(w, r, precisionV2, writeErrorV2)
+	})
+	mux.HandleFunc("/write", func(w http.ResponseWriter, r *http.Request) {
+		l.write(w, r, precisionV1, writeErrorV1)
+	})
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Influxdb-Version", "go-monitor")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"name": "go-monitor", "status": "pass"})
+	})
+	return mux
+}
+
+// precisionParser maps an endpoint's precision parameter to a Precision,
+// reporting whether the endpoint accepts it.
+type precisionParser func(s string) (monitor.Precision, bool)
+
+// precisionV2 maps a 2.x precision parameter to a Precision. 2.x has no
+// minute or hour precision.
+func precisionV2(s string) (monitor.Precision, bool) {
+	p := monitor.Precision(s)
+	switch p {
+	case "":
+		return monitor.PrecisionNanoseconds, true
+	case monitor.PrecisionMinutes, monitor.PrecisionHours:
+		return p, false
+	}
+	return p, p.Valid()
+}
+
+// precisionV1 maps a 1.x precision parameter to a Precision, accepting n
+// and u as well.
+func precisionV1(s string) (monitor.Precision, bool) {
+	switch s {
+	case "", "n":
+		return monitor.PrecisionNanoseconds, true
+	case "u":
+		return monitor.PrecisionMicroseconds, true
+	}
+	p := monitor.Precision(s)
+	return p, p.Valid()
+}
+
+// errorWriter reports a failed write in the dialect of the endpoint.
+type errorWriter func(w http.ResponseWriter, status int, msg string)
+
+// writeErrorV2 writes an InfluxDB 2.x error body.
+func writeErrorV2(w http.ResponseWriter, status int, msg string) {
+	code := "invalid"
+	switch status {
+	case http.StatusUnauthorized:
+		code = "unauthorized"
+	case http.StatusMethodNotAllowed:
+		code = "method not allowed"
+	case http.StatusRequestEntityTooLarge:
+		code = "request too large"
+	case http.StatusTooManyRequests:
+		code = "too many requests"
+	case http.StatusServiceUnavailable:
+		code = "unavailable"
+	}
+	writeJSON(w, status, map[string]string{"code": code, "message": msg})
+}
+
+// writeErrorV1 writes an InfluxDB 1.x error body.
+func writeErrorV1(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (l *Listener) write(w http.ResponseWriter, r *http.Request, parsePrecision precisionParser, fail errorWriter) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		fail(w, http.StatusMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	if !l.authorized(r) {
+		fail(w, http.StatusUnauthorized, "unauthorized access")
+		return
+	}
+	param := r.URL.Query().Get("precision")
+	precision, ok := parsePrecision(param)
+	if !ok {
+		fail(w, http.StatusBadRequest, fmt.Sprintf("invalid precision %q", param))
+		return
+	}
+
+	body := io.Reader(http.MaxBytesReader(w, r.Body, l.cfg.MaxBodySize))
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			fail(w, http.StatusBadRequest, fmt.Sprintf("invalid gzip body: %v", err))
+			return
+		}
+		defer gz.Close()
+		body = &limitedReader{r: gz, n: l.cfg.MaxBodySize}
+	}
+
+	// Read the whole body before parsing, so a truncated body is reported
+	// as too large rather than as a malformed last line.
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) || errors.Is(err, errTooLarge) {
+			fail(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", l.cfg.MaxBodySize))
+		} else {
+			fail(w, http.StatusBadRequest, fmt.Sprintf("error reading body: %v", err))
+		}
+		return
+	}
+
+	metrics, err := monitor.ParseLineProtocol(bytes.NewReader(data), precision)
+	if err != nil {
+		var parseErr *monitor.ParseError
+		if errors.As(err, &parseErr) {
+			fail(w, http.StatusBadRequest, fmt.Sprintf("unable to parse %q: %s", parseErr.Text, err))
+		} else {
+			fail(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	if err := l.push(metrics); err != nil {
+		if errors.Is(err, monitor.ErrBufferFull) {
+			fail(w, http.StatusTooManyRequests, err.Error())
+		} else {
+			fail(w, http.StatusServiceUnavailable, err.Error())
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorized reports whether the request carries the configured token, as
+// "Authorization: Token <token>" or, for 1.x clients, "Bearer <token>".
+func (l *Listener) authorized(r *http.Request) bool {
+	if l.cfg.Token == "" {
+		return true
+	}
+	auth := r.Header.Get("Authorization")
+	return auth == "Token "+l.cfg.Token || auth == "Bearer "+l.cfg.Token
+}
+
+// emitter is implemented by sinks that report why a metric was not
+// accepted, such as monitor.Emitter.
+type emitter interface {
+	Emit(m *monitor.Metric) error
+}
+
+// push hands metrics to the sink, stopping at the first one it rejects when
+// the sink reports errors.
+func (l *Listener) push(metrics []*monitor.Metric) error {
+	l.mu.Lock()
+	sink := l.sink
+	l.mu.Unlock()
+
+	e, ok := sink.(emitter)
+	for _, m := range metrics {
+		if !ok {
+			sink.Push(m)
+			continue
+		}
+		if err := e.Emit(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readUDP parses each datagram as line protocol. Malformed lines are
+// skipped; the rest of the datagram is still used.
+func (l *Listener) readUDP(conn net.PacketConn) {
+	defer l.wg.Done()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			l.logger.Warn("line protocol UDP read error", "error", err)
+			continue
+		}
+
+		var metrics []*monitor.Metric
+		parser := monitor.NewLineParser(bytes.NewReader(buf[:n]), l.cfg.UDPPrecision)
+		for {
+			m, err := parser.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				l.logger.Debug("invalid line protocol datagram line", "error", err)
+				continue
+			}
+			metrics = append(metrics, m)
+		}
+		l.push(metrics)
+	}
+}
+
+// limitedReader fails with errTooLarge once more than n bytes are read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if lr.n < 0 {
+		return 0, errTooLarge
+	}
+	if int64(len(p)) > lr.n+1 {
+		p = p[:lr.n+1]
+	}
+	n, err := lr.r.Read(p)
+	lr.n -= int64(n)
+	if lr.n < 0 {
+		return n, errTooLarge
+	}
+	return n, err
+}
+
+// Compile-time check.
+var _ monitor.Input = (*Listener)(nil)
@@ -0,0 +1,306 @@
+package influxlistener
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	metrics []*monitor.Metric
+}
+
+func (s *recordingSink) Push(m *monitor.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, m)
+}
+
+func (s *recordingSink) snapshot() []*monitor.Metric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*monitor.Metric(nil), s.metrics...)
+}
+
+func startListener(t *testing.T, cfg monitor.InfluxListenerConfig) (*Listener, *recordingSink) {
+	t.Helper()
+	if cfg.Addr == "" {
+		cfg.Addr = "127.0.0.1:0"
+	}
+	l := New(cfg, nil)
+	sink := &recordingSink{}
+	if err := l.Start(context.Background(), sink); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { l.Stop() })
+	return l, sink
+}
+
+func post(t *testing.T, l *Listener, path string, body []byte, header map[string]string) (*http.Response, map[string]string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "http://"+l.Addr().String()+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var msg map[string]string
+	if resp.StatusCode != http.StatusNoContent {
+		json.NewDecoder(resp.Body).Decode(&msg)
+	}
+	return resp, msg
+}
+
+func TestWriteV2(t *testing.T) {
+	l, sink := startListener(t, monitor.InfluxListenerConfig{})
+
+	body := "cpu,host=a usage=0.5 1700000000\nmem,host=a used=42i 1700000001\n"
+	resp, _ := post(t, l, "/api/v2/write?org=o&bucket=b&precision=s", []byte(body), nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+
+	metrics := sink.snapshot()
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(metrics))
+	}
+	if metrics[0].Measurement != "cpu" || metrics[0].Tags["host"] != "a" || metrics[0].Fields["usage"] != 0.5 {
+		t.Errorf("metric = %+v", metrics[0])
+	}
+	if !metrics[1].Timestamp.Equal(time.Unix(1700000001, 0)) {
+		t.Errorf("timestamp = %v, want seconds precision", metrics[1].Timestamp)
+	}
+}
+
+func TestWriteV1Precision(t *testing.T) {
+	l, sink := startListener(t, monitor.InfluxListenerConfig{})
+
+	resp, _ := post(t, l, "/write?db=telegraf&precision=u", []byte("m v=1 1700000000000000"), nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+	if got := sink.snapshot()[0].Timestamp; !got.Equal(time.UnixMicro(1700000000000000)) {
+		t.Errorf("timestamp = %v, want microsecond precision", got)
+	}
+
+	tests := []struct {
+		precision string
+		ts        int64
+		want      time.Time
+	}{
+		{"m", 28333333, time.Unix(28333333*60, 0)},
+		{"h", 472222, time.Unix(472222*3600, 0)},
+	}
+	for _, tt := range tests {
+		body := fmt.Sprintf("m v=1 %d", tt.ts)
+		resp, _ := post(t, l, "/write?precision="+tt.precision, []byte(body), nil)
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("precision=%s: status = %d, want 204", tt.precision, resp.StatusCode)
+		}
+		metrics := sink.snapshot()
+		if got := metrics[len(metrics)-1].Timestamp; !got.Equal(tt.want) {
+			t.Errorf("precision=%s: timestamp = %v, want %v", tt.precision, got, tt.want)
+		}
+	}
+
+	resp, msg := post(t, l, "/write?precision=d", []byte("m v=1 1"), nil)
+	if resp.StatusCode != http.StatusBadRequest || msg["error"] == "" {
+		t.Errorf("unsupported precision: status = %d, body = %v", resp.StatusCode, msg)
+	}
+	resp, _ = post(t, l, "/api/v2/write?precision=h", []byte("m v=1 1"), nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("v2 precision=h: status = %d, want 400", resp.StatusCode)
+	}
+}
+
+// emittingSink is a sink that reports errors, as monitor.Emitter does.
+type emittingSink struct {
+	recordingSink
+	err error
+}
+
+func (s *emittingSink) Emit(m *monitor.Metric) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.Push(m)
+	return nil
+}
+
+func TestWriteRejected(t *testing.T) {
+	l := New(monitor.InfluxListenerConfig{Addr: "127.0.0.1:0"}, nil)
+	sink := &emittingSink{}
+	if err := l.Start(context.Background(), sink); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer l.Stop()
+
+	resp, _ := post(t, l, "/api/v2/write", []byte("cpu usage=1"), nil)
+	if resp.StatusCode != http.StatusNoContent || len(sink.snapshot()) != 1 {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+
+	sink.err = monitor.ErrBufferFull
+	resp, msg := post(t, l, "/api/v2/write", []byte("cpu usage=1"), nil)
+	if resp.StatusCode != http.StatusTooManyRequests || msg["code"] != "too many requests" {
+		t.Errorf("buffer full: status = %d, body = %v", resp.StatusCode, msg)
+	}
+
+	sink.err = monitor.ErrMonitorStopped
+	resp, msg = post(t, l, "/write", []byte("cpu usage=1"), nil)
+	if resp.StatusCode != http.StatusServiceUnavailable || msg["error"] == "" {
+		t.Errorf("monitor stopped: status = %d, body = %v", resp.StatusCode, msg)
+	}
+}
+
+func TestWriteGzip(t *testing.T) {
+	l, sink := startListener(t, monitor.InfluxListenerConfig{})
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("cpu usage=1\ncpu usage=2\n"))
+	gz.Close()
+
+	resp, _ := post(t, l, "/api/v2/write", buf.Bytes(), map[string]string{"Content-Encoding": "gzip"})
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+	if n := len(sink.snapshot()); n != 2 {
+		t.Errorf("got %d metrics, want 2", n)
+	}
+
+	resp, _ = post(t, l, "/api/v2/write", []byte("not gzip"), map[string]string{"Content-Encoding": "gzip"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid gzip: status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestWriteMalformed(t *testing.T) {
+	l, sink := startListener(t, monitor.InfluxListenerConfig{})
+
+	resp, msg := post(t, l, "/api/v2/write", []byte("cpu usage=1\ncpu usage=\n"), nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", resp.StatusCode)
+	}
+	if msg["code"] != "invalid" || !strings.Contains(msg["message"], "line 2") {
+		t.Errorf("error body = %v", msg)
+	}
+	if n := len(sink.snapshot()); n != 0 {
+		t.Errorf("a rejected body should push nothing, got %d metrics", n)
+	}
+
+	resp, msg = post(t, l, "/write", []byte("bad"), nil)
+	if resp.StatusCode != http.StatusBadRequest || msg["error"] == "" {
+		t.Errorf("v1: status = %d, body = %v", resp.StatusCode, msg)
+	}
+}
+
+func TestWriteTooLarge(t *testing.T) {
+	l, _ := startListener(t, monitor.InfluxListenerConfig{MaxBodySize: 64})
+	line := []byte(strings.Repeat("cpu usage=1\n", 20))
+
+	resp, msg := post(t, l, "/api/v2/write", line, nil)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge || msg["code"] != "request too large" {
+		t.Errorf("status = %d, body = %v", resp.StatusCode, msg)
+	}
+
+	// The limit applies to the decompressed body too.
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(line)
+	gz.Close()
+	if buf.Len() > 64 {
+		t.Fatalf("compressed body is %d bytes, test needs it under the limit", buf.Len())
+	}
+	resp, _ = post(t, l, "/api/v2/write", buf.Bytes(), map[string]string{"Content-Encoding": "gzip"})
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("gzip: status = %d, want 413", resp.StatusCode)
+	}
+}
+
+func TestWriteToken(t *testing.T) {
+	l, sink := startListener(t, monitor.InfluxListenerConfig{Token: "secret"})
+
+	resp, msg := post(t, l, "/api/v2/write", []byte("cpu usage=1"), nil)
+	if resp.StatusCode != http.StatusUnauthorized || msg["code"] != "unauthorized" {
+		t.Errorf("status = %d, body = %v", resp.StatusCode, msg)
+	}
+	resp, _ = post(t, l, "/api/v2/write", []byte("cpu usage=1"), map[string]string{"Authorization": "Token secret"})
+	if resp.StatusCode != http.StatusNoContent || len(sink.snapshot()) != 1 {
+		t.Errorf("with token: status = %d", resp.StatusCode)
+	}
+}
+
+func TestPingAndMethod(t *testing.T) {
+	l, _ := startListener(t, monitor.InfluxListenerConfig{})
+	base := "http://" + l.Addr().String()
+
+	resp, err := http.Get(base + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("/ping status = %d, want 204", resp.StatusCode)
+	}
+
+	resp, err = http.Get(base + "/api/v2/write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET write status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestUDP(t *testing.T) {
+	l, sink := startListener(t, monitor.InfluxListenerConfig{
+		UDPAddr:      "127.0.0.1:0",
+		UDPPrecision: monitor.PrecisionMilliseconds,
+	})
+
+	conn, err := net.Dial("udp", l.UDPAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("cpu usage=1 1700000000000\nbroken\ncpu usage=2 1700000000001\n"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.snapshot()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	metrics := sink.snapshot()
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2 (malformed line skipped)", len(metrics))
+	}
+	if !metrics[1].Timestamp.Equal(time.UnixMilli(1700000000001)) {
+		t.Errorf("timestamp = %v, want millisecond precision", metrics[1].Timestamp)
+	}
+
+	if err := l.Stop(); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if l.Addr() != nil || l.UDPAddr() != nil {
+		t.Error("addresses should be nil after Stop")
+	}
+}
@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+)
+
+// Sink receives metrics pushed by an Input. Emitter implements it.
+type Sink interface {
+	Push(m *Metric)
+}
+
+// Input is a push-style metric source, such as a listener that receives
+// metrics over the network. Unlike a CollectFunc it is not polled: the
+// monitor starts it once the pipeline is running and it pushes metrics into
+// the sink as they arrive.
+type Input interface {
+	// Name returns the input name for logging.
+	Name() string
+
+	// Start begins receiving metrics and returns once the input is ready.
+	// The input keeps pushing into sink until Stop is called.
+	Start(ctx context.Context, sink Sink) error
+
+	// Stop shuts the input down, pushing anything it still holds before it
+	// returns.
+	Stop() error
+}
+
+// startInputs starts every input, stopping those already started if one
+// fails. It returns a function that stops them all in reverse order.
+func (m *Monitor) startInputs(ctx context.Context) (func(), error) {
+	stop := func(inputs []Input) {
+		for i := len(inputs) - 1; i >= 0; i-- {
+			if err := inputs[i].Stop(); err != nil {
+				m.logger.Error("error stopping input", "input", inputs[i].Name(), "error", err)
+			}
+		}
+	}
+
+	for i, in := range m.inputs {
+		if err := in.Start(ctx, m.Emitter()); err != nil {
+			stop(m.inputs[:i])
+			return nil, fmt.Errorf("failed to start input %s: %w", in.Name(), err)
+		}
+		m.logger.Info("input started", "input", in.Name())
+	}
+	return func() { stop(m.inputs) }, nil
+}
@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeInput struct {
+	name     string
+	startErr error
+	started  bool
+	stopped  bool
+	sink     Sink
+}
+
+func (f *fakeInput) Name() string { return f.name }
+
+func (f *fakeInput) Start(ctx context.Context, sink Sink) error {
+	if f.startErr != nil {
+		return f.startErr
+	}
+	f.started = true
+	f.sink = sink
+	sink.Push(NewMetric("input").WithTag("phase", "start").WithField("value", 1))
+	return nil
+}
+
+func (f *fakeInput) Stop() error {
+	f.stopped = true
+	f.sink.Push(NewMetric("input").WithTag("phase", "stop").WithField("value", 2))
+	return nil
+}
+
+func TestMonitorInput(t *testing.T) {
+	in := &fakeInput{name: "fake"}
+	backend := &mockBackend{name: "test", healthy: true}
+
+	m, err := New("test", nil,
+		WithConfig(&Config{
+			Global: GlobalConfig{
+				PollInterval:  Duration{time.Hour},
+				LogLevel:      "error",
+				BatchSize:     10,
+				RetryAttempts: 1,
+				RetryDelay:    Duration{time.Millisecond},
+			},
+		}),
+		WithInput(in),
+		WithBackend(backend),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	if !in.started || !in.stopped {
+		t.Fatalf("started = %v, stopped = %v", in.started, in.stopped)
+	}
+	phases := make(map[string]bool)
+	for _, batch := range backend.written {
+		for _, metric := range batch {
+			phases[metric.Tags["phase"]] = true
+		}
+	}
+	if !phases["start"] || !phases["stop"] {
+		t.Errorf("delivered phases = %v, want start and stop", phases)
+	}
+}
+
+func TestMonitorInputStartError(t *testing.T) {
+	first := &fakeInput{name: "first"}
+	second := &fakeInput{name: "second", startErr: errors.New("address in use")}
+
+	m, err := New("test", nil,
+		WithConfig(&Config{Global: GlobalConfig{PollInterval: Duration{time.Hour}, LogLevel: "error", BatchSize: 10}}),
+		WithInput(first),
+		WithInput(second),
+		WithBackend(&mockBackend{name: "test", healthy: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if err := m.Run(context.Background()); err == nil {
+		t.Fatal("Run() should fail when an input cannot start")
+	}
+	if !first.stopped {
+		t.Error("inputs started before the failure should be stopped")
+	}
+}
+
+func TestMonitorInputNotStartedForRunOnce(t *testing.T) {
+	in := &fakeInput{name: "fake"}
+	fn := func(ctx context.Context) ([]*Metric, error) { return nil, nil }
+
+	m, err := New("test", fn, WithInput(in), WithRunOnce(true),
+		WithBackend(&mockBackend{name: "test", healthy: true}))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if in.started {
+		t.Error("inputs should not be started in run-once mode")
+	}
+}
@@ -0,0 +1,187 @@
+package monitor
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Kind describes how a field's value is meant to be interpreted, so backends
+// with typed metrics (such as Prometheus) can export it correctly.
+type Kind int
+
+const (
+	// KindGauge is a value that can go up and down. It is the default for
+	// fields without an explicit kind.
+	KindGauge Kind = iota
+	// KindCounter is a cumulative value that only increases (or resets).
+	KindCounter
+	// KindHistogram is a Histogram value.
+	KindHistogram
+	// KindSummary is a Summary value.
+	KindSummary
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindGauge:
+		return "gauge"
+	case KindCounter:
+		return "counter"
+	case KindHistogram:
+		return "histogram"
+	case KindSummary:
+		return "summary"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *Kind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "gauge":
+		*k = KindGauge
+	case "counter":
+		*k = KindCounter
+	case "histogram":
+		*k = KindHistogram
+	case "summary":
+		*k = KindSummary
+	default:
+		return fmt.Errorf("unknown metric kind %q", text)
+	}
+	return nil
+}
+
+// Histogram is a field value holding a pre-aggregated distribution.
+// Buckets maps each upper bound to the cumulative count of observations less
+// than or equal to it; the +Inf bucket is implied by Count.
+type Histogram struct {
+	Count   uint64
+	Sum     float64
+	Buckets map[float64]uint64
+}
+
+// Summary is a field value holding pre-computed quantiles. Quantiles maps
+// each quantile (0 to 1) to its value.
+type Summary struct {
+	Count     uint64
+	Sum       float64
+	Quantiles map[float64]float64
+}
+
+// Flatten returns the metric with every Histogram and Summary field
+// expanded into scalar fields, for backends without native distribution
+// types:
+//
+//	<field>_count, <field>_sum, <field>_bucket_<le> (histograms)
+//	<field>_count, <field>_sum, <field>_quantile_<q> (summaries)
+//
+// Counts, sums and buckets are marked as counters, and each sum and quantile
+// keeps the distribution's field metadata. The metric itself is returned
+// when there is nothing to expand.
+func (m *Metric) Flatten() *Metric {
+	if !hasDistribution(m.Fields) {
+		return m
+	}
+
+	flat := *m
+	flat.Fields = make(map[string]interface{}, len(m.Fields))
+	flat.FieldKinds = make(map[string]Kind, len(m.FieldKinds))
+	flat.FieldMeta = nil
+	for k, v := range m.Fields {
+		meta, hasMeta := m.FieldMeta[k]
+		switch val := v.(type) {
+		case Histogram:
+			flat.setCounter(k+"_count", val.Count)
+			flat.setCounter(k+"_sum", val.Sum)
+			for le, n := range val.Buckets {
+				flat.setCounter(k+"_bucket_"+formatBound(le), n)
+			}
+			if hasMeta {
+				flat.setMeta(k+"_sum", meta)
+			}
+		case Summary:
+			flat.setCounter(k+"_count", val.Count)
+			flat.setCounter(k+"_sum", val.Sum)
+			for q, qv := range val.Quantiles {
+				key := k + "_quantile_" + formatBound(q)
+				flat.Fields[key] = qv
+				flat.FieldKinds[key] = KindGauge
+				if hasMeta {
+					flat.setMeta(key, meta)
+				}
+			}
+			if hasMeta {
+				flat.setMeta(k+"_sum", meta)
+			}
+		default:
+			flat.Fields[k] = v
+			if kind, ok := m.FieldKinds[k]; ok {
+				flat.FieldKinds[k] = kind
+			}
+			if hasMeta {
+				flat.setMeta(k, meta)
+			}
+		}
+	}
+	return &flat
+}
+
+func (m *Metric) setCounter(key string, value interface{}) {
+	m.Fields[key] = value
+	m.FieldKinds[key] = KindCounter
+}
+
+func (m *Metric) setMeta(key string, meta FieldMeta) {
+	if m.FieldMeta == nil {
+		m.FieldMeta = make(map[string]FieldMeta)
+	}
+	m.FieldMeta[key] = meta
+}
+
+func hasDistribution(fields map[string]interface{}) bool {
+	for _, v := range fields {
+		switch v.(type) {
+		case Histogram, Summary:
+			return true
+		}
+	}
+	return false
+}
+
+// SortedBounds returns the histogram's bucket upper bounds in ascending
+// order.
+func (h Histogram) SortedBounds() []float64 {
+	bounds := make([]float64, 0, len(h.Buckets))
+	for le := range h.Buckets {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+	return bounds
+}
+
+func (h Histogram) clone() Histogram {
+	h.Buckets = maps.Clone(h.Buckets)
+	return h
+}
+
+func (s Summary) clone() Summary {
+	s.Quantiles = maps.Clone(s.Quantiles)
+	return s
+}
+
+func formatBound(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"log/slog"
+	"math"
+	"testing"
+)
+
+func TestKindText(t *testing.T) {
+	for _, k := range []Kind{KindGauge, KindCounter, KindHistogram, KindSummary} {
+		text, _ := k.MarshalText()
+		var got Kind
+		if err := got.UnmarshalText(text); err != nil || got != k {
+			t.Errorf("round trip of %v = %v, %v", k, got, err)
+		}
+	}
+
+	var k Kind
+	if err := k.UnmarshalText([]byte("meter")); err == nil {
+		t.Error("UnmarshalText() should reject unknown kinds")
+	}
+}
+
+func TestMetricFieldKind(t *testing.T) {
+	m := NewMetric("http").
+		WithField("in_flight", 3).
+		WithCounter("requests", 120).
+		WithHistogram("latency", Histogram{Count: 2, Sum: 0.3, Buckets: map[float64]uint64{0.1: 1, 1: 2}}).
+		WithSummary("size", Summary{Count: 2, Sum: 10, Quantiles: map[float64]float64{0.5: 4}})
+
+	tests := map[string]Kind{
+		"in_flight": KindGauge,
+		"requests":  KindCounter,
+		"latency":   KindHistogram,
+		"size":      KindSummary,
+	}
+	for field, want := range tests {
+		if got := m.FieldKind(field); got != want {
+			t.Errorf("FieldKind(%q) = %v, want %v", field, got, want)
+		}
+	}
+
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate() error: %v", err)
+	}
+
+	// The metric's Kind is the default for fields without their own.
+	c := NewMetric("net").WithKind(KindCounter).WithField("rx_bytes", 10).WithGauge("mtu", 1500)
+	if c.FieldKind("rx_bytes") != KindCounter || c.FieldKind("mtu") != KindGauge {
+		t.Errorf("FieldKind() = %v, %v, want counter, gauge", c.FieldKind("rx_bytes"), c.FieldKind("mtu"))
+	}
+}
+
+func TestMetricValidateKindMismatch(t *testing.T) {
+	m := NewMetric("http").WithField("latency", 0.3)
+	m.FieldKinds = map[string]Kind{"latency": KindHistogram}
+
+	if err := m.Validate(); err == nil {
+		t.Error("Validate() should reject a histogram field holding a scalar")
+	}
+}
+
+func TestMetricFlatten(t *testing.T) {
+	m := NewMetric("http").
+		WithCounter("requests", 120).
+		WithHistogram("latency", Histogram{
+			Count:   3,
+			Sum:     1.5,
+			Buckets: map[float64]uint64{0.1: 1, 1: 2, math.Inf(1): 3},
+		}).
+		WithSummary("size", Summary{Count: 3, Sum: 30, Quantiles: map[float64]float64{0.99: 20}})
+
+	flat := m.Flatten()
+	want := map[string]interface{}{
+		"requests":            120,
+		"latency_count":       uint64(3),
+		"latency_sum":         1.5,
+		"latency_bucket_0.1":  uint64(1),
+		"latency_bucket_1":    uint64(2),
+		"latency_bucket_+Inf": uint64(3),
+		"size_count":          uint64(3),
+		"size_sum":            30.0,
+		"size_quantile_0.99":  20.0,
+	}
+	if len(flat.Fields) != len(want) {
+		t.Errorf("Flatten() fields = %v, want %v", flat.Fields, want)
+	}
+	for k, v := range want {
+		if flat.Fields[k] != v {
+			t.Errorf("Fields[%q] = %v (%T), want %v (%T)", k, flat.Fields[k], flat.Fields[k], v, v)
+		}
+	}
+	if flat.FieldKind("latency_bucket_1") != KindCounter || flat.FieldKind("size_quantile_0.99") != KindGauge {
+		t.Error("flattened buckets should be counters and quantiles gauges")
+	}
+	if flat.FieldKind("requests") != KindCounter {
+		t.Error("Flatten() should keep the kinds of scalar fields")
+	}
+	if _, ok := m.Fields["latency"]; !ok {
+		t.Error("Flatten() should not modify the original metric")
+	}
+
+	scalar := NewMetric("cpu").WithField("usage", 1.0)
+	if scalar.Flatten() != scalar {
+		t.Error("Flatten() should return the metric itself when there is nothing to expand")
+	}
+}
+
+func TestMetricCloneDistribution(t *testing.T) {
+	m := NewMetric("http").
+		WithCounter("requests", 1).
+		WithHistogram("latency", Histogram{Count: 1, Buckets: map[float64]uint64{1: 1}})
+
+	clone := m.Clone()
+	clone.Fields["latency"].(Histogram).Buckets[1] = 99
+	clone.FieldKinds["requests"] = KindGauge
+
+	if m.Fields["latency"].(Histogram).Buckets[1] != 1 {
+		t.Error("Clone() should deep copy histogram buckets")
+	}
+	if m.FieldKind("requests") != KindCounter {
+		t.Error("Clone() should copy FieldKinds")
+	}
+}
+
+func TestSpoolDistributionRoundTrip(t *testing.T) {
+	s, err := openSpool(t.TempDir(), 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+
+	m := NewMetric("http").
+		WithCounter("requests", int64(5)).
+		WithHistogram("latency", Histogram{Count: 1, Sum: 0.2, Buckets: map[float64]uint64{0.5: 1}}).
+		WithSummary("size", Summary{Count: 1, Sum: 7, Quantiles: map[float64]float64{0.5: 7}})
+	if err := s.Append([]*Metric{m}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	s.Replay(func(batch []*Metric) error {
+		got := batch[0]
+		if h, ok := got.Fields["latency"].(Histogram); !ok || h.Buckets[0.5] != 1 {
+			t.Errorf("latency = %#v, want the histogram", got.Fields["latency"])
+		}
+		if _, ok := got.Fields["size"].(Summary); !ok {
+			t.Errorf("size = %#v, want the summary", got.Fields["size"])
+		}
+		if got.FieldKind("requests") != KindCounter {
+			t.Errorf("FieldKind(requests) = %v, want counter", got.FieldKind("requests"))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,332 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseError describes a line that could not be parsed.
+type ParseError struct {
+	Line int    // 1-based line number
+	Msg  string // what went wrong
+	Text string // the offending line
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// LineParser reads metrics from InfluxDB line protocol, one line at a time.
+//
+// It accepts what LineEncoder writes and the standard line protocol syntax:
+// escaped measurement, tag and field names; integer (i), unsigned (u),
+// float, boolean and quoted string field values; and an optional timestamp
+// at the parser's precision. Blank lines and lines starting with # are
+// skipped. Lines without a timestamp get the time they were parsed. As in
+// InfluxDB, a backslash before any other character is kept as written, so
+// \n is a backslash and an n rather than a newline.
+type LineParser struct {
+	r         *bufio.Reader
+	precision Precision
+	line      int
+}
+
+// NewLineParser returns a parser reading from r with timestamps at the given
+// precision. An invalid precision falls back to nanoseconds.
+func NewLineParser(r io.Reader, precision Precision) *LineParser {
+	if !precision.Valid() {
+		precision = PrecisionNanoseconds
+	}
+	return &LineParser{r: bufio.NewReader(r), precision: precision}
+}
+
+// Next returns the next metric. It returns io.EOF when the input is
+// exhausted. A malformed line yields a *ParseError; parsing can continue with
+// the following line by calling Next again.
+func (p *LineParser) Next() (*Metric, error) {
+	for {
+		raw, err := p.r.ReadBytes('\n')
+		if len(raw) == 0 && err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		p.line++
+
+		line := bytes.TrimRight(raw, "\r\n")
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) == 0 || trimmed[0] == '#' {
+			if err != nil {
+				return nil, io.EOF
+			}
+			continue
+		}
+
+		m, perr := parseLine(string(trimmed), p.precision)
+		if perr != nil {
+			return nil, &ParseError{Line: p.line, Msg: perr.Error(), Text: string(line)}
+		}
+		return m, nil
+	}
+}
+
+// ParseLineProtocol parses every line from r. It stops at the first error,
+// returning the metrics parsed before it.
+func ParseLineProtocol(r io.Reader, precision Precision) ([]*Metric, error) {
+	p := NewLineParser(r, precision)
+	var metrics []*Metric
+	for {
+		m, err := p.Next()
+		if err == io.EOF {
+			return metrics, nil
+		}
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+}
+
+// lineScanner walks a single line of line protocol.
+type lineScanner struct {
+	s   string
+	pos int
+}
+
+func (l *lineScanner) done() bool { return l.pos >= len(l.s) }
+
+func (l *lineScanner) peek() byte { return l.s[l.pos] }
+
+// token reads up to the first unescaped byte in stops and returns it
+// unescaped. The stop byte is not consumed.
+func (l *lineScanner) token(stops string) string {
+	var sb strings.Builder
+	for l.pos < len(l.s) {
+		c := l.s[l.pos]
+		if c == '\\' && l.pos+1 < len(l.s) {
+			next := l.s[l.pos+1]
+			switch next {
+			case ',', ' ', '=', '\\', '"':
+				sb.WriteByte(next)
+			default:
+				sb.WriteByte(c)
+				sb.WriteByte(next)
+			}
+			l.pos += 2
+			continue
+		}
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+		sb.WriteByte(c)
+		l.pos++
+	}
+	return sb.String()
+}
+
+// quoted reads a double-quoted string value; the opening quote is at pos.
+func (l *lineScanner) quoted() (string, error) {
+	var sb strings.Builder
+	l.pos++ // opening quote
+	for l.pos < len(l.s) {
+		c := l.s[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.s):
+			switch next := l.s[l.pos+1]; next {
+			case '"', '\\':
+				sb.WriteByte(next)
+			default:
+				sb.WriteByte(c)
+				sb.WriteByte(next)
+			}
+			l.pos += 2
+		case c == '"':
+			l.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			l.pos++
+		}
+	}
+	return "", errors.New("unterminated string field value")
+}
+
+func parseLine(s string, precision Precision) (*Metric, error) {
+	l := &lineScanner{s: s}
+
+	name := l.token(", ")
+	if name == "" {
+		return nil, errors.New("missing measurement")
+	}
+	m := NewMetric(name)
+
+	// Tags.
+	for !l.done() && l.peek() == ',' {
+		l.pos++
+		key := l.token("=, ")
+		if l.done() || l.peek() != '=' {
+			return nil, fmt.Errorf("tag %q has no value", key)
+		}
+		l.pos++
+		value := l.token(", ")
+		if key == "" || value == "" {
+			return nil, errors.New("empty tag key or value")
+		}
+		m.Tags[key] = value
+	}
+
+	if l.done() || l.peek() != ' ' {
+		return nil, errors.New("missing fields")
+	}
+	for !l.done() && l.peek() == ' ' {
+		l.pos++
+	}
+
+	// Fields.
+	for {
+		key := l.token("=, ")
+		if key == "" {
+			return nil, errors.New("empty field key")
+		}
+		if l.done() || l.peek() != '=' {
+			return nil, fmt.Errorf("field %q has no value", key)
+		}
+		l.pos++
+		if l.done() {
+			return nil, fmt.Errorf("field %q has no value", key)
+		}
+
+		var value interface{}
+		if l.peek() == '"' {
+			str, err := l.quoted()
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", key, err)
+			}
+			value = str
+		} else {
+			var err error
+			if value, err = parseFieldValue(l.token(", ")); err != nil {
+				return nil, fmt.Errorf("field %q: %w", key, err)
+			}
+		}
+		m.Fields[key] = value
+
+		if l.done() || l.peek() != ',' {
+			break
+		}
+		l.pos++
+	}
+
+	// Optional timestamp.
+	rest := strings.TrimSpace(l.s[l.pos:])
+	if rest != "" {
+		if l.s[l.pos] != ' ' {
+			return nil, fmt.Errorf("unexpected %q after fields", rest)
+		}
+		ts, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", rest)
+		}
+		m.Timestamp = timeAt(ts, precision)
+	}
+
+	return m, nil
+}
+
+func parseFieldValue(s string) (interface{}, error) {
+	if s == "" {
+		return nil, errors.New("empty value")
+	}
+	switch s {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+	switch s[len(s)-1] {
+	case 'i':
+		v, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return v, nil
+	case 'u':
+		v, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		return v, nil
+	}
+	if !isDecimalFloat(s) {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// isDecimalFloat reports whether s is a plain decimal float such as -1.5 or
+// 2e-3. ParseFloat also accepts NaN, infinities, hex floats and underscores,
+// none of which line protocol allows.
+func isDecimalFloat(s string) bool {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(s) && isDigit(s[i]); i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		for i++; i < len(s) && isDigit(s[i]); i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		for ; i < len(s) && isDigit(s[i]); i++ {
+		}
+		if i == start {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// timeAt converts an integer timestamp at the given precision to a time.
+func timeAt(ts int64, p Precision) time.Time {
+	switch p {
+	case PrecisionHours:
+		return time.Unix(ts*3600, 0)
+	case PrecisionMinutes:
+		return time.Unix(ts*60, 0)
+	case PrecisionSeconds:
+		return time.Unix(ts, 0)
+	case PrecisionMilliseconds:
+		return time.UnixMilli(ts)
+	case PrecisionMicroseconds:
+		return time.UnixMicro(ts)
+	default:
+		return time.Unix(0, ts)
+	}
+}
@@ -0,0 +1,277 @@
+package monitor
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLineProtocolTypes(t *testing.T) {
+	input := `cpu,host=server1,region=us-east usage=42.5,cores=8i,bytes=1024u,online=t,offline=FALSE,name="box 1",exp=1e3,small=-2.5E-1,frac=.5 1704067200000000000`
+	metrics, err := ParseLineProtocol(strings.NewReader(input), PrecisionNanoseconds)
+	if err != nil {
+		t.Fatalf("ParseLineProtocol() error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(metrics))
+	}
+	m := metrics[0]
+	if m.Measurement != "cpu" {
+		t.Errorf("Measurement = %q, want cpu", m.Measurement)
+	}
+	wantTags := map[string]string{"host": "server1", "region": "us-east"}
+	if !reflect.DeepEqual(m.Tags, wantTags) {
+		t.Errorf("Tags = %v, want %v", m.Tags, wantTags)
+	}
+	wantFields := map[string]interface{}{
+		"usage":   42.5,
+		"cores":   int64(8),
+		"bytes":   uint64(1024),
+		"online":  true,
+		"offline": false,
+		"name":    "box 1",
+		"exp":     1000.0,
+		"small":   -0.25,
+		"frac":    0.5,
+	}
+	if !reflect.DeepEqual(m.Fields, wantFields) {
+		t.Errorf("Fields = %#v, want %#v", m.Fields, wantFields)
+	}
+	if want := time.Unix(1704067200, 0); !m.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", m.Timestamp, want)
+	}
+}
+
+func TestParseLineProtocolEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		check func(t *testing.T, m *Metric)
+	}{
+		{
+			"measurement",
+			`my\ cpu\,total=x v=1i`,
+			func(t *testing.T, m *Metric) {
+				if m.Measurement != "my cpu,total=x" {
+					t.Errorf("Measurement = %q", m.Measurement)
+				}
+			},
+		},
+		{
+			"tag key and value",
+			`m,a\ b\=c=d\,e\ f\=g v=1i`,
+			func(t *testing.T, m *Metric) {
+				if m.Tags["a b=c"] != "d,e f=g" {
+					t.Errorf("Tags = %v", m.Tags)
+				}
+			},
+		},
+		{
+			"backslashes",
+			`m\\x,note=a\nb,path=C:\\temp v=1i`,
+			func(t *testing.T, m *Metric) {
+				if m.Measurement != `m\x` || m.Tags["path"] != `C:\temp` || m.Tags["note"] != `a\nb` {
+					t.Errorf("got %q %v", m.Measurement, m.Tags)
+				}
+			},
+		},
+		{
+			"field key",
+			`m a\ b\,c\=d=1i`,
+			func(t *testing.T, m *Metric) {
+				if m.Fields["a b,c=d"] != int64(1) {
+					t.Errorf("Fields = %v", m.Fields)
+				}
+			},
+		},
+		{
+			"string field",
+			`m msg="say \"hi\", \\ bye\nend",v=1`,
+			func(t *testing.T, m *Metric) {
+				if m.Fields["msg"] != `say "hi", \ bye\nend` || m.Fields["v"] != 1.0 {
+					t.Errorf("Fields = %#v", m.Fields)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := ParseLineProtocol(strings.NewReader(tt.line), PrecisionNanoseconds)
+			if err != nil {
+				t.Fatalf("ParseLineProtocol() error: %v", err)
+			}
+			tt.check(t, metrics[0])
+		})
+	}
+}
+
+func TestParseLineProtocolRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 12, 30, 0, 123456789, time.UTC)
+	original := []*Metric{
+		NewMetric("cpu").
+			WithTags(map[string]string{"host": "server 1", "path": `C:\x`, "k=v": "a,b"}).
+			WithFields(map[string]interface{}{
+				"usage":  42.5,
+				"cores":  int64(8),
+				"bytes":  uint64(1 << 40),
+				"online": true,
+				"msg":    "line one, line \"two\" \\",
+			}).
+			WithTimestamp(ts),
+		NewMetric(`weird\ name,x`).WithField("v", -1.25e-7).WithTimestamp(ts),
+	}
+
+	var text strings.Builder
+	for _, m := range original {
+		text.WriteString(m.ToLineProtocol())
+		text.WriteString("\n")
+	}
+
+	parsed, err := ParseLineProtocol(strings.NewReader(text.String()), PrecisionNanoseconds)
+	if err != nil {
+		t.Fatalf("ParseLineProtocol() error: %v", err)
+	}
+	if len(parsed) != len(original) {
+		t.Fatalf("got %d metrics, want %d", len(parsed), len(original))
+	}
+	for i := range original {
+		want, got := original[i], parsed[i]
+		if got.Measurement != want.Measurement {
+			t.Errorf("[%d] Measurement = %q, want %q", i, got.Measurement, want.Measurement)
+		}
+		if !reflect.DeepEqual(got.Tags, want.Tags) {
+			t.Errorf("[%d] Tags = %v, want %v", i, got.Tags, want.Tags)
+		}
+		if !reflect.DeepEqual(got.Fields, want.Fields) {
+			t.Errorf("[%d] Fields = %#v, want %#v", i, got.Fields, want.Fields)
+		}
+		if !got.Timestamp.Equal(want.Timestamp) {
+			t.Errorf("[%d] Timestamp = %v, want %v", i, got.Timestamp, want.Timestamp)
+		}
+		if got.ToLineProtocol() != want.ToLineProtocol() {
+			t.Errorf("[%d] re-encoded %q, want %q", i, got.ToLineProtocol(), want.ToLineProtocol())
+		}
+	}
+}
+
+func TestParseLineProtocolPrecision(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		precision Precision
+		value     string
+	}{
+		{PrecisionHours, "473352"},
+		{PrecisionMinutes, "28401120"},
+		{PrecisionSeconds, "1704067200"},
+		{PrecisionMilliseconds, "1704067200000"},
+		{PrecisionMicroseconds, "1704067200000000"},
+		{PrecisionNanoseconds, "1704067200000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.precision), func(t *testing.T) {
+			metrics, err := ParseLineProtocol(strings.NewReader("m v=1 "+tt.value), tt.precision)
+			if err != nil {
+				t.Fatalf("ParseLineProtocol() error: %v", err)
+			}
+			if !metrics[0].Timestamp.Equal(ts) {
+				t.Errorf("Timestamp = %v, want %v", metrics[0].Timestamp, ts)
+			}
+		})
+	}
+}
+
+func TestParseLineProtocolNoTimestamp(t *testing.T) {
+	before := time.Now()
+	metrics, err := ParseLineProtocol(strings.NewReader("m v=1\n"), PrecisionNanoseconds)
+	if err != nil {
+		t.Fatalf("ParseLineProtocol() error: %v", err)
+	}
+	if metrics[0].Timestamp.Before(before) {
+		t.Errorf("Timestamp = %v, want parse time", metrics[0].Timestamp)
+	}
+}
+
+func TestParseLineProtocolSkipsBlankAndComments(t *testing.T) {
+	input := "# header\n\nm v=1\r\n   \n  # indented comment\nm v=2"
+	metrics, err := ParseLineProtocol(strings.NewReader(input), PrecisionNanoseconds)
+	if err != nil {
+		t.Fatalf("ParseLineProtocol() error: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(metrics))
+	}
+	if metrics[1].Fields["v"] != 2.0 {
+		t.Errorf("second metric v = %v, want 2", metrics[1].Fields["v"])
+	}
+}
+
+func TestParseLineProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"no fields", "cpu"},
+		{"only tags", "cpu,host=a"},
+		{"tag without value", "cpu,host v=1"},
+		{"empty tag value", "cpu,host= v=1"},
+		{"field without value", "cpu v"},
+		{"empty field value", "cpu v="},
+		{"empty field key", "cpu =1"},
+		{"bad float", "cpu v=abc"},
+		{"NaN", "cpu v=NaN"},
+		{"infinity", "cpu v=+Inf"},
+		{"spelled infinity", "cpu v=-Infinity"},
+		{"hex float", "cpu v=0x1p-2"},
+		{"underscore", "cpu v=1_000"},
+		{"integer underscore", "cpu v=1_000i"},
+		{"bare exponent", "cpu v=1e"},
+		{"bare point", "cpu v=."},
+		{"bad integer", "cpu v=1.5i"},
+		{"negative unsigned", "cpu v=-1u"},
+		{"unterminated string", `cpu v="abc`},
+		{"bad timestamp", "cpu v=1 yesterday"},
+		{"trailing garbage", `cpu v="a"x`},
+		{"missing measurement", ",host=a v=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLineProtocol(strings.NewReader("ok v=1\n"+tt.line), PrecisionNanoseconds)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want *ParseError", err)
+			}
+			if perr.Line != 2 {
+				t.Errorf("Line = %d, want 2", perr.Line)
+			}
+		})
+	}
+}
+
+func TestLineParserContinuesAfterError(t *testing.T) {
+	p := NewLineParser(strings.NewReader("a v=1\nbad\nc v=3\n"), PrecisionNanoseconds)
+
+	var names []string
+	var errs int
+	for {
+		m, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs++
+			continue
+		}
+		names = append(names, m.Measurement)
+	}
+	if errs != 1 {
+		t.Errorf("errors = %d, want 1", errs)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "c"}) {
+		t.Errorf("names = %v, want [a c]", names)
+	}
+}
@@ -0,0 +1,241 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Precision is the timestamp precision of InfluxDB line protocol.
+type Precision string
+
+const (
+	PrecisionHours        Precision = "h"
+	PrecisionMinutes      Precision = "m"
+	PrecisionSeconds      Precision = "s"
+	PrecisionMilliseconds Precision = "ms"
+	PrecisionMicroseconds Precision = "us"
+	PrecisionNanoseconds  Precision = "ns"
+)
+
+// Valid reports whether the precision is one of the known precisions.
+func (p Precision) Valid() bool {
+	switch p {
+	case PrecisionHours, PrecisionMinutes, PrecisionSeconds, PrecisionMilliseconds, PrecisionMicroseconds, PrecisionNanoseconds:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrNoFields is returned when a metric has no fields that line protocol can
+// represent, for example because every field is NaN or infinite.
+var ErrNoFields = errors.New("metric has no encodable fields")
+
+// LineEncoder encodes metrics as InfluxDB line protocol.
+//
+// Output is deterministic: tags and fields are sorted by key. Measurements,
+// tag keys, tag values and field keys are escaped following the line
+// protocol rules, with backslashes and newlines escaped as well so a line
+// never breaks. Tags with an empty key or value and fields with an empty key
+// are left out, as are NaN and infinite floats, which line protocol cannot
+// represent. Histogram and summary fields are flattened (see
+// Metric.Flatten). A zero timestamp is omitted so the server assigns one.
+//
+// A LineEncoder reuses internal buffers and is not safe for concurrent use.
+type LineEncoder struct {
+	precision Precision
+	buf       []byte
+	keys      []string
+}
+
+// NewLineEncoder returns an encoder writing timestamps at the given
+// precision. An invalid precision falls back to nanoseconds.
+func NewLineEncoder(precision Precision) *LineEncoder {
+	if !precision.Valid() {
+		precision = PrecisionNanoseconds
+	}
+	return &LineEncoder{precision: precision}
+}
+
+// Encode writes m to w as a single line, including the trailing newline.
+func (e *LineEncoder) Encode(w io.Writer, m *Metric) error {
+	var err error
+	e.buf, err = e.Append(e.buf[:0], m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(e.buf)
+	return err
+}
+
+// Append appends m to dst as a single line, including the trailing newline,
+// and returns the extended buffer. On error dst is returned unchanged.
+func (e *LineEncoder) Append(dst []byte, m *Metric) ([]byte, error) {
+	if m.Measurement == "" {
+		return dst, fmt.Errorf("measurement name is required")
+	}
+	m = m.Flatten()
+	start := len(dst)
+
+	dst = appendEscaped(dst, m.Measurement, false)
+
+	e.keys = e.keys[:0]
+	for k, v := range m.Tags {
+		if k != "" && v != "" {
+			e.keys = append(e.keys, k)
+		}
+	}
+	slices.Sort(e.keys)
+	for _, k := range e.keys {
+		dst = append(dst, ',')
+		dst = appendEscaped(dst, k, true)
+		dst = append(dst, '=')
+		dst = appendEscaped(dst, m.Tags[k], true)
+	}
+
+	e.keys = e.keys[:0]
+	for k, v := range m.Fields {
+		if k != "" && encodable(v) {
+			e.keys = append(e.keys, k)
+		}
+	}
+	if len(e.keys) == 0 {
+		return dst[:start], ErrNoFields
+	}
+	slices.Sort(e.keys)
+	for i, k := range e.keys {
+		if i == 0 {
+			dst = append(dst, ' ')
+		} else {
+			dst = append(dst, ',')
+		}
+		dst = appendEscaped(dst, k, true)
+		dst = append(dst, '=')
+		dst = appendFieldValue(dst, m.Fields[k])
+	}
+
+	if !m.Timestamp.IsZero() {
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, timestampAt(m.Timestamp, e.precision), 10)
+	}
+
+	return append(dst, '\n'), nil
+}
+
+// timestampAt converts t to an integer timestamp at the given precision.
+func timestampAt(t time.Time, p Precision) int64 {
+	switch p {
+	case PrecisionHours:
+		return t.Unix() / 3600
+	case PrecisionMinutes:
+		return t.Unix() / 60
+	case PrecisionSeconds:
+		return t.Unix()
+	case PrecisionMilliseconds:
+		return t.UnixMilli()
+	case PrecisionMicroseconds:
+		return t.UnixMicro()
+	default:
+		return t.UnixNano()
+	}
+}
+
+// encodable reports whether a field value can be written as line protocol.
+func encodable(v interface{}) bool {
+	switch val := v.(type) {
+	case float64:
+		return !math.IsNaN(val) && !math.IsInf(val, 0)
+	case float32:
+		return !math.IsNaN(float64(val)) && !math.IsInf(float64(val), 0)
+	case nil:
+		return false
+	default:
+		return true
+	}
+}
+
+// appendEscaped appends a measurement (withEquals false) or a tag key, tag
+// value or field key (withEquals true) with line protocol escaping. Line
+// protocol has no escape for control characters, so each one, such as a
+// newline or tab, is written as an escaped space.
+func appendEscaped(dst []byte, s string, withEquals bool) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == ',', c == ' ', c == '\\':
+			dst = append(dst, '\\', c)
+		case c == '=':
+			if withEquals {
+				dst = append(dst, '\\')
+			}
+			dst = append(dst, c)
+		case isControl(c):
+			dst = append(dst, '\\', ' ')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// appendFieldString appends a double-quoted string field value. Only double
+// quotes and backslashes are escapes in string values; control characters
+// such as newlines are written as spaces, since a line cannot contain them.
+func appendFieldString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"', c == '\\':
+			dst = append(dst, '\\', c)
+		case isControl(c):
+			dst = append(dst, ' ')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"')
+}
+
+// isControl reports whether c is an ASCII control character.
+func isControl(c byte) bool {
+	return c < 0x20 || c == 0x7f
+}
+
+func appendFieldValue(dst []byte, v interface{}) []byte {
+	switch val := v.(type) {
+	case float64:
+		return strconv.AppendFloat(dst, val, 'g', -1, 64)
+	case float32:
+		return strconv.AppendFloat(dst, float64(val), 'g', -1, 32)
+	case int:
+		return append(strconv.AppendInt(dst, int64(val), 10), 'i')
+	case int8:
+		return append(strconv.AppendInt(dst, int64(val), 10), 'i')
+	case int16:
+		return append(strconv.AppendInt(dst, int64(val), 10), 'i')
+	case int32:
+		return append(strconv.AppendInt(dst, int64(val), 10), 'i')
+	case int64:
+		return append(strconv.AppendInt(dst, val, 10), 'i')
+	case uint:
+		return append(strconv.AppendUint(dst, uint64(val), 10), 'u')
+	case uint8:
+		return append(strconv.AppendUint(dst, uint64(val), 10), 'u')
+	case uint16:
+		return append(strconv.AppendUint(dst, uint64(val), 10), 'u')
+	case uint32:
+		return append(strconv.AppendUint(dst, uint64(val), 10), 'u')
+	case uint64:
+		return append(strconv.AppendUint(dst, val, 10), 'u')
+	case bool:
+		return strconv.AppendBool(dst, val)
+	case string:
+		return appendFieldString(dst, val)
+	default:
+		return appendFieldString(dst, fmt.Sprint(val))
+	}
+}
@@ -0,0 +1,173 @@
+package monitor
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLineEncoderDeterministic(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMetric("cpu").
+		WithTags(map[string]string{"region": "us-east", "host": "server1", "az": "a"}).
+		WithFields(map[string]interface{}{"usage": 42.5, "idle": 57.5, "cores": 8, "online": true}).
+		WithTimestamp(ts)
+
+	want := "cpu,az=a,host=server1,region=us-east cores=8i,idle=57.5,online=true,usage=42.5 1704067200000000000\n"
+	enc := NewLineEncoder(PrecisionNanoseconds)
+	for i := 0; i < 20; i++ {
+		line, err := enc.Append(nil, m)
+		if err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+		if string(line) != want {
+			t.Fatalf("Append() = %q, want %q", line, want)
+		}
+	}
+}
+
+func TestLineEncoderEscaping(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric *Metric
+		want   string
+	}{
+		{
+			"measurement",
+			NewMetric("my cpu,total=x").WithField("v", 1),
+			`my\ cpu\,total=x v=1i`,
+		},
+		{
+			"tag key and value",
+			NewMetric("m").WithTag("a b=c", "d,e f=g").WithField("v", 1),
+			`m,a\ b\=c=d\,e\ f\=g v=1i`,
+		},
+		{
+			"backslash and control characters",
+			NewMetric(`m\x`).WithTag("path", `C:\temp`).WithTag("note", "a\nb\tc").WithField("v", 1),
+			`m\\x,note=a\ b\ c,path=C:\\temp v=1i`,
+		},
+		{
+			"field key",
+			NewMetric("m").WithField("a b,c=d", 1),
+			`m a\ b\,c\=d=1i`,
+		},
+		{
+			"string field",
+			NewMetric("m").WithField("msg", `say "hi" \ bye`+"\r\nend"),
+			`m msg="say \"hi\" \\ bye  end"`,
+		},
+		{
+			"empty tags skipped",
+			NewMetric("m").WithTag("empty", "").WithTag("", "x").WithField("v", 1),
+			`m v=1i`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.metric.Timestamp = time.Time{}
+			line, err := NewLineEncoder(PrecisionNanoseconds).Append(nil, tt.metric)
+			if err != nil {
+				t.Fatalf("Append() error: %v", err)
+			}
+			if got := string(line); got != tt.want+"\n" {
+				t.Errorf("Append() = %q, want %q", got, tt.want+"\n")
+			}
+		})
+	}
+}
+
+func TestLineEncoderNonFinite(t *testing.T) {
+	m := NewMetric("m").
+		WithField("ok", 1.5).
+		WithField("nan", math.NaN()).
+		WithField("inf", math.Inf(1)).
+		WithField("ninf", float32(math.Inf(-1))).
+		WithTimestamp(time.Time{})
+
+	line, err := NewLineEncoder(PrecisionNanoseconds).Append(nil, m)
+	if err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if string(line) != "m ok=1.5\n" {
+		t.Errorf("Append() = %q, want NaN and Inf fields skipped", line)
+	}
+
+	onlyNaN := NewMetric("m").WithField("nan", math.NaN())
+	dst := []byte("keep\n")
+	dst, err = NewLineEncoder(PrecisionNanoseconds).Append(dst, onlyNaN)
+	if !errors.Is(err, ErrNoFields) {
+		t.Errorf("Append() error = %v, want ErrNoFields", err)
+	}
+	if string(dst) != "keep\n" {
+		t.Errorf("Append() should leave dst unchanged on error, got %q", dst)
+	}
+}
+
+func TestLineEncoderPrecision(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	m := NewMetric("m").WithField("v", 1).WithTimestamp(ts)
+
+	tests := map[Precision]string{
+		PrecisionHours:        "m v=1i 473352\n",
+		PrecisionMinutes:      "m v=1i 28401120\n",
+		PrecisionSeconds:      "m v=1i 1704067200\n",
+		PrecisionMilliseconds: "m v=1i 1704067200123\n",
+		PrecisionMicroseconds: "m v=1i 1704067200123456\n",
+		PrecisionNanoseconds:  "m v=1i 1704067200123456789\n",
+		"bogus":               "m v=1i 1704067200123456789\n",
+	}
+	for p, want := range tests {
+		line, _ := NewLineEncoder(p).Append(nil, m)
+		if string(line) != want {
+			t.Errorf("precision %q: Append() = %q, want %q", p, line, want)
+		}
+	}
+}
+
+func TestLineEncoderEncode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewLineEncoder(PrecisionSeconds)
+	ts := time.Unix(1700000000, 0)
+
+	enc.Encode(&buf, NewMetric("a").WithField("v", 1).WithTimestamp(ts))
+	enc.Encode(&buf, NewMetric("b").WithHistogram("h", Histogram{Count: 2, Sum: 3}).WithTimestamp(ts))
+
+	want := "a v=1i 1700000000\nb h_count=2u,h_sum=3 1700000000\n"
+	if buf.String() != want {
+		t.Errorf("Encode() wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestLineEncoderAllocations(t *testing.T) {
+	m := NewMetric("cpu").
+		WithTag("host", "server1").
+		WithTag("region", "us-east").
+		WithField("usage", 42.5).
+		WithField("cores", 8)
+
+	enc := NewLineEncoder(PrecisionNanoseconds)
+	buf := make([]byte, 0, 256)
+	enc.Append(buf, m) // warm up the key buffer
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = enc.Append(buf[:0], m)
+	})
+	if allocs != 0 {
+		t.Errorf("Append() allocated %v times per metric, want 0", allocs)
+	}
+}
+
+func TestPrecisionValid(t *testing.T) {
+	for _, p := range []Precision{PrecisionHours, PrecisionMinutes, PrecisionSeconds, PrecisionMilliseconds, PrecisionMicroseconds, PrecisionNanoseconds} {
+		if !p.Valid() {
+			t.Errorf("%q should be valid", p)
+		}
+	}
+	if Precision("d").Valid() {
+		t.Error(`"d" should not be valid`)
+	}
+}
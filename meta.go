@@ -0,0 +1,59 @@
+package monitor
+
+// Unit is the unit of a field's value. Backends that support units use it
+// to name or annotate the exported metric; the predefined units follow the
+// Prometheus base-unit conventions.
+type Unit string
+
+const (
+	UnitSeconds Unit = "seconds"
+	UnitBytes   Unit = "bytes"
+	UnitRatio   Unit = "ratio"
+)
+
+// FieldMeta describes a single field of a metric.
+type FieldMeta struct {
+	Help string
+	Unit Unit
+}
+
+// WithHelp sets the description used for the metric's fields when they have
+// none of their own.
+func (m *Metric) WithHelp(help string) *Metric {
+	m.Help = help
+	return m
+}
+
+// WithUnit sets the unit used for the metric's fields when they have none of
+// their own.
+func (m *Metric) WithUnit(unit Unit) *Metric {
+	m.Unit = unit
+	return m
+}
+
+// WithFieldMeta sets the description and unit of a single field.
+func (m *Metric) WithFieldMeta(key, help string, unit Unit) *Metric {
+	if m.FieldMeta == nil {
+		m.FieldMeta = make(map[string]FieldMeta)
+	}
+	m.FieldMeta[key] = FieldMeta{Help: help, Unit: unit}
+	return m
+}
+
+// FieldHelp returns the description of the named field, falling back to the
+// metric's Help.
+func (m *Metric) FieldHelp(key string) string {
+	if meta, ok := m.FieldMeta[key]; ok && meta.Help != "" {
+		return meta.Help
+	}
+	return m.Help
+}
+
+// FieldUnit returns the unit of the named field, falling back to the
+// metric's Unit.
+func (m *Metric) FieldUnit(key string) Unit {
+	if meta, ok := m.FieldMeta[key]; ok && meta.Unit != "" {
+		return meta.Unit
+	}
+	return m.Unit
+}
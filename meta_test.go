@@ -0,0 +1,54 @@
+package monitor
+
+import "testing"
+
+func TestMetricFieldMeta(t *testing.T) {
+	m := NewMetric("disk").
+		WithHelp("Disk usage.").
+		WithUnit(UnitBytes).
+		WithField("used", 100).
+		WithField("used_pct", 0.5).
+		WithFieldMeta("used_pct", "Fraction of the disk in use.", UnitRatio)
+
+	if got := m.FieldHelp("used"); got != "Disk usage." {
+		t.Errorf("FieldHelp(used) = %q, want the metric's help", got)
+	}
+	if got := m.FieldUnit("used"); got != UnitBytes {
+		t.Errorf("FieldUnit(used) = %q, want %q", got, UnitBytes)
+	}
+	if got := m.FieldHelp("used_pct"); got != "Fraction of the disk in use." {
+		t.Errorf("FieldHelp(used_pct) = %q", got)
+	}
+	if got := m.FieldUnit("used_pct"); got != UnitRatio {
+		t.Errorf("FieldUnit(used_pct) = %q, want %q", got, UnitRatio)
+	}
+
+	// Empty per-field values fall back to the metric's.
+	m.WithFieldMeta("used", "", "")
+	if m.FieldHelp("used") != "Disk usage." || m.FieldUnit("used") != UnitBytes {
+		t.Error("empty FieldMeta should fall back to the metric's help and unit")
+	}
+
+	clone := m.Clone()
+	clone.FieldMeta["used_pct"] = FieldMeta{}
+	if clone.Help != m.Help || clone.Unit != m.Unit {
+		t.Error("Clone() should copy Help and Unit")
+	}
+	if m.FieldUnit("used_pct") != UnitRatio {
+		t.Error("Clone() should copy FieldMeta")
+	}
+}
+
+func TestMetricFlattenKeepsMeta(t *testing.T) {
+	m := NewMetric("http").
+		WithHistogram("latency", Histogram{Count: 1, Sum: 0.2, Buckets: map[float64]uint64{0.5: 1}}).
+		WithFieldMeta("latency", "Request latency.", UnitSeconds)
+
+	flat := m.Flatten()
+	if flat.FieldUnit("latency_sum") != UnitSeconds || flat.FieldHelp("latency_sum") != "Request latency." {
+		t.Error("the flattened sum should keep the histogram's metadata")
+	}
+	if flat.FieldUnit("latency_count") != "" {
+		t.Error("the flattened count should have no unit")
+	}
+}
@@ -2,16 +2,27 @@
 
 import (
 	"fmt"
-	"strings"
 	"time"
 )
 
 // Metric represents a single data point to be sent to backends.
+//
+// Kind is the default kind of the metric's fields; FieldKinds overrides it
+// per field. Histogram and Summary field values are always of their own
+// kind. See FieldKind.
+//
+// Help and Unit likewise describe all fields unless FieldMeta has an entry
+// for the field. See FieldHelp and FieldUnit.
 type Metric struct {
 	Measurement string
 	Tags        map[string]string
 	Fields      map[string]interface{}
 	Timestamp   time.Time
+	Kind        Kind
+	FieldKinds  map[string]Kind
+	Help        string
+	Unit        Unit
+	FieldMeta   map[string]FieldMeta
 }
 
 // NewMetric creates a new Metric with the given measurement name.
@@ -52,6 +63,56 @@ func (m *Metric) WithField(key string, value interface{}) *Metric {
 	return m
 }
 
+// WithKind sets the default kind of the metric's fields.
+func (m *Metric) WithKind(kind Kind) *Metric {
+	m.Kind = kind
+	return m
+}
+
+// WithCounter adds a field holding a cumulative counter.
+func (m *Metric) WithCounter(key string, value interface{}) *Metric {
+	return m.withKind(key, value, KindCounter)
+}
+
+// WithGauge adds a field holding a gauge. Fields are gauges by default, so
+// this only matters for metrics whose Kind is something else.
+func (m *Metric) WithGauge(key string, value interface{}) *Metric {
+	return m.withKind(key, value, KindGauge)
+}
+
+// WithHistogram adds a histogram field.
+func (m *Metric) WithHistogram(key string, h Histogram) *Metric {
+	return m.withKind(key, h, KindHistogram)
+}
+
+// WithSummary adds a summary field.
+func (m *Metric) WithSummary(key string, s Summary) *Metric {
+	return m.withKind(key, s, KindSummary)
+}
+
+func (m *Metric) withKind(key string, value interface{}, kind Kind) *Metric {
+	m.Fields[key] = value
+	if m.FieldKinds == nil {
+		m.FieldKinds = make(map[string]Kind)
+	}
+	m.FieldKinds[key] = kind
+	return m
+}
+
+// FieldKind returns the kind of the named field.
+func (m *Metric) FieldKind(key string) Kind {
+	switch m.Fields[key].(type) {
+	case Histogram:
+		return KindHistogram
+	case Summary:
+		return KindSummary
+	}
+	if kind, ok := m.FieldKinds[key]; ok {
+		return kind
+	}
+	return m.Kind
+}
+
 // WithTimestamp sets the metric timestamp.
 func (m *Metric) WithTimestamp(t time.Time) *Metric {
 	m.Timestamp = t
@@ -65,13 +126,34 @@ func (m *Metric) Clone() *Metric {
 		Tags:        make(map[string]string, len(m.Tags)),
 		Fields:      make(map[string]interface{}, len(m.Fields)),
 		Timestamp:   m.Timestamp,
+		Kind:        m.Kind,
+		Help:        m.Help,
+		Unit:        m.Unit,
 	}
 	for k, v := range m.Tags {
 		clone.Tags[k] = v
 	}
 	for k, v := range m.Fields {
+		switch val := v.(type) {
+		case Histogram:
+			v = val.clone()
+		case Summary:
+			v = val.clone()
+		}
 		clone.Fields[k] = v
 	}
+	if m.FieldKinds != nil {
+		clone.FieldKinds = make(map[string]Kind, len(m.FieldKinds))
+		for k, v := range m.FieldKinds {
+			clone.FieldKinds[k] = v
+		}
+	}
+	if m.FieldMeta != nil {
+		clone.FieldMeta = make(map[string]FieldMeta, len(m.FieldMeta))
+		for k, v := range m.FieldMeta {
+			clone.FieldMeta[k] = v
+		}
+	}
 	return clone
 }
 
@@ -83,89 +165,36 @@ func (m *Metric) Validate() error {
 	if len(m.Fields) == 0 {
 		return fmt.Errorf("at least one field is required")
 	}
+	for k, v := range m.Fields {
+		switch kind := m.FieldKind(k); kind {
+		case KindHistogram, KindSummary:
+			if _, ok := v.(Histogram); ok && kind == KindHistogram {
+				continue
+			}
+			if _, ok := v.(Summary); ok && kind == KindSummary {
+				continue
+			}
+			return fmt.Errorf("field %q is a %s but holds %T", k, kind, v)
+		}
+	}
 	return nil
 }
 
-// ToLineProtocol converts the metric to InfluxDB line protocol format.
+// ToLineProtocol converts the metric to InfluxDB line protocol format with
+// nanosecond timestamps, without a trailing newline. It returns an empty
+// string if the metric cannot be encoded. See LineEncoder.
 func (m *Metric) ToLineProtocol() string {
-	var sb strings.Builder
-
-	sb.WriteString(escapeKey(m.Measurement))
-
-	for k, v := range m.Tags {
-		sb.WriteByte(',')
-		sb.WriteString(escapeKey(k))
-		sb.WriteByte('=')
-		sb.WriteString(escapeTagValue(v))
+	line, err := NewLineEncoder(PrecisionNanoseconds).Append(nil, m)
+	if err != nil {
+		return ""
 	}
-
-	sb.WriteByte(' ')
-
-	first := true
-	for k, v := range m.Fields {
-		if !first {
-			sb.WriteByte(',')
-		}
-		first = false
-		sb.WriteString(escapeKey(k))
-		sb.WriteByte('=')
-		sb.WriteString(formatFieldValue(v))
-	}
-
-	sb.WriteByte(' ')
-	sb.WriteString(fmt.Sprintf("%d", m.Timestamp.UnixNano()))
-
-	return sb.String()
+	return string(line[:len(line)-1])
 }
 
 func escapeKey(s string) string {
-	s = strings.ReplaceAll(s, ",", "\\,")
-	s = strings.ReplaceAll(s, "=", "\\=")
-	s = strings.ReplaceAll(s, " ", "\\ ")
-	return s
-}
-
-func escapeTagValue(s string) string {
-	s = strings.ReplaceAll(s, ",", "\\,")
-	s = strings.ReplaceAll(s, "=", "\\=")
-	s = strings.ReplaceAll(s, " ", "\\ ")
-	return s
+	return string(appendEscaped(nil, s, true))
 }
 
 func formatFieldValue(v interface{}) string {
-	switch val := v.(type) {
-	case float64:
-		return fmt.Sprintf("%g", val)
-	case float32:
-		return fmt.Sprintf("%g", val)
-	case int:
-		return fmt.Sprintf("%di", val)
-	case int8:
-		return fmt.Sprintf("%di", val)
-	case int16:
-		return fmt.Sprintf("%di", val)
-	case int32:
-		return fmt.Sprintf("%di", val)
-	case int64:
-		return fmt.Sprintf("%di", val)
-	case uint:
-		return fmt.Sprintf("%du", val)
-	case uint8:
-		return fmt.Sprintf("%du", val)
-	case uint16:
-		return fmt.Sprintf("%du", val)
-	case uint32:
-		return fmt.Sprintf("%du", val)
-	case uint64:
-		return fmt.Sprintf("%du", val)
-	case bool:
-		if val {
-			return "true"
-		}
-		return "false"
-	case string:
-		return fmt.Sprintf("%q", val)
-	default:
-		return fmt.Sprintf("%q", fmt.Sprint(val))
-	}
+	return string(appendFieldValue(nil, v))
 }
@@ -2,8 +2,10 @@
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 )
 
@@ -12,32 +14,57 @@
 
 // Monitor is the core runtime that handles polling, signals, backends, and shutdown.
 type Monitor struct {
-	name      string
-	collector CollectFunc
-	pipeline  *Pipeline
-	signals   *SignalHandler
-	logger    *slog.Logger
-	levelVar  *slog.LevelVar
-	cfg       *Config
-	cfgPath   string
-	echoMode  bool
-	runOnce   bool
-	reloadFn  func(string) (*Config, error)
-	backends  []Backend
-	stats     statsTracker
+	name       string
+	collectors []*collector
+	pipeline   *Pipeline
+	signals    *SignalHandler
+	logger     *slog.Logger
+	levelVar   *slog.LevelVar
+	cfg        *Config
+	cfgPath    string
+	echoMode   bool
+	runOnce    bool
+	reloadFn   func(string) (*Config, error)
+	backends   []Backend
+	inputs     []Input
+	processors []Processor
+	// backendProcessors holds the per-backend chains from config, keyed by
+	// backend name.
+	backendProcessors map[string][]Processor
+	stats             statsTracker
+	emitMu            sync.RWMutex
+	ran               bool
+	stopped           bool
+	inflight          sync.WaitGroup
+	adminCh           chan adminCommand
 }
 
 // New creates a new Monitor with the given name, collect function, and options.
-func New(name string, collector CollectFunc, opts ...Option) (*Monitor, error) {
+// The collect function is registered as DefaultCollectorName and may be nil
+// when collectors are added with WithCollector or inputs with WithInput.
+func New(name string, collect CollectFunc, opts ...Option) (*Monitor, error) {
 	m := &Monitor{
-		name:      name,
-		collector: collector,
+		name: name,
+	}
+	if collect != nil {
+		m.collectors = append(m.collectors, &collector{name: DefaultCollectorName, fn: collect})
 	}
 
 	for _, opt := range opts {
 		opt(m)
 	}
 
+	if len(m.collectors) == 0 && len(m.inputs) == 0 {
+		return nil, fmt.Errorf("no collectors configured (pass a CollectFunc or use WithCollector or WithInput)")
+	}
+	seen := make(map[string]bool, len(m.collectors))
+	for _, c := range m.collectors {
+		if seen[c.name] {
+			return nil, fmt.Errorf("duplicate collector name %q", c.name)
+		}
+		seen[c.name] = true
+	}
+
 	// Load config from file if path given and no config provided directly.
 	if m.cfg == nil && m.cfgPath != "" {
 		cfg, err := LoadConfig(m.cfgPath)
@@ -57,26 +84,66 @@ func New(name string, collector CollectFunc, opts ...Option) (*Monitor, error) {
 		m.logger, m.levelVar = NewLogger(m.cfg.Global.LogLevel)
 	}
 
+	if t := m.cfg.Telemetry; t.Enabled {
+		if seen[TelemetryCollectorName] {
+			return nil, fmt.Errorf("collector name %q is reserved when telemetry is enabled", TelemetryCollectorName)
+		}
+		prefix := t.Prefix
+		if prefix == "" {
+			prefix = "gomonitor"
+		}
+		m.collectors = append(m.collectors, &collector{
+			name:     TelemetryCollectorName,
+			fn:       m.telemetryCollector(prefix),
+			interval: t.PollInterval.Duration,
+		})
+	}
+
+	if err := m.setupProcessors(); err != nil {
+		return nil, fmt.Errorf("processors: %w", err)
+	}
+
+	// The pipeline exists before Run so metrics emitted early are buffered
+	// until it starts.
+	pipelineCfg := PipelineConfig{
+		BatchSize:        m.cfg.Global.BatchSize,
+		FlushInterval:    m.cfg.Global.PollInterval.Duration,
+		RetryAttempts:    m.cfg.Global.RetryAttempts,
+		RetryDelay:       m.cfg.Global.RetryDelay.Duration,
+		RetryMaxDelay:    m.cfg.Global.RetryMaxDelay.Duration,
+		QueueSize:        m.cfg.Global.QueueSize,
+		BreakerThreshold: m.cfg.Global.BreakerThreshold,
+		BreakerCooldown:  m.cfg.Global.BreakerCooldown.Duration,
+		MaxBufferSize:    m.cfg.Global.MaxBufferSize,
+		OverflowPolicy:   m.cfg.Global.OverflowPolicy,
+		BlockTimeout:     m.cfg.Global.BlockTimeout.Duration,
+		TripOnPanic:      m.cfg.Global.TripOnPanic,
+		Spool:            m.cfg.Spool,
+		Logger:           m.logger,
+	}
+	m.pipeline = NewPipeline(pipelineCfg)
+
 	return m, nil
 }
 
-// Run starts the monitor and blocks until shutdown.
+// Run starts the monitor and blocks until shutdown. A Monitor can only be
+// run once.
 func (m *Monitor) Run(ctx context.Context) error {
+	m.emitMu.Lock()
+	ran := m.ran
+	m.ran = true
+	m.emitMu.Unlock()
+	if ran {
+		return errors.New("monitor has already been run")
+	}
+	defer m.closeEmitter()
+
 	m.logger.Info("starting monitor",
 		"name", m.name,
 		"interval", m.cfg.Global.PollInterval.Duration,
+		"collectors", len(m.collectors),
 	)
 
-	// Build pipeline.
-	pipelineCfg := PipelineConfig{
-		BatchSize:     m.cfg.Global.BatchSize,
-		FlushInterval: m.cfg.Global.PollInterval.Duration,
-		RetryAttempts: m.cfg.Global.RetryAttempts,
-		RetryDelay:    m.cfg.Global.RetryDelay.Duration,
-		Logger:        m.logger,
-	}
-	m.pipeline = NewPipeline(pipelineCfg)
-
 	// Add backends.
 	if err := m.addBackends(); err != nil {
 		return err
@@ -87,6 +154,7 @@ func (m *Monitor) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to start pipeline: %w", err)
 	}
 	defer func() {
+		m.closeEmitter()
 		stopCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		if err := m.pipeline.Stop(stopCtx); err != nil {
@@ -98,49 +166,154 @@ func (m *Monitor) Run(ctx context.Context) error {
 	m.signals = NewSignalHandler(m.logger)
 	ctx = m.signals.Start(ctx)
 
-	// Immediate first collection.
-	m.collect(ctx)
+	if m.cfg.Admin.Enabled && !m.runOnce {
+		admin, err := m.startAdmin(m.cfg.Admin)
+		if err != nil {
+			return err
+		}
+		defer admin.stop()
+	}
+
+	// Inputs only make sense for a long-running monitor.
+	if !m.runOnce {
+		stopInputs, err := m.startInputs(ctx)
+		if err != nil {
+			return err
+		}
+		defer stopInputs()
+	}
 
 	if m.runOnce {
+		for _, c := range m.collectors {
+			if c.enabled(m.cfg) {
+				m.collectNow(ctx, c)
+			}
+		}
 		return nil
 	}
 
-	// Main polling loop.
-	ticker := time.NewTicker(m.cfg.Global.PollInterval.Duration)
-	defer ticker.Stop()
+	// Immediate first collection. Collections run in the background so a
+	// slow collector never holds up the loop below.
+	now := time.Now()
+	for _, c := range m.collectors {
+		if !c.enabled(m.cfg) {
+			continue
+		}
+		m.schedule(ctx, c)
+		c.next = now.Add(c.intervalFor(m.cfg))
+	}
+
+	// Main polling loop. A single timer is armed for whichever collector is
+	// due next.
+	timer := time.NewTimer(m.untilNextDue(time.Now()))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			m.logger.Info("shutting down, performing final collection...")
 			finalCtx, finalCancel := context.WithTimeout(context.Background(), 30*time.Second)
-			m.collect(finalCtx)
+			m.waitInflight(finalCtx)
+			for _, c := range m.collectors {
+				if c.enabled(m.cfg) {
+					m.collectNow(finalCtx, c)
+				}
+			}
 			finalCancel()
 			m.logger.Info("shutdown complete")
 			return nil
 
 		case <-m.signals.Reload():
-			m.handleReload(ticker)
+			m.handleReload()
+
+		case cmd := <-m.adminCh:
+			cmd.reply <- m.handleAdmin(ctx, cmd)
+
+		case <-timer.C:
+			now := time.Now()
+			for _, c := range m.collectors {
+				if !c.enabled(m.cfg) || now.Before(c.next) {
+					continue
+				}
+				m.schedule(ctx, c)
+				c.next = nextDue(c.next, c.intervalFor(m.cfg), now)
+			}
+		}
 
-		case <-ticker.C:
-			m.collect(ctx)
+		timer.Reset(m.untilNextDue(time.Now()))
+	}
+}
+
+// untilNextDue returns how long until the earliest enabled collector is due.
+func (m *Monitor) untilNextDue(now time.Time) time.Duration {
+	var earliest time.Time
+	for _, c := range m.collectors {
+		if !c.enabled(m.cfg) {
+			continue
+		}
+		if earliest.IsZero() || c.next.Before(earliest) {
+			earliest = c.next
 		}
 	}
+	if earliest.IsZero() {
+		// Everything is disabled; wake up occasionally in case a reload
+		// re-enables something.
+		return m.cfg.Global.PollInterval.Duration
+	}
+	return max(earliest.Sub(now), 0)
+}
+
+// nextDue advances a schedule by whole intervals past now, so a slow
+// collection skips missed slots instead of firing repeatedly to catch up.
+func nextDue(prev time.Time, interval time.Duration, now time.Time) time.Time {
+	next := prev.Add(interval)
+	if next.After(now) {
+		return next
+	}
+	missed := now.Sub(next)/interval + 1
+	return next.Add(missed * interval)
 }
 
-// Stats returns a snapshot of polling statistics.
+// Stats returns a snapshot of polling statistics across all collectors.
 func (m *Monitor) Stats() PollStats {
 	return m.stats.snapshot()
 }
 
+// CollectorStats returns a snapshot of polling statistics per collector,
+// keyed by collector name.
+func (m *Monitor) CollectorStats() map[string]PollStats {
+	stats := make(map[string]PollStats, len(m.collectors))
+	for _, c := range m.collectors {
+		stats[c.name] = c.stats.snapshot()
+	}
+	return stats
+}
+
 func (m *Monitor) addBackends() error {
+	used := make(map[string]bool)
+	add := func(b Backend) {
+		name := b.Name()
+		if procs := m.backendProcessors[name]; len(procs) > 0 {
+			b = NewProcessedBackend(b, procs...)
+			used[name] = true
+		}
+		// The filter sees metrics before the backend's own processors.
+		if f, ok := m.cfg.Filters[name]; ok {
+			if !f.empty() {
+				b = NewFilteredBackend(b, f)
+			}
+			used[name] = true
+		}
+		m.pipeline.AddBackend(b)
+	}
+
 	// Add user-provided backends first.
 	for _, b := range m.backends {
-		m.pipeline.AddBackend(b)
+		add(b)
 	}
 
 	if m.echoMode {
-		m.pipeline.AddBackend(NewEchoStdout(m.logger))
+		add(NewEchoStdout(m.logger))
 	} else {
 		// InfluxDB and Prometheus are handled by sub-packages;
 		// the user adds them via WithBackend.
@@ -151,34 +324,182 @@ func (m *Monitor) addBackends() error {
 		return fmt.Errorf("no backends configured (use WithBackend, WithEcho, or enable backends in config)")
 	}
 
+	for name := range m.backendProcessors {
+		if !used[name] {
+			m.logger.Warn("processors configured for unknown backend", "backend", name)
+		}
+	}
+	for name := range m.cfg.Filters {
+		if !used[name] {
+			m.logger.Warn("filter configured for unknown backend", "backend", name)
+		}
+	}
+
 	return nil
 }
 
-func (m *Monitor) collect(ctx context.Context) {
+// safeCollect calls fn, returning a *PanicError if it panics.
+func safeCollect(ctx context.Context, fn CollectFunc) (metrics []*Metric, err error) {
+	defer recoverPanic(&err)
+	return fn(ctx)
+}
+
+// errNoReloadSource is returned by a reload with nothing to reload from.
+var errNoReloadSource = errors.New("no config path or reload function")
+
+// errCollectTimeout is the cancellation cause of a collection that ran past
+// its timeout, as opposed to one interrupted by shutdown.
+var errCollectTimeout = errors.New("collection timed out")
+
+// schedule starts a background collection for c, applying the collector's
+// overlap policy if its previous collection is still running.
+func (m *Monitor) schedule(ctx context.Context, c *collector) {
+	timeout := c.timeoutFor(m.cfg)
+
+	c.mu.Lock()
+	if c.running {
+		switch c.overlapFor(m.cfg) {
+		case OverlapQueue:
+			if !c.queued {
+				c.queued = true
+				c.mu.Unlock()
+				m.logger.Debug("previous collection still running, queued", "collector", c.name)
+				return
+			}
+		case OverlapCancel:
+			c.cancel()
+			m.logger.Warn("previous collection still running, cancelling it", "collector", c.name)
+			c.running = false
+		}
+	}
+	if c.running {
+		c.mu.Unlock()
+		m.stats.recordSkipped()
+		c.stats.recordSkipped()
+		m.logger.Warn("previous collection still running, skipping poll", "collector", c.name)
+		return
+	}
+
+	runCtx, cancel := context.WithTimeoutCause(ctx, timeout, errCollectTimeout)
+	c.gen++
+	gen := c.gen
+	c.running = true
+	c.queued = false
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	m.inflight.Add(1)
+	go m.runCollection(ctx, c, runCtx, cancel, gen, timeout)
+}
+
+// runCollection performs a scheduled collection, then any collection queued
+// behind it, until the collector is idle or superseded.
+func (m *Monitor) runCollection(ctx context.Context, c *collector, runCtx context.Context, cancel context.CancelFunc, gen uint64, timeout time.Duration) {
+	defer m.inflight.Done()
+
+	for {
+		m.collect(runCtx, c, gen)
+		cancel()
+
+		c.mu.Lock()
+		if c.gen != gen {
+			// Superseded; the newer run owns the collector's state.
+			c.mu.Unlock()
+			return
+		}
+		if !c.queued || ctx.Err() != nil {
+			c.running = false
+			c.queued = false
+			c.mu.Unlock()
+			return
+		}
+		runCtx, cancel = context.WithTimeoutCause(ctx, timeout, errCollectTimeout)
+		c.gen++
+		gen = c.gen
+		c.queued = false
+		c.cancel = cancel
+		c.mu.Unlock()
+	}
+}
+
+// collectNow runs a collection synchronously with the collector's timeout.
+func (m *Monitor) collectNow(ctx context.Context, c *collector) {
+	ctx, cancel := context.WithTimeoutCause(ctx, c.timeoutFor(m.cfg), errCollectTimeout)
+	defer cancel()
+	m.collect(ctx, c, 0)
+}
+
+// waitInflight waits for background collections to finish or ctx to expire.
+func (m *Monitor) waitInflight(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		m.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// They were cancelled with the monitor's context; whatever they
+		// still publish reaches a stopped pipeline and is counted as dropped.
+		m.logger.Warn("timed out waiting for running collections, their metrics will be dropped")
+	}
+}
+
+// collect runs one collection, records its statistics and pushes the
+// result. Results of a run superseded under OverlapCancel are discarded.
+func (m *Monitor) collect(ctx context.Context, c *collector, gen uint64) {
 	start := time.Now()
 
-	metrics, err := m.collector(ctx)
+	metrics, err := safeCollect(ctx, c.fn)
 	duration := time.Since(start)
 
+	if c.superseded(gen) {
+		m.stats.recordSkipped()
+		c.stats.recordSkipped()
+		m.logger.Debug("discarding superseded collection", "collector", c.name)
+		return
+	}
+
+	if err == nil && context.Cause(ctx) == errCollectTimeout {
+		err = fmt.Errorf("collection timed out after %v", duration.Round(time.Millisecond))
+	}
+
+	if pe, ok := asPanic(err); ok {
+		m.stats.recordPanic()
+		c.stats.recordPanic()
+		m.logger.Error("collector panicked",
+			"collector", c.name,
+			"panic", pe.Value,
+			"stack", string(pe.Stack),
+		)
+	}
+
 	if err != nil {
 		m.stats.recordPoll(false, 0, duration)
-		m.logger.Error("collection failed", "error", err, "duration", duration)
+		c.stats.recordPoll(false, 0, duration)
+		m.stats.recordError(err)
+		c.stats.recordError(err)
+		m.logger.Error("collection failed",
+			"collector", c.name,
+			"error", err,
+			"duration", duration,
+		)
 		return
 	}
 
 	m.stats.recordPoll(true, len(metrics), duration)
+	c.stats.recordPoll(true, len(metrics), duration)
 
-	for _, metric := range metrics {
-		m.pipeline.Push(metric)
-	}
+	m.publish(metrics)
 
 	m.logger.Info("poll completed",
+		"collector", c.name,
 		"metrics", len(metrics),
 		"duration", duration,
 	)
 }
 
-func (m *Monitor) handleReload(ticker *time.Ticker) {
+func (m *Monitor) handleReload() error {
 	m.logger.Info("reloading configuration")
 
 	var newCfg *Config
@@ -190,25 +511,34 @@ func (m *Monitor) handleReload(ticker *time.Ticker) {
 		newCfg, err = LoadConfig(m.cfgPath)
 	} else {
 		m.logger.Warn("no config path or reload function, ignoring reload signal")
-		return
+		return errNoReloadSource
 	}
 
 	if err != nil {
 		m.logger.Error("config reload failed, keeping current config", "error", err)
-		return
+		return err
 	}
 
-	// Update poll interval if changed.
-	if newCfg.Global.PollInterval.Duration != m.cfg.Global.PollInterval.Duration {
-		ticker.Reset(newCfg.Global.PollInterval.Duration)
-		m.logger.Info("updated poll interval", "interval", newCfg.Global.PollInterval.Duration)
+	// Reschedule collectors whose poll interval changed.
+	now := time.Now()
+	for _, c := range m.collectors {
+		oldInterval, newInterval := c.intervalFor(m.cfg), c.intervalFor(newCfg)
+		if newInterval != oldInterval {
+			c.next = now.Add(newInterval)
+			m.logger.Info("updated poll interval", "collector", c.name, "interval", newInterval)
+		}
+		if c.enabled(newCfg) && !c.enabled(m.cfg) {
+			c.next = now
+		}
 	}
 
-	// Update log level if changed.
-	if m.levelVar != nil && newCfg.Global.LogLevel != m.cfg.Global.LogLevel {
+	// Update log level if changed. Compare against the live level so a
+	// reload also undoes a level set through the admin server.
+	if m.levelVar != nil && ParseLogLevel(newCfg.Global.LogLevel) != m.levelVar.Level() {
 		m.levelVar.Set(ParseLogLevel(newCfg.Global.LogLevel))
 		m.logger.Info("updated log level", "level", newCfg.Global.LogLevel)
 	}
 
 	m.cfg = newCfg
+	return nil
 }
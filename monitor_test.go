@@ -4,7 +4,10 @@
 	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -226,3 +229,334 @@ func TestMonitorWithLogger(t *testing.T) {
 		t.Error("Logger should be the one provided via WithLogger")
 	}
 }
+
+func TestMonitorMultipleCollectors(t *testing.T) {
+	var mu sync.Mutex
+	counts := map[string]int{}
+	counter := func(name string) CollectFunc {
+		return func(ctx context.Context) ([]*Metric, error) {
+			mu.Lock()
+			counts[name]++
+			mu.Unlock()
+			return []*Metric{NewMetric(name).WithField("value", 1)}, nil
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Global.PollInterval = Duration{1 * time.Hour}
+	cfg.Collectors = map[string]CollectorConfig{
+		"slow": {PollInterval: Duration{1 * time.Hour}},
+	}
+
+	logger, _ := NewLogger("error")
+	m, err := New("test", nil,
+		WithConfig(cfg),
+		WithLogger(logger),
+		WithCollector("fast", counter("fast"), 50*time.Millisecond),
+		// The config override wins over the interval given here.
+		WithCollector("slow", counter("slow"), 10*time.Millisecond),
+		WithBackend(&mockBackend{name: "test", healthy: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 230*time.Millisecond)
+	defer cancel()
+	m.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// fast: initial + at least 3 ticks + final; slow: initial + final.
+	if counts["fast"] < 4 {
+		t.Errorf("fast collector ran %d times, want at least 4", counts["fast"])
+	}
+	if counts["slow"] != 2 {
+		t.Errorf("slow collector ran %d times, want 2 (initial and final)", counts["slow"])
+	}
+
+	stats := m.CollectorStats()
+	if stats["slow"].TotalPolls != 2 {
+		t.Errorf("CollectorStats()[slow].TotalPolls = %d, want 2", stats["slow"].TotalPolls)
+	}
+	if total := m.Stats().TotalPolls; total != int64(counts["fast"]+counts["slow"]) {
+		t.Errorf("Stats().TotalPolls = %d, want %d", total, counts["fast"]+counts["slow"])
+	}
+}
+
+func TestMonitorCollectorDisabled(t *testing.T) {
+	ran := false
+	cfg := DefaultConfig()
+	cfg.Collectors = map[string]CollectorConfig{"off": {Disabled: true}}
+
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) {
+		return []*Metric{NewMetric("on").WithField("value", 1)}, nil
+	},
+		WithConfig(cfg),
+		WithRunOnce(true),
+		WithCollector("off", func(ctx context.Context) ([]*Metric, error) {
+			ran = true
+			return nil, nil
+		}, 0),
+		WithBackend(&mockBackend{name: "test", healthy: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if ran {
+		t.Error("Disabled collector should not run")
+	}
+	if stats := m.CollectorStats(); stats[DefaultCollectorName].TotalPolls != 1 {
+		t.Errorf("default collector TotalPolls = %d, want 1", stats[DefaultCollectorName].TotalPolls)
+	}
+}
+
+func TestMonitorCollectorErrors(t *testing.T) {
+	fn := func(ctx context.Context) ([]*Metric, error) { return nil, nil }
+
+	if _, err := New("test", nil); err == nil {
+		t.Error("New() should error without any collectors")
+	}
+
+	if _, err := New("test", fn, WithCollector(DefaultCollectorName, fn, 0)); err == nil {
+		t.Error("New() should error on duplicate collector names")
+	}
+}
+
+func TestNextDue(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"on time", base.Add(5 * time.Second), base.Add(10 * time.Second)},
+		{"missed one slot", base.Add(12 * time.Second), base.Add(20 * time.Second)},
+		{"missed several slots", base.Add(35 * time.Second), base.Add(40 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDue(base, 10*time.Second, tt.now); !got.Equal(tt.want) {
+				t.Errorf("nextDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitorCollectTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Global.CollectTimeout = Duration{20 * time.Millisecond}
+
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) {
+		// Ignores cancellation until well past the deadline.
+		<-ctx.Done()
+		return []*Metric{NewMetric("late").WithField("value", 1)}, nil
+	},
+		WithConfig(cfg),
+		WithRunOnce(true),
+		WithBackend(&mockBackend{name: "test", healthy: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	stats := m.Stats()
+	if stats.FailedPolls != 1 || stats.TotalMetrics != 0 {
+		t.Errorf("Stats() = %+v, want one failed poll and no metrics", stats)
+	}
+}
+
+// overlapMonitor builds a monitor whose "slow" collector blocks until release
+// is closed, polled every 20ms under the given overlap policy.
+func overlapMonitor(t *testing.T, policy OverlapPolicy, calls *atomic.Int32, release chan struct{}) *Monitor {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Global.PollInterval = Duration{1 * time.Hour}
+	cfg.Collectors = map[string]CollectorConfig{
+		"slow": {CollectTimeout: Duration{5 * time.Second}, OverlapPolicy: policy},
+	}
+
+	logger, _ := NewLogger("error")
+	m, err := New("test", nil,
+		WithConfig(cfg),
+		WithLogger(logger),
+		WithCollector("slow", func(ctx context.Context) ([]*Metric, error) {
+			if calls.Add(1) == 1 {
+				select {
+				case <-release:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+			return []*Metric{NewMetric("slow").WithField("value", 1)}, nil
+		}, 20*time.Millisecond),
+		WithBackend(&mockBackend{name: "test", healthy: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return m
+}
+
+func TestMonitorOverlapSkip(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	m := overlapMonitor(t, OverlapSkip, &calls, release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, func() { close(release) })
+	m.Run(ctx)
+
+	stats := m.CollectorStats()["slow"]
+	if stats.SkippedPolls == 0 {
+		t.Errorf("SkippedPolls = 0, want polls skipped while the collector was busy")
+	}
+	// Polls due while blocked never ran: only the first, any after release,
+	// and the final collection.
+	if got := int64(calls.Load()); got != stats.TotalPolls {
+		t.Errorf("collector ran %d times, TotalPolls = %d", got, stats.TotalPolls)
+	}
+}
+
+func TestMonitorOverlapQueue(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	m := overlapMonitor(t, OverlapQueue, &calls, release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, func() { close(release) })
+
+	// While the first run is blocked, further polls collapse into a single
+	// queued run and the rest are skipped.
+	time.AfterFunc(90*time.Millisecond, func() {
+		if got := calls.Load(); got != 1 {
+			t.Errorf("collector ran %d times while blocked, want 1", got)
+		}
+	})
+	m.Run(ctx)
+
+	stats := m.CollectorStats()["slow"]
+	if stats.SkippedPolls == 0 {
+		t.Error("SkippedPolls = 0, want overlapping polls beyond the queued one skipped")
+	}
+	if calls.Load() < 3 {
+		t.Errorf("collector ran %d times, want at least 3 (blocked, queued, final)", calls.Load())
+	}
+}
+
+func TestMonitorOverlapCancel(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	defer close(release)
+	m := overlapMonitor(t, OverlapCancel, &calls, release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	m.Run(ctx)
+
+	stats := m.CollectorStats()["slow"]
+	// The blocked first run was cancelled and its result discarded as
+	// skipped; every later run completed.
+	if stats.SkippedPolls != 1 {
+		t.Errorf("SkippedPolls = %d, want 1", stats.SkippedPolls)
+	}
+	if stats.FailedPolls != 0 {
+		t.Errorf("FailedPolls = %d, want 0", stats.FailedPolls)
+	}
+	if stats.SuccessfulPolls < 2 {
+		t.Errorf("SuccessfulPolls = %d, want at least 2", stats.SuccessfulPolls)
+	}
+}
+
+func TestMonitorLoopNotBlockedByCollector(t *testing.T) {
+	var fast atomic.Int32
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Global.PollInterval = Duration{1 * time.Hour}
+	cfg.Global.CollectTimeout = Duration{100 * time.Millisecond}
+
+	block := make(chan struct{})
+	defer close(block)
+
+	m, err := New("test", nil,
+		WithConfig(cfg),
+		WithCollector("hung", func(ctx context.Context) ([]*Metric, error) {
+			select {
+			case <-block:
+			case <-ctx.Done():
+			}
+			return nil, ctx.Err()
+		}, 0),
+		WithCollector("fast", func(ctx context.Context) ([]*Metric, error) {
+			fast.Add(1)
+			return nil, nil
+		}, 20*time.Millisecond),
+		WithBackend(&mockBackend{name: "test", healthy: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	m.Run(ctx)
+
+	if fast.Load() < 4 {
+		t.Errorf("fast collector ran %d times while another was hung, want at least 4", fast.Load())
+	}
+}
+
+func TestMonitorCollectorPanicRecovered(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) {
+		var tags map[string]string
+		tags["host"] = "a"
+		return nil, nil
+	},
+		WithRunOnce(true),
+		WithLogger(logger),
+		WithCollector("ok", func(ctx context.Context) ([]*Metric, error) {
+			return []*Metric{NewMetric("ok").WithField("value", 1)}, nil
+		}, 0),
+		WithBackend(&mockBackend{name: "test", healthy: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	stats := m.CollectorStats()
+	if got := stats[DefaultCollectorName]; got.FailedPolls != 1 || got.PanickedPolls != 1 {
+		t.Errorf("default collector stats = %+v, want one failed, panicked poll", got)
+	}
+	if got := stats["ok"]; got.SuccessfulPolls != 1 {
+		t.Errorf("ok collector SuccessfulPolls = %d, want 1", got.SuccessfulPolls)
+	}
+	if m.Stats().PanickedPolls != 1 {
+		t.Errorf("Stats().PanickedPolls = %d, want 1", m.Stats().PanickedPolls)
+	}
+	if !strings.Contains(buf.String(), "collector panicked") || !strings.Contains(buf.String(), "goroutine") {
+		t.Errorf("panic should be logged with a stack trace, got:\n%s", buf.String())
+	}
+}
@@ -1,6 +1,9 @@
 package monitor
 
-import "log/slog"
+import (
+	"log/slog"
+	"time"
+)
 
 // Option configures a Monitor.
 type Option func(*Monitor)
@@ -47,6 +50,36 @@ func WithBackend(b Backend) Option {
 	}
 }
 
+// WithCollector registers an additional named collector. Each collector is
+// polled on its own schedule: interval, unless overridden by a
+// [collectors.<name>] poll_interval setting; zero means global.poll_interval.
+func WithCollector(name string, fn CollectFunc, interval time.Duration) Option {
+	return func(m *Monitor) {
+		m.collectors = append(m.collectors, &collector{
+			name:     name,
+			fn:       fn,
+			interval: interval,
+		})
+	}
+}
+
+// WithInput adds a push-style input. Inputs are started after the pipeline
+// and stopped before it, so everything they push is delivered.
+func WithInput(in Input) Option {
+	return func(m *Monitor) {
+		m.inputs = append(m.inputs, in)
+	}
+}
+
+// WithProcessor adds a processor applied to every collected or emitted
+// metric. Processors run in the order added, ahead of those configured
+// under [processors].
+func WithProcessor(p Processor) Option {
+	return func(m *Monitor) {
+		m.processors = append(m.processors, p)
+	}
+}
+
 // WithReloadFunc provides a custom config reload function.
 // The function receives the config file path and returns a new Config.
 func WithReloadFunc(fn func(path string) (*Config, error)) Option {
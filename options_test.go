@@ -1,7 +1,9 @@
 package monitor
 
 import (
+	"context"
 	"testing"
+	"time"
 )
 
 func TestOptions(t *testing.T) {
@@ -40,6 +42,11 @@ func TestOptions(t *testing.T) {
 		t.Errorf("backends count = %d, want 1", len(m.backends))
 	}
 
+	WithCollector("inventory", func(ctx context.Context) ([]*Metric, error) { return nil, nil }, 5*time.Minute)(m)
+	if len(m.collectors) != 1 || m.collectors[0].name != "inventory" || m.collectors[0].interval != 5*time.Minute {
+		t.Errorf("collectors = %+v, want one inventory collector every 5m", m.collectors)
+	}
+
 	reloadFn := func(path string) (*Config, error) { return nil, nil }
 	WithReloadFunc(reloadFn)(m)
 	if m.reloadFn == nil {
@@ -2,44 +2,130 @@
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 )
 
+// OverflowPolicy selects what Push does when the buffer is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest evicts the oldest buffered metric to make room.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowDropNewest discards the metric being pushed.
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+	// OverflowBlock waits up to BlockTimeout for room, then discards the
+	// metric being pushed. Room is made when buffered batches are handed to
+	// the backend queues, not when they are delivered, so a slow backend
+	// does not hold up Push: its full queue spools or drops batches instead.
+	// Once the pipeline is started the flush goroutine drains a full buffer
+	// right away, so Push mostly blocks before Start.
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// Valid reports whether the policy is one of the known policies.
+func (o OverflowPolicy) Valid() bool {
+	switch o {
+	case OverflowDropOldest, OverflowDropNewest, OverflowBlock:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrBufferFull is returned for a metric discarded because the pipeline's
+// buffer was full, under OverflowDropNewest or OverflowBlock.
+var ErrBufferFull = errors.New("pipeline buffer full")
+
+// errPipelineStopped is returned for a metric pushed once Stop has begun.
+var errPipelineStopped = errors.New("pipeline stopped")
+
 // PipelineConfig configures the metric pipeline.
 type PipelineConfig struct {
-	BatchSize     int
-	FlushInterval time.Duration
-	RetryAttempts int
-	RetryDelay    time.Duration
-	Logger        *slog.Logger
+	BatchSize        int
+	FlushInterval    time.Duration
+	RetryAttempts    int
+	RetryDelay       time.Duration
+	RetryMaxDelay    time.Duration
+	QueueSize        int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	MaxBufferSize    int
+	OverflowPolicy   OverflowPolicy
+	BlockTimeout     time.Duration
+	TripOnPanic      bool
+	Spool            SpoolConfig
+	Logger           *slog.Logger
 }
 
 // DefaultPipelineConfig returns sensible pipeline defaults.
 func DefaultPipelineConfig() PipelineConfig {
 	return PipelineConfig{
-		BatchSize:     10,
-		FlushInterval: 10 * time.Second,
-		RetryAttempts: 3,
-		RetryDelay:    1 * time.Second,
-		Logger:        slog.Default(),
+		BatchSize:        10,
+		FlushInterval:    10 * time.Second,
+		RetryAttempts:    3,
+		RetryDelay:       1 * time.Second,
+		RetryMaxDelay:    30 * time.Second,
+		QueueSize:        100,
+		BreakerThreshold: 3,
+		BreakerCooldown:  30 * time.Second,
+		MaxBufferSize:    10000,
+		OverflowPolicy:   OverflowDropOldest,
+		BlockTimeout:     1 * time.Second,
+		Logger:           slog.Default(),
 	}
 }
 
-// Pipeline manages metric batching and delivery to backends.
+// PipelineStats holds buffer statistics for a pipeline.
+type PipelineStats struct {
+	BufferLen      int
+	PushedMetrics  int64
+	InvalidMetrics int64
+	DroppedMetrics int64
+}
+
+// Pipeline manages metric batching and delivery to backends. Each backend
+// has its own bounded queue and worker, so backends are delivered to
+// independently of one another.
 type Pipeline struct {
-	backends      []Backend
+	queues        []*backendQueue
 	batchSize     int
 	flushInterval time.Duration
 	retryAttempts int
 	retryDelay    time.Duration
+	retryMaxDelay time.Duration
+	queueSize     int
+	breakerLimit  int
+	breakerWait   time.Duration
+	maxBuffer     int
+	overflow      OverflowPolicy
+	blockTimeout  time.Duration
+	tripOnPanic   bool
+	spoolCfg      SpoolConfig
+
+	mu      sync.Mutex
+	closed  bool // set by Stop; later pushes are dropped
+	buffer  []*Metric
+	space   chan struct{} // closed and replaced whenever the buffer drains
+	flushCh chan struct{}
+	stats   PipelineStats
+	done    chan struct{}
+	wg      sync.WaitGroup
+	logger  *slog.Logger
 
-	mu     sync.Mutex
-	buffer []*Metric
-	done   chan struct{}
-	wg     sync.WaitGroup
-	logger *slog.Logger
+	// stateMu guards the worker lifecycle; queues may only be sent to while
+	// it is held for reading.
+	stateMu sync.RWMutex
+	started bool
+	workers sync.WaitGroup
+	stopCtx context.Context
+	stopFn  context.CancelFunc
 }
 
 // NewPipeline creates a new metric pipeline.
@@ -56,55 +142,136 @@ func NewPipeline(cfg PipelineConfig) *Pipeline {
 	if cfg.RetryDelay <= 0 {
 		cfg.RetryDelay = 1 * time.Second
 	}
+	if cfg.RetryMaxDelay <= 0 {
+		cfg.RetryMaxDelay = 30 * time.Second
+	}
+	if cfg.RetryMaxDelay < cfg.RetryDelay {
+		cfg.RetryMaxDelay = cfg.RetryDelay
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = 3
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+	if cfg.MaxBufferSize <= 0 {
+		cfg.MaxBufferSize = 10000
+	}
+	if cfg.MaxBufferSize < cfg.BatchSize {
+		cfg.MaxBufferSize = cfg.BatchSize
+	}
+	if !cfg.OverflowPolicy.Valid() {
+		cfg.OverflowPolicy = OverflowDropOldest
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = 1 * time.Second
+	}
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
 
+	stopCtx, stopFn := context.WithCancel(context.Background())
+
 	return &Pipeline{
-		backends:      make([]Backend, 0),
+		queues:        make([]*backendQueue, 0),
 		batchSize:     cfg.BatchSize,
 		flushInterval: cfg.FlushInterval,
 		retryAttempts: cfg.RetryAttempts,
 		retryDelay:    cfg.RetryDelay,
+		retryMaxDelay: cfg.RetryMaxDelay,
+		queueSize:     cfg.QueueSize,
+		breakerLimit:  cfg.BreakerThreshold,
+		breakerWait:   cfg.BreakerCooldown,
+		maxBuffer:     cfg.MaxBufferSize,
+		overflow:      cfg.OverflowPolicy,
+		blockTimeout:  cfg.BlockTimeout,
+		tripOnPanic:   cfg.TripOnPanic,
+		spoolCfg:      cfg.Spool,
 		buffer:        make([]*Metric, 0, cfg.BatchSize),
+		space:         make(chan struct{}),
+		flushCh:       make(chan struct{}, 1),
 		done:          make(chan struct{}),
+		stopCtx:       stopCtx,
+		stopFn:        stopFn,
 		logger:        cfg.Logger,
 	}
 }
 
 // AddBackend adds a backend to the pipeline.
 func (p *Pipeline) AddBackend(b Backend) {
-	p.backends = append(p.backends, b)
+	breaker := newCircuitBreaker(p.breakerLimit, p.breakerWait)
+	q := newBackendQueue(b, p.queueSize, breaker, p.logger)
+	q.tripOnPanic = p.tripOnPanic
+	p.queues = append(p.queues, q)
 }
 
-// Start begins the background flush goroutine.
+// Start initializes backends and begins the backend workers and the
+// background flush goroutine.
 func (p *Pipeline) Start(ctx context.Context) error {
-	for _, b := range p.backends {
-		if err := b.Initialize(ctx); err != nil {
+	if err := p.openSpools(); err != nil {
+		return err
+	}
+
+	for _, q := range p.queues {
+		if err := q.backend.Initialize(ctx); err != nil {
 			return err
 		}
-		p.logger.Info("backend initialized", "backend", b.Name())
+		p.logger.Info("backend initialized", "backend", q.backend.Name())
 	}
 
+	for _, q := range p.queues {
+		p.workers.Add(1)
+		go func(q *backendQueue) {
+			defer p.workers.Done()
+			q.run(p.stopCtx, p)
+		}(q)
+	}
+
+	p.stateMu.Lock()
+	p.started = true
+	p.stateMu.Unlock()
+
 	p.wg.Add(1)
 	go p.flushLoop(ctx)
 
 	return nil
 }
 
-// Stop shuts down the pipeline, flushing remaining metrics.
+// Stop shuts down the pipeline, flushing remaining metrics. Deliveries still
+// in flight when ctx expires are cancelled and spooled when possible.
+// Metrics pushed once Stop has begun are counted as dropped.
 func (p *Pipeline) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
 	close(p.done)
 	p.wg.Wait()
 
 	if err := p.Flush(ctx); err != nil {
 		p.logger.Error("final flush failed", "error", err)
+		p.spoolBuffered()
+	}
+
+	p.stateMu.Lock()
+	started := p.started
+	p.started = false
+	if started {
+		p.stopFn()
+		for _, q := range p.queues {
+			close(q.ch)
+		}
 	}
+	p.stateMu.Unlock()
+	p.workers.Wait()
 
 	var lastErr error
-	for _, b := range p.backends {
-		if err := b.Close(); err != nil {
-			p.logger.Error("backend close failed", "backend", b.Name(), "error", err)
+	for _, q := range p.queues {
+		if err := q.backend.Close(); err != nil {
+			p.logger.Error("backend close failed", "backend", q.backend.Name(), "error", err)
 			lastErr = err
 		}
 	}
@@ -112,83 +279,333 @@ func (p *Pipeline) Stop(ctx context.Context) error {
 	return lastErr
 }
 
-// Push adds a metric to the pipeline.
+// Push adds a metric to the pipeline. Once a full batch is buffered the
+// flush goroutine is woken to hand it to the backend queues; Push itself
+// never waits on delivery. When the buffer is at MaxBufferSize the overflow
+// policy decides which metric is discarded.
 func (p *Pipeline) Push(m *Metric) {
+	p.push(m)
+}
+
+// push is Push, reporting why the metric was dropped: its validation error,
+// ErrBufferFull or errPipelineStopped. A metric accepted by evicting an
+// older one under OverflowDropOldest is not an error.
+func (p *Pipeline) push(m *Metric) error {
 	if err := m.Validate(); err != nil {
+		p.mu.Lock()
+		p.stats.InvalidMetrics++
+		p.mu.Unlock()
 		p.logger.Warn("invalid metric dropped", "error", err)
-		return
+		return err
 	}
 
 	p.mu.Lock()
+	var deadline <-chan time.Time
+	for !p.closed && len(p.buffer) >= p.maxBuffer {
+		switch p.overflow {
+		case OverflowDropNewest:
+			p.stats.DroppedMetrics++
+			p.mu.Unlock()
+			p.logger.Warn("buffer full, dropped newest metric", "measurement", m.Measurement)
+			return ErrBufferFull
+
+		case OverflowDropOldest:
+			// Re-slicing keeps eviction O(1); append reallocates once the
+			// capacity left behind the evicted metrics runs out.
+			oldest := p.buffer[0]
+			p.buffer[0] = nil
+			p.buffer = p.buffer[1:]
+			p.stats.DroppedMetrics++
+			p.logger.Warn("buffer full, dropped oldest metric", "measurement", oldest.Measurement)
+
+		case OverflowBlock:
+			if deadline == nil {
+				timer := time.NewTimer(p.blockTimeout)
+				defer timer.Stop()
+				deadline = timer.C
+			}
+			space := p.space
+			p.mu.Unlock()
+			select {
+			case <-space:
+			case <-deadline:
+				p.mu.Lock()
+				p.stats.DroppedMetrics++
+				p.mu.Unlock()
+				p.logger.Warn("buffer full, timed out waiting for room", "measurement", m.Measurement)
+				return ErrBufferFull
+			}
+			p.mu.Lock()
+		}
+	}
+
+	if p.closed {
+		p.stats.DroppedMetrics++
+		p.mu.Unlock()
+		p.logger.Warn("pipeline stopped, dropped metric", "measurement", m.Measurement)
+		return errPipelineStopped
+	}
 	p.buffer = append(p.buffer, m)
-	shouldFlush := len(p.buffer) >= p.batchSize
+	p.stats.PushedMetrics++
+	full := len(p.buffer) >= p.batchSize
 	p.mu.Unlock()
 
-	if shouldFlush {
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-			if err := p.Flush(ctx); err != nil {
-				p.logger.Error("batch flush failed", "error", err)
-			}
-		}()
+	if full {
+		select {
+		case p.flushCh <- struct{}{}:
+		default:
+		}
 	}
+	return nil
 }
 
-// Flush sends all buffered metrics to backends.
+// Flush sends all buffered metrics to backends and waits for every backend
+// to finish with them or for ctx to expire. Batches it had no room to hand
+// out before ctx expired stay buffered for the next flush. Spooled batches
+// are replayed ahead of the buffered metrics so delivery order is preserved.
 func (p *Pipeline) Flush(ctx context.Context) error {
 	p.mu.Lock()
-	if len(p.buffer) == 0 {
-		p.mu.Unlock()
-		return nil
-	}
-	batch := p.buffer
-	p.buffer = make([]*Metric, 0, p.batchSize)
+	batches := p.takeBatchesLocked(true)
 	p.mu.Unlock()
 
-	p.logger.Debug("flushing metrics", "count", len(batch))
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
 
+	if len(batches) > 0 {
+		p.logger.Debug("flushing metrics", "batches", len(batches))
+	} else {
+		// An empty batch still gives queues a chance to replay their backlog.
+		batches = [][]*Metric{nil}
+	}
+
+	var pending []chan error
 	var lastErr error
-	for _, b := range p.backends {
-		if !b.Healthy() {
-			p.logger.Warn("skipping unhealthy backend", "backend", b.Name())
-			continue
+	handed := make([]int, len(p.queues)) // batches each queue has accepted
+	for _, batch := range batches {
+		for i, q := range p.queues {
+			if len(batch) == 0 && !q.hasBacklog() {
+				handed[i]++
+				continue
+			}
+
+			if !p.started {
+				if err := q.deliver(ctx, p, batch); err != nil {
+					p.logger.Error("backend write failed", "backend", q.backend.Name(), "error", err)
+					lastErr = err
+				}
+				continue
+			}
+
+			done := make(chan error, 1)
+			select {
+			case q.ch <- delivery{ctx: ctx, batch: batch, done: done}:
+				handed[i]++
+				pending = append(pending, done)
+			case <-ctx.Done():
+				p.requeue(batches, handed)
+				return ctx.Err()
+			}
 		}
+	}
 
-		if err := p.writeWithRetry(ctx, b, batch); err != nil {
-			p.logger.Error("backend write failed", "backend", b.Name(), "error", err)
-			lastErr = err
+	for _, done := range pending {
+		select {
+		case err := <-done:
+			if err != nil {
+				lastErr = err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 
 	return lastErr
 }
 
-func (p *Pipeline) writeWithRetry(ctx context.Context, b Backend, metrics []*Metric) error {
+// requeue keeps the batches a Flush could not hand out before its context
+// expired, handed[i] being how many of them queue i accepted. A batch some
+// queues accepted is offered only to the others, which spool or drop it if
+// they are full; batches no queue accepted go back to the front of the
+// buffer. Either way no queue is given a batch twice. The caller must hold
+// p.stateMu for reading.
+func (p *Pipeline) requeue(batches [][]*Metric, handed []int) {
+	accepted := slices.Max(handed)
+	for i, q := range p.queues {
+		for _, batch := range batches[handed[i]:accepted] {
+			if len(batch) > 0 {
+				q.offer(batch)
+			}
+		}
+	}
+
+	var rest []*Metric
+	for _, batch := range batches[accepted:] {
+		rest = append(rest, batch...)
+	}
+	if len(rest) == 0 {
+		return
+	}
+	p.mu.Lock()
+	p.buffer = append(rest, p.buffer...)
+	p.mu.Unlock()
+}
+
+// spoolBuffered hands whatever is still buffered to each backend's spool,
+// counting it as dropped for backends without one. Stop calls it when the
+// final flush could not deliver everything.
+func (p *Pipeline) spoolBuffered() {
+	p.mu.Lock()
+	batches := p.takeBatchesLocked(true)
+	p.mu.Unlock()
+
+	for _, batch := range batches {
+		for _, q := range p.queues {
+			if !q.spoolBatch(batch) {
+				q.logger.Warn("pipeline stopped, dropping batch", "backend", q.backend.Name(), "count", len(batch))
+				q.recordDropped()
+			}
+		}
+	}
+}
+
+// dispatch hands a batch to every backend queue without blocking. Queues
+// holding a spooled backlog are nudged even when the batch is empty so they
+// get a chance to replay it.
+func (p *Pipeline) dispatch(batch []*Metric) {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+
+	if !p.started {
+		return
+	}
+
+	for _, q := range p.queues {
+		if len(batch) == 0 && !q.hasBacklog() {
+			continue
+		}
+		q.offer(batch)
+	}
+}
+
+// dispatchBuffered hands buffered batches to the backend queues. On periodic
+// flushes the trailing partial batch goes too, and queues with a spooled
+// backlog are nudged even if nothing is buffered.
+func (p *Pipeline) dispatchBuffered(partial bool) {
+	p.mu.Lock()
+	batches := p.takeBatchesLocked(partial)
+	p.mu.Unlock()
+
+	if partial && len(batches) == 0 {
+		p.dispatch(nil)
+		return
+	}
+	for _, batch := range batches {
+		p.dispatch(batch)
+	}
+}
+
+// takeBatchesLocked removes buffered metrics in batchSize chunks. A trailing
+// partial batch is only taken when partial is true. Blocked pushers are woken
+// if any room was made. The caller must hold p.mu.
+func (p *Pipeline) takeBatchesLocked(partial bool) [][]*Metric {
+	var batches [][]*Metric
+	for len(p.buffer) >= p.batchSize || (partial && len(p.buffer) > 0) {
+		n := min(p.batchSize, len(p.buffer))
+		batch := make([]*Metric, n)
+		copy(batch, p.buffer[:n])
+		batches = append(batches, batch)
+
+		rest := copy(p.buffer, p.buffer[n:])
+		clear(p.buffer[rest:])
+		p.buffer = p.buffer[:rest]
+	}
+
+	if len(batches) > 0 {
+		close(p.space)
+		p.space = make(chan struct{})
+	}
+	return batches
+}
+
+// openSpools opens one spool per backend when spooling is enabled. Each
+// backend gets its own subdirectory, named after the backend.
+func (p *Pipeline) openSpools() error {
+	if !p.spoolCfg.Enabled {
+		return nil
+	}
+
+	used := make(map[string]int)
+	for _, q := range p.queues {
+		name := q.backend.Name()
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, used[name])
+		}
+
+		sp, err := openSpool(
+			filepath.Join(p.spoolCfg.Dir, name),
+			p.spoolCfg.MaxSize,
+			p.spoolCfg.MaxAge.Duration,
+			p.logger,
+		)
+		if err != nil {
+			return fmt.Errorf("backend %s: %w", q.backend.Name(), err)
+		}
+		if n := sp.Len(); n > 0 {
+			p.logger.Info("found spooled batches", "backend", q.backend.Name(), "batches", n)
+		}
+		q.spool = sp
+	}
+	return nil
+}
+
+// writeWithRetry writes a batch to a queue's backend, retrying retryable
+// failures with exponential backoff and full jitter.
+func (p *Pipeline) writeWithRetry(ctx context.Context, q *backendQueue, metrics []*Metric) error {
+	b := q.backend
+
 	var lastErr error
 	for attempt := 1; attempt <= p.retryAttempts; attempt++ {
-		err := b.Write(ctx, metrics)
+		err := safeWrite(ctx, b, metrics)
 		if err == nil {
 			return nil
 		}
 
 		lastErr = err
+		if !IsRetryable(err) {
+			return err
+		}
 		if attempt < p.retryAttempts {
+			delay := p.backoff(attempt)
 			p.logger.Warn("write failed, retrying",
 				"backend", b.Name(),
 				"attempt", attempt,
+				"delay", delay,
 				"error", err,
 			)
+			q.recordRetry()
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(p.retryDelay):
+			case <-time.After(delay):
 			}
 		}
 	}
 	return lastErr
 }
 
+// backoff returns the delay before retrying after the given failed attempt:
+// a uniformly random duration between zero and retryDelay doubled once per
+// previous attempt, capped at retryMaxDelay.
+func (p *Pipeline) backoff(attempt int) time.Duration {
+	ceiling := p.retryDelay
+	for i := 1; i < attempt && ceiling < p.retryMaxDelay; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, p.retryMaxDelay)
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
 func (p *Pipeline) flushLoop(ctx context.Context) {
 	defer p.wg.Done()
 	ticker := time.NewTicker(p.flushInterval)
@@ -200,10 +617,10 @@ func (p *Pipeline) flushLoop(ctx context.Context) {
 			return
 		case <-ctx.Done():
 			return
+		case <-p.flushCh:
+			p.dispatchBuffered(false)
 		case <-ticker.C:
-			if err := p.Flush(ctx); err != nil {
-				p.logger.Error("periodic flush failed", "error", err)
-			}
+			p.dispatchBuffered(true)
 		}
 	}
 }
@@ -215,7 +632,37 @@ func (p *Pipeline) BufferLen() int {
 	return len(p.buffer)
 }
 
+// Stats returns a snapshot of buffer statistics.
+func (p *Pipeline) Stats() PipelineStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.BufferLen = len(p.buffer)
+	return stats
+}
+
+// SpoolLen returns the total number of spooled batches across all backends.
+func (p *Pipeline) SpoolLen() int {
+	total := 0
+	for _, q := range p.queues {
+		if q.spool != nil {
+			total += q.spool.Len()
+		}
+	}
+	return total
+}
+
 // BackendCount returns the number of configured backends.
 func (p *Pipeline) BackendCount() int {
-	return len(p.backends)
+	return len(p.queues)
+}
+
+// BackendStats returns a snapshot of delivery statistics for each backend,
+// in the order the backends were added.
+func (p *Pipeline) BackendStats() []BackendStats {
+	stats := make([]BackendStats, len(p.queues))
+	for i, q := range p.queues {
+		stats[i] = q.snapshot()
+	}
+	return stats
 }
@@ -2,7 +2,10 @@
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -175,6 +178,9 @@ func TestPipelineDefaults(t *testing.T) {
 	if p.retryDelay != 1*time.Second {
 		t.Errorf("retryDelay = %v, want 1s", p.retryDelay)
 	}
+	if p.retryMaxDelay != 30*time.Second {
+		t.Errorf("retryMaxDelay = %v, want 30s", p.retryMaxDelay)
+	}
 }
 
 // retryBackend allows custom write behavior for testing.
@@ -189,3 +195,918 @@ func (b *retryBackend) Initialize(ctx context.Context) error            { return
 func (b *retryBackend) Write(ctx context.Context, m []*Metric) error    { return b.writeFn(ctx, m) }
 func (b *retryBackend) Close() error                                    { return nil }
 func (b *retryBackend) Healthy() bool                                   { return b.healthy }
+
+func TestPipelineSpoolsFailedBatches(t *testing.T) {
+	var mu sync.Mutex
+	failing := true
+	var delivered []string
+	backend := &retryBackend{
+		name:    "test",
+		healthy: true,
+		writeFn: func(ctx context.Context, metrics []*Metric) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if failing {
+				return fmt.Errorf("backend down")
+			}
+			for _, m := range metrics {
+				delivered = append(delivered, m.Measurement)
+			}
+			return nil
+		},
+	}
+
+	cfg := PipelineConfig{
+		BatchSize:     100,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 1,
+		RetryDelay:    1 * time.Millisecond,
+		Spool:         SpoolConfig{Enabled: true, Dir: t.TempDir()},
+	}
+	p := NewPipeline(cfg)
+	p.AddBackend(backend)
+
+	ctx := context.Background()
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	p.Push(NewMetric("first").WithField("v", 1))
+	if err := p.Flush(ctx); err == nil {
+		t.Error("Flush() should report the write failure")
+	}
+	p.Push(NewMetric("second").WithField("v", 2))
+	p.Flush(ctx)
+
+	if p.SpoolLen() != 2 {
+		t.Fatalf("SpoolLen() = %d, want 2", p.SpoolLen())
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+
+	p.Push(NewMetric("third").WithField("v", 3))
+	if err := p.Flush(ctx); err != nil {
+		t.Fatalf("Flush() after recovery error: %v", err)
+	}
+
+	if p.SpoolLen() != 0 {
+		t.Errorf("SpoolLen() = %d, want 0 after replay", p.SpoolLen())
+	}
+	mu.Lock()
+	got := fmt.Sprint(delivered)
+	mu.Unlock()
+	if got != "[first second third]" {
+		t.Errorf("delivered = %s, want [first second third]", got)
+	}
+
+	p.Stop(ctx)
+}
+
+func TestPipelineSpoolReplayInterrupted(t *testing.T) {
+	var mu sync.Mutex
+	delivered := 0
+	backend := &retryBackend{
+		name:    "slow",
+		healthy: true,
+		writeFn: func(ctx context.Context, metrics []*Metric) error {
+			select {
+			case <-time.After(20 * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			mu.Lock()
+			delivered++
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	p := NewPipeline(PipelineConfig{
+		BatchSize:        100,
+		FlushInterval:    1 * time.Hour,
+		RetryAttempts:    1,
+		BreakerThreshold: 1,
+		BreakerCooldown:  1 * time.Hour,
+		Spool:            SpoolConfig{Enabled: true, Dir: t.TempDir()},
+	})
+	p.AddBackend(backend)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		p.queues[0].spool.Append([]*Metric{NewMetric("backlog").WithField("v", i)})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Flush(ctx); err == nil {
+		t.Fatal("Flush() should report the interrupted replay")
+	}
+	stats := p.BackendStats()[0]
+	if stats.State != BreakerClosed || stats.FailedBatch != 0 {
+		t.Errorf("State = %v, FailedBatch = %d; an interrupted replay is not a backend failure", stats.State, stats.FailedBatch)
+	}
+	if n := p.SpoolLen(); n == 0 || n == 10 {
+		t.Errorf("SpoolLen() = %d, want part of the backlog replayed", n)
+	}
+
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 10 || p.SpoolLen() != 0 {
+		t.Errorf("delivered %d batches with %d spooled, want the whole backlog", delivered, p.SpoolLen())
+	}
+	p.Stop(context.Background())
+}
+
+func TestPipelineSpoolReplaysAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	down := &mockBackend{name: "test", healthy: false}
+	p := NewPipeline(PipelineConfig{
+		BatchSize:     100,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 1,
+		Spool:         SpoolConfig{Enabled: true, Dir: dir},
+	})
+	p.AddBackend(down)
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	p.Push(NewMetric("cpu").WithField("usage", 42.5))
+	p.Stop(ctx)
+
+	if len(down.written) != 0 {
+		t.Fatal("Unhealthy backend should not receive writes")
+	}
+
+	up := &mockBackend{name: "test", healthy: true}
+	p = NewPipeline(PipelineConfig{
+		BatchSize:     100,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 1,
+		Spool:         SpoolConfig{Enabled: true, Dir: dir},
+	})
+	p.AddBackend(up)
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	if p.SpoolLen() != 1 {
+		t.Fatalf("SpoolLen() after restart = %d, want 1", p.SpoolLen())
+	}
+
+	// Flush with an empty buffer still replays the backlog.
+	if err := p.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if len(up.written) != 1 || up.written[0][0].Measurement != "cpu" {
+		t.Errorf("Recovered backend should receive the spooled batch, got %v", up.written)
+	}
+	p.Stop(ctx)
+}
+
+func TestPipelineSlowBackendIsolated(t *testing.T) {
+	release := make(chan struct{})
+	slow := &retryBackend{
+		name:    "slow",
+		healthy: true,
+		writeFn: func(ctx context.Context, metrics []*Metric) error {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	}
+
+	received := make(chan []*Metric, 1)
+	fast := &retryBackend{
+		name:    "fast",
+		healthy: true,
+		writeFn: func(ctx context.Context, metrics []*Metric) error {
+			received <- metrics
+			return nil
+		},
+	}
+
+	cfg := PipelineConfig{
+		BatchSize:     1,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 1,
+	}
+	p := NewPipeline(cfg)
+	p.AddBackend(slow)
+	p.AddBackend(fast)
+
+	ctx := context.Background()
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	pushed := make(chan struct{})
+	go func() {
+		p.Push(NewMetric("cpu").WithField("usage", 42.5))
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+	case <-time.After(1 * time.Second):
+		t.Fatal("Push() should not block on a slow backend")
+	}
+
+	select {
+	case batch := <-received:
+		if len(batch) != 1 {
+			t.Errorf("fast backend received %d metrics, want 1", len(batch))
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("fast backend should not wait for the slow backend")
+	}
+
+	close(release)
+	p.Stop(ctx)
+}
+
+func TestPipelineFlushDeadlineKeepsBatches(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var delivered []string
+	backend := &retryBackend{
+		name:    "slow",
+		healthy: true,
+		writeFn: func(ctx context.Context, metrics []*Metric) error {
+			<-release
+			mu.Lock()
+			defer mu.Unlock()
+			for _, m := range metrics {
+				delivered = append(delivered, m.Measurement)
+			}
+			return nil
+		},
+	}
+
+	cfg := PipelineConfig{
+		BatchSize:     1,
+		QueueSize:     1,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 1,
+	}
+	p := NewPipeline(cfg)
+	p.AddBackend(backend)
+
+	// Stop the flush loop so only Flush hands batches to the queue.
+	startCtx, cancel := context.WithCancel(context.Background())
+	if err := p.Start(startCtx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	cancel()
+	p.wg.Wait()
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		p.Push(NewMetric(name).WithField("v", 1))
+	}
+
+	ctx, cancelFlush := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelFlush()
+	if err := p.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Flush() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if p.BufferLen() == 0 {
+		t.Fatal("batches Flush could not hand out should stay buffered")
+	}
+
+	close(release)
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	mu.Lock()
+	got := strings.Join(delivered, "")
+	mu.Unlock()
+	if got != "abcd" {
+		t.Errorf("delivered %q, want every metric in order", got)
+	}
+	if stats := p.BackendStats()[0]; stats.DroppedBatch != 0 {
+		t.Errorf("DroppedBatch = %d, want 0", stats.DroppedBatch)
+	}
+	p.Stop(context.Background())
+}
+
+func TestPipelineStopFullQueueNoDuplicates(t *testing.T) {
+	// The full queue is either the first one Stop's flush blocks on or one
+	// that other queues have already accepted the batch ahead of.
+	for _, slowFirst := range []bool{true, false} {
+		t.Run(fmt.Sprintf("slow first %v", slowFirst), func(t *testing.T) {
+			var mu sync.Mutex
+			delivered := map[string][]string{}
+			record := func(name string, metrics []*Metric) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, m := range metrics {
+					delivered[name] = append(delivered[name], m.Measurement)
+				}
+			}
+			fast := &retryBackend{
+				name:    "fast",
+				healthy: true,
+				writeFn: func(ctx context.Context, metrics []*Metric) error {
+					record("fast", metrics)
+					return nil
+				},
+			}
+			slow := &retryBackend{
+				name:    "slow",
+				healthy: true,
+				writeFn: func(ctx context.Context, metrics []*Metric) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			}
+
+			cfg := PipelineConfig{
+				BatchSize:     1,
+				QueueSize:     1,
+				FlushInterval: 1 * time.Hour,
+				RetryAttempts: 1,
+				Spool:         SpoolConfig{Enabled: true, Dir: t.TempDir()},
+			}
+			p := NewPipeline(cfg)
+			if slowFirst {
+				p.AddBackend(slow)
+				p.AddBackend(fast)
+			} else {
+				p.AddBackend(fast)
+				p.AddBackend(slow)
+			}
+
+			// Stop the flush loop so only Stop's flush hands batches to the queues.
+			startCtx, cancel := context.WithCancel(context.Background())
+			if err := p.Start(startCtx); err != nil {
+				t.Fatalf("Start() error: %v", err)
+			}
+			cancel()
+			p.wg.Wait()
+
+			for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+				p.Push(NewMetric(name).WithField("v", 1))
+			}
+
+			ctx, cancelStop := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancelStop()
+			p.Stop(ctx)
+
+			for i, q := range p.queues {
+				name := q.backend.Name()
+				if _, err := q.spool.Replay(func(batch []*Metric) error {
+					record(name, batch)
+					return nil
+				}); err != nil {
+					t.Fatalf("Replay() error: %v", err)
+				}
+				got := delivered[name]
+				sort.Strings(got)
+				if strings.Join(got, "") != "abcdef" {
+					t.Errorf("%s delivered or spooled %v, want every metric exactly once", name, got)
+				}
+				if stats := p.BackendStats()[i]; stats.DroppedBatch != 0 {
+					t.Errorf("%s DroppedBatch = %d, want 0", name, stats.DroppedBatch)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineBackendStats(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: true}
+
+	p := NewPipeline(PipelineConfig{
+		BatchSize:     100,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 1,
+	})
+	p.AddBackend(backend)
+
+	ctx := context.Background()
+	p.Start(ctx)
+
+	p.Push(NewMetric("cpu").WithField("usage", 42.5))
+	p.Flush(ctx)
+
+	stats := p.BackendStats()
+	if len(stats) != 1 {
+		t.Fatalf("BackendStats() len = %d, want 1", len(stats))
+	}
+	if stats[0].Name != "test" {
+		t.Errorf("Name = %q, want %q", stats[0].Name, "test")
+	}
+	if stats[0].DeliveredBatch != 1 {
+		t.Errorf("DeliveredBatch = %d, want 1", stats[0].DeliveredBatch)
+	}
+	if !stats[0].Healthy {
+		t.Error("Healthy should be true")
+	}
+
+	p.Stop(ctx)
+}
+
+func TestPipelineOverflowDropOldest(t *testing.T) {
+	p := NewPipeline(PipelineConfig{
+		BatchSize:      2,
+		MaxBufferSize:  2,
+		OverflowPolicy: OverflowDropOldest,
+	})
+
+	p.Push(NewMetric("a").WithField("v", 1))
+	p.Push(NewMetric("b").WithField("v", 2))
+	p.Push(NewMetric("c").WithField("v", 3))
+
+	stats := p.Stats()
+	if stats.BufferLen != 2 {
+		t.Errorf("BufferLen = %d, want 2", stats.BufferLen)
+	}
+	if stats.DroppedMetrics != 1 {
+		t.Errorf("DroppedMetrics = %d, want 1", stats.DroppedMetrics)
+	}
+	if stats.PushedMetrics != 3 {
+		t.Errorf("PushedMetrics = %d, want 3", stats.PushedMetrics)
+	}
+	if p.buffer[0].Measurement != "b" || p.buffer[1].Measurement != "c" {
+		t.Errorf("buffer = [%s %s], want [b c]", p.buffer[0].Measurement, p.buffer[1].Measurement)
+	}
+
+	// Repeated evictions keep the newest metrics without growing the buffer.
+	for i := 0; i < 1000; i++ {
+		p.Push(NewMetric(fmt.Sprintf("m%d", i)).WithField("v", i))
+	}
+	if p.buffer[0].Measurement != "m998" || p.buffer[1].Measurement != "m999" {
+		t.Errorf("buffer = [%s %s], want [m998 m999]", p.buffer[0].Measurement, p.buffer[1].Measurement)
+	}
+	if cap(p.buffer) > 8 {
+		t.Errorf("cap(buffer) = %d, want it bounded by MaxBufferSize", cap(p.buffer))
+	}
+}
+
+func TestPipelineOverflowDropNewest(t *testing.T) {
+	p := NewPipeline(PipelineConfig{
+		BatchSize:      2,
+		MaxBufferSize:  2,
+		OverflowPolicy: OverflowDropNewest,
+	})
+
+	p.Push(NewMetric("a").WithField("v", 1))
+	p.Push(NewMetric("b").WithField("v", 2))
+	p.Push(NewMetric("c").WithField("v", 3))
+
+	stats := p.Stats()
+	if stats.DroppedMetrics != 1 {
+		t.Errorf("DroppedMetrics = %d, want 1", stats.DroppedMetrics)
+	}
+	if p.buffer[0].Measurement != "a" || p.buffer[1].Measurement != "b" {
+		t.Errorf("buffer = [%s %s], want [a b]", p.buffer[0].Measurement, p.buffer[1].Measurement)
+	}
+}
+
+func TestPipelineOverflowBlockTimeout(t *testing.T) {
+	p := NewPipeline(PipelineConfig{
+		BatchSize:      1,
+		MaxBufferSize:  1,
+		OverflowPolicy: OverflowBlock,
+		BlockTimeout:   20 * time.Millisecond,
+	})
+
+	p.Push(NewMetric("a").WithField("v", 1))
+
+	start := time.Now()
+	p.Push(NewMetric("b").WithField("v", 2))
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Push() returned after %v, want it to block for the timeout", elapsed)
+	}
+
+	if stats := p.Stats(); stats.DroppedMetrics != 1 || stats.BufferLen != 1 {
+		t.Errorf("DroppedMetrics = %d, BufferLen = %d, want 1, 1", stats.DroppedMetrics, stats.BufferLen)
+	}
+}
+
+func TestPipelineOverflowBlockUnblocksOnFlush(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: true}
+	p := NewPipeline(PipelineConfig{
+		BatchSize:      1,
+		MaxBufferSize:  1,
+		OverflowPolicy: OverflowBlock,
+		BlockTimeout:   5 * time.Second,
+	})
+	p.AddBackend(backend)
+
+	p.Push(NewMetric("a").WithField("v", 1))
+
+	pushed := make(chan struct{})
+	go func() {
+		p.Push(NewMetric("b").WithField("v", 2))
+		close(pushed)
+	}()
+
+	// Flush before Start delivers inline and frees room for the blocked push.
+	time.Sleep(10 * time.Millisecond)
+	p.Flush(context.Background())
+
+	select {
+	case <-pushed:
+	case <-time.After(1 * time.Second):
+		t.Fatal("blocked Push() should resume once the buffer drains")
+	}
+
+	if stats := p.Stats(); stats.DroppedMetrics != 0 || stats.BufferLen != 1 {
+		t.Errorf("DroppedMetrics = %d, BufferLen = %d, want 0, 1", stats.DroppedMetrics, stats.BufferLen)
+	}
+}
+
+func TestPipelinePushAfterStop(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: true}
+	p := NewPipeline(PipelineConfig{BatchSize: 10, FlushInterval: 1 * time.Hour})
+	p.AddBackend(backend)
+
+	ctx := context.Background()
+	p.Start(ctx)
+	p.Push(NewMetric("before").WithField("v", 1))
+	p.Stop(ctx)
+
+	if err := p.push(NewMetric("after").WithField("v", 2)); !errors.Is(err, errPipelineStopped) {
+		t.Errorf("push() error = %v, want %v", err, errPipelineStopped)
+	}
+	stats := p.Stats()
+	if stats.PushedMetrics != 1 || stats.DroppedMetrics != 1 || stats.BufferLen != 0 {
+		t.Errorf("Pushed = %d, Dropped = %d, BufferLen = %d, want 1, 1, 0",
+			stats.PushedMetrics, stats.DroppedMetrics, stats.BufferLen)
+	}
+	if len(backend.written) != 1 || backend.written[0][0].Measurement != "before" {
+		t.Errorf("written = %v, want only the metric pushed before Stop", backend.written)
+	}
+}
+
+func TestPipelineFlushSplitsBatches(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: true}
+	p := NewPipeline(PipelineConfig{
+		BatchSize:     2,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 1,
+	})
+	p.AddBackend(backend)
+
+	for i := 0; i < 5; i++ {
+		p.Push(NewMetric("cpu").WithField("v", i))
+	}
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	if len(backend.written) != 3 {
+		t.Fatalf("Backend received %d batches, want 3", len(backend.written))
+	}
+	if len(backend.written[2]) != 1 {
+		t.Errorf("Last batch has %d metrics, want 1", len(backend.written[2]))
+	}
+}
+
+func TestPipelineInvalidMetricCounted(t *testing.T) {
+	p := NewPipeline(DefaultPipelineConfig())
+	p.Push(NewMetric("cpu"))
+
+	if stats := p.Stats(); stats.InvalidMetrics != 1 || stats.PushedMetrics != 0 {
+		t.Errorf("InvalidMetrics = %d, PushedMetrics = %d, want 1, 0", stats.InvalidMetrics, stats.PushedMetrics)
+	}
+}
+
+func TestPipelinePermanentErrorNotRetried(t *testing.T) {
+	calls := 0
+	var mu sync.Mutex
+	backend := &retryBackend{
+		name:    "test",
+		healthy: true,
+		writeFn: func(ctx context.Context, metrics []*Metric) error {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			return Permanent(fmt.Errorf("bad line protocol"))
+		},
+	}
+
+	p := NewPipeline(PipelineConfig{
+		BatchSize:     100,
+		FlushInterval: 1 * time.Hour,
+		RetryAttempts: 3,
+		RetryDelay:    1 * time.Millisecond,
+		Spool:         SpoolConfig{Enabled: true, Dir: t.TempDir()},
+	})
+	p.AddBackend(backend)
+
+	ctx := context.Background()
+	p.Start(ctx)
+
+	p.Push(NewMetric("cpu").WithField("usage", 42.5))
+	if err := p.Flush(ctx); err == nil {
+		t.Error("Flush() should report the permanent error")
+	}
+
+	mu.Lock()
+	if calls != 1 {
+		t.Errorf("Expected 1 write attempt for a permanent error, got %d", calls)
+	}
+	mu.Unlock()
+
+	if p.SpoolLen() != 0 {
+		t.Errorf("SpoolLen() = %d, want 0 (rejected batches are not spooled)", p.SpoolLen())
+	}
+	if stats := p.BackendStats()[0]; stats.RejectedBatch != 1 || stats.Retries != 0 {
+		t.Errorf("RejectedBatch = %d, Retries = %d, want 1, 0", stats.RejectedBatch, stats.Retries)
+	}
+
+	p.Stop(ctx)
+}
+
+func TestPipelineBackoff(t *testing.T) {
+	p := NewPipeline(PipelineConfig{
+		RetryDelay:    10 * time.Millisecond,
+		RetryMaxDelay: 40 * time.Millisecond,
+	})
+
+	ceilings := map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 20 * time.Millisecond,
+		3: 40 * time.Millisecond,
+		8: 40 * time.Millisecond,
+	}
+	for attempt, ceiling := range ceilings {
+		for i := 0; i < 50; i++ {
+			d := p.backoff(attempt)
+			if d < 0 || d > ceiling {
+				t.Fatalf("backoff(%d) = %v, want within [0, %v]", attempt, d, ceiling)
+			}
+		}
+	}
+}
+
+// probeBackend is a retryBackend that also implements Prober.
+type probeBackend struct {
+	retryBackend
+	probeFn func(ctx context.Context) error
+}
+
+func (b *probeBackend) Probe(ctx context.Context) error { return b.probeFn(ctx) }
+
+func TestPipelineCircuitBreakerRecovers(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	writes := 0
+	delivered := make(chan []*Metric, 10)
+	backend := &probeBackend{
+		retryBackend: retryBackend{
+			name:    "test",
+			healthy: true,
+			writeFn: func(ctx context.Context, metrics []*Metric) error {
+				mu.Lock()
+				defer mu.Unlock()
+				writes++
+				if down {
+					return fmt.Errorf("backend down")
+				}
+				delivered <- metrics
+				return nil
+			},
+		},
+		probeFn: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if down {
+				return fmt.Errorf("still down")
+			}
+			return nil
+		},
+	}
+
+	p := NewPipeline(PipelineConfig{
+		BatchSize:        100,
+		FlushInterval:    1 * time.Hour,
+		RetryAttempts:    1,
+		BreakerThreshold: 1,
+		BreakerCooldown:  20 * time.Millisecond,
+		Spool:            SpoolConfig{Enabled: true, Dir: t.TempDir()},
+	})
+	p.AddBackend(backend)
+
+	ctx := context.Background()
+	p.Start(ctx)
+
+	p.Push(NewMetric("first").WithField("v", 1))
+	p.Flush(ctx)
+
+	if state := p.BackendStats()[0].State; state != BreakerOpen {
+		t.Fatalf("State = %v, want open", state)
+	}
+
+	// While open, batches are spooled without touching the backend.
+	p.Push(NewMetric("second").WithField("v", 2))
+	p.Flush(ctx)
+
+	mu.Lock()
+	if writes != 1 {
+		t.Errorf("writes = %d, want 1 (open circuit should not write)", writes)
+	}
+	down = false
+	mu.Unlock()
+
+	// The worker probes on its own once the cooldown elapses and replays
+	// the spool without any new metrics arriving.
+	var got []string
+	timeout := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case batch := <-delivered:
+			got = append(got, batch[0].Measurement)
+		case <-timeout:
+			t.Fatalf("spooled batches not replayed after recovery, got %v", got)
+		}
+	}
+	if fmt.Sprint(got) != "[first second]" {
+		t.Errorf("replayed = %v, want [first second]", got)
+	}
+	if state := p.BackendStats()[0].State; state != BreakerClosed {
+		t.Errorf("State = %v, want closed", state)
+	}
+
+	p.Stop(ctx)
+}
+
+func TestPipelineUnhealthyBackendTrialWrite(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: false}
+
+	p := NewPipeline(PipelineConfig{
+		BatchSize:        100,
+		FlushInterval:    1 * time.Hour,
+		RetryAttempts:    1,
+		BreakerThreshold: 1,
+		BreakerCooldown:  10 * time.Millisecond,
+	})
+	p.AddBackend(backend)
+
+	ctx := context.Background()
+	p.Start(ctx)
+
+	p.Push(NewMetric("dropped").WithField("v", 1))
+	p.Flush(ctx)
+	if len(backend.written) != 0 {
+		t.Fatal("Unhealthy backend should be skipped while the circuit is open")
+	}
+
+	// After the cooldown the next batch is a trial write, even though the
+	// backend still reports itself unhealthy.
+	time.Sleep(20 * time.Millisecond)
+	p.Push(NewMetric("trial").WithField("v", 2))
+	p.Flush(ctx)
+
+	if len(backend.written) != 1 || backend.written[0][0].Measurement != "trial" {
+		t.Errorf("Expected trial write after cooldown, got %v", backend.written)
+	}
+	if state := p.BackendStats()[0].State; state != BreakerClosed {
+		t.Errorf("State = %v, want closed after successful trial write", state)
+	}
+
+	p.Stop(ctx)
+}
+
+func TestPipelineHalfOpenEmptyWakeup(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: true}
+	p := NewPipeline(PipelineConfig{
+		BatchSize:        100,
+		FlushInterval:    1 * time.Hour,
+		BreakerThreshold: 1,
+		BreakerCooldown:  10 * time.Millisecond,
+	})
+	p.AddBackend(backend)
+	q := p.queues[0]
+
+	// A wakeup with no batch and no backlog cannot try the backend, so the
+	// breaker goes back to open rather than staying half-open.
+	q.breaker.trip(time.Now().Add(-time.Hour))
+	if err := q.deliver(context.Background(), p, nil); err != nil {
+		t.Fatalf("deliver() error: %v", err)
+	}
+	if state := q.breaker.peek(); state != BreakerOpen {
+		t.Fatalf("State = %v, want open", state)
+	}
+	if len(backend.written) != 0 {
+		t.Errorf("written = %v, want nothing", backend.written)
+	}
+
+	// Once the cooldown has elapsed the next batch is the trial write.
+	time.Sleep(20 * time.Millisecond)
+	if err := q.deliver(context.Background(), p, []*Metric{NewMetric("trial").WithField("v", 1)}); err != nil {
+		t.Fatalf("deliver() error: %v", err)
+	}
+	if state := q.breaker.peek(); state != BreakerClosed || len(backend.written) != 1 {
+		t.Errorf("State = %v, written = %v, want closed after the trial write", state, backend.written)
+	}
+}
+
+func TestPipelineUnhealthyBackendBreakerThreshold(t *testing.T) {
+	backend := &retryBackend{name: "test", healthy: true}
+	backend.writeFn = func(ctx context.Context, metrics []*Metric) error {
+		backend.healthy = false
+		return fmt.Errorf("backend down")
+	}
+
+	p := NewPipeline(PipelineConfig{
+		BatchSize:        100,
+		FlushInterval:    1 * time.Hour,
+		RetryAttempts:    1,
+		BreakerThreshold: 3,
+		BreakerCooldown:  1 * time.Hour,
+	})
+	p.AddBackend(backend)
+
+	ctx := context.Background()
+	p.Start(ctx)
+
+	for i, want := range []BreakerState{BreakerClosed, BreakerClosed, BreakerOpen} {
+		p.Push(NewMetric("cpu").WithField("v", i))
+		p.Flush(ctx)
+		if state := p.BackendStats()[0].State; state != want {
+			t.Errorf("after %d failed deliveries: State = %v, want %v", i+1, state, want)
+		}
+	}
+
+	p.Stop(ctx)
+}
+
+func TestPipelineBackendPanicRecovered(t *testing.T) {
+	for _, trip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("trip=%v", trip), func(t *testing.T) {
+			calls := 0
+			var mu sync.Mutex
+			bad := &retryBackend{
+				name:    "bad",
+				healthy: true,
+				writeFn: func(ctx context.Context, metrics []*Metric) error {
+					mu.Lock()
+					calls++
+					mu.Unlock()
+					panic("nil map write")
+				},
+			}
+			good := &mockBackend{name: "good", healthy: true}
+
+			logger, _ := NewLogger("error")
+			p := NewPipeline(PipelineConfig{
+				BatchSize:     100,
+				FlushInterval: 1 * time.Hour,
+				RetryAttempts: 3,
+				RetryDelay:    1 * time.Millisecond,
+				TripOnPanic:   trip,
+				Spool:         SpoolConfig{Enabled: true, Dir: t.TempDir()},
+				Logger:        logger,
+			})
+			p.AddBackend(bad)
+			p.AddBackend(good)
+
+			ctx := context.Background()
+			p.Start(ctx)
+			defer p.Stop(ctx)
+
+			p.Push(NewMetric("cpu").WithField("usage", 42.5))
+			err := p.Flush(ctx)
+			if _, ok := asPanic(err); !ok {
+				t.Errorf("Flush() error = %v, want a PanicError", err)
+			}
+
+			mu.Lock()
+			if calls != 1 {
+				t.Errorf("Expected 1 write attempt for a panic, got %d", calls)
+			}
+			mu.Unlock()
+
+			if len(good.written) != 1 {
+				t.Errorf("good backend got %d batches, want 1", len(good.written))
+			}
+			if p.SpoolLen() != 0 {
+				t.Errorf("SpoolLen() = %d, want 0 (panicking batches are not spooled)", p.SpoolLen())
+			}
+
+			stats := p.BackendStats()[0]
+			if stats.PanickedBatch != 1 || stats.FailedBatch != 1 {
+				t.Errorf("PanickedBatch = %d, FailedBatch = %d, want 1, 1", stats.PanickedBatch, stats.FailedBatch)
+			}
+			want := BreakerClosed
+			if trip {
+				want = BreakerOpen
+			}
+			if stats.State != want {
+				t.Errorf("State = %v, want %v", stats.State, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync/atomic"
+)
+
+// Processor transforms metrics between collection and delivery. Process may
+// modify the metrics it is given in place, drop some or add new ones, and
+// returns the metrics to pass on. It may be called concurrently.
+//
+// Processors added with WithProcessor or configured without backends run
+// on everything a collector returns or the Emitter submits, before the
+// metrics are validated and buffered. Per-backend processors, configured
+// with backends or set up with NewProcessedBackend, run on copies of each
+// batch written to their backend.
+type Processor interface {
+	Process(metrics []*Metric) []*Metric
+}
+
+// ProcessorFunc adapts a function to a Processor.
+type ProcessorFunc func(metrics []*Metric) []*Metric
+
+func (f ProcessorFunc) Process(metrics []*Metric) []*Metric {
+	return f(metrics)
+}
+
+// runProcessors passes metrics through each processor in turn.
+func runProcessors(procs []Processor, metrics []*Metric) []*Metric {
+	for _, p := range procs {
+		if len(metrics) == 0 {
+			break
+		}
+		metrics = p.Process(metrics)
+	}
+	return metrics
+}
+
+// MatchMeasurements restricts p to metrics whose measurement matches one of
+// the glob patterns. Other metrics pass through unchanged, ahead of those p
+// returns.
+func MatchMeasurements(patterns []string, p Processor) Processor {
+	return &measurementScope{patterns: patterns, p: p}
+}
+
+type measurementScope struct {
+	patterns []string
+	p        Processor
+}
+
+func (s *measurementScope) Process(metrics []*Metric) []*Metric {
+	var matched, rest []*Metric
+	for _, m := range metrics {
+		if matchAny(s.patterns, m.Measurement) {
+			matched = append(matched, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+	if len(matched) == 0 {
+		return metrics
+	}
+	return append(rest, s.p.Process(matched)...)
+}
+
+// NewProcessedBackend wraps b so every batch written to it first passes
+// through procs. The batch is copied before processing, so other backends
+// see it unchanged, and a batch the processors empty is not written.
+// Metrics the processors leave invalid, such as one with every field
+// dropped, are removed from the batch and counted in the backend's
+// BackendStats.InvalidMetrics.
+func NewProcessedBackend(b Backend, procs ...Processor) Backend {
+	return &processedBackend{Backend: b, procs: procs}
+}
+
+type processedBackend struct {
+	Backend
+	procs   []Processor
+	invalid atomic.Int64
+}
+
+func (p *processedBackend) Write(ctx context.Context, metrics []*Metric) error {
+	batch := make([]*Metric, len(metrics))
+	for i, m := range metrics {
+		batch[i] = m.Clone()
+	}
+	batch = runProcessors(p.procs, batch)
+
+	valid := batch[:0]
+	for _, m := range batch {
+		if m.Validate() == nil {
+			valid = append(valid, m)
+		} else {
+			p.invalid.Add(1)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	return p.Backend.Write(ctx, valid)
+}
+
+// invalidMetrics returns how many processed metrics were dropped as invalid.
+func (p *processedBackend) invalidMetrics() int64 {
+	return p.invalid.Load()
+}
+
+// Unwrap returns the wrapped backend.
+func (p *processedBackend) Unwrap() Backend {
+	return p.Backend
+}
+
+// asProber returns b, or the backend it wraps, as a Prober.
+func asProber(b Backend) (Prober, bool) {
+	return unwrapBackend[Prober](b)
+}
+
+// unwrapBackend returns b, or the first backend it wraps, as a T.
+func unwrapBackend[T any](b Backend) (T, bool) {
+	for {
+		if t, ok := b.(T); ok {
+			return t, true
+		}
+		w, ok := b.(interface{ Unwrap() Backend })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		b = w.Unwrap()
+	}
+}
+
+// scopedProcessor is a processor built from config with its place in the
+// chain and the backends it is limited to.
+type scopedProcessor struct {
+	order    int
+	backends []string
+	p        Processor
+}
+
+// build creates the configured processors, sorted by order.
+func (c ProcessorsConfig) build() ([]scopedProcessor, error) {
+	var procs []scopedProcessor
+	add := func(scope ProcessorScope, p Processor) {
+		if len(scope.Measurements) > 0 {
+			p = MatchMeasurements(scope.Measurements, p)
+		}
+		procs = append(procs, scopedProcessor{order: scope.Order, backends: scope.Backends, p: p})
+	}
+
+	for _, r := range c.Rename {
+		add(r.ProcessorScope, &RenameProcessor{Measurements: r.Measurement, Tags: r.Tags, Fields: r.Fields})
+	}
+	for _, t := range c.Tags {
+		add(t.ProcessorScope, &TagsProcessor{Add: t.Add, Drop: t.Drop, Overwrite: t.Overwrite})
+	}
+	for _, f := range c.Fields {
+		add(f.ProcessorScope, &FieldsProcessor{Add: f.Add, Drop: f.Drop, Overwrite: f.Overwrite})
+	}
+	for i, r := range c.Regex {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("processors.regex[%d]: %w", i, err)
+		}
+		add(r.ProcessorScope, &RegexProcessor{Tag: r.Tag, Pattern: re, Replacement: r.Replacement, ResultTag: r.ResultTag})
+	}
+	for _, cv := range c.Convert {
+		add(cv.ProcessorScope, &ConvertProcessor{Fields: cv.Fields, Type: cv.Type})
+	}
+	for _, s := range c.Scale {
+		add(s.ProcessorScope, &ScaleProcessor{Fields: s.Fields, Factor: s.Factor, Offset: s.Offset})
+	}
+
+	sort.SliceStable(procs, func(i, j int) bool {
+		return procs[i].order < procs[j].order
+	})
+	return procs, nil
+}
+
+// setupProcessors appends the configured processors to those added with
+// WithProcessor, or to the chain of each backend they name.
+func (m *Monitor) setupProcessors() error {
+	procs, err := m.cfg.Processors.build()
+	if err != nil {
+		return err
+	}
+	for _, sp := range procs {
+		if len(sp.backends) == 0 {
+			m.processors = append(m.processors, sp.p)
+			continue
+		}
+		if m.backendProcessors == nil {
+			m.backendProcessors = make(map[string][]Processor)
+		}
+		for _, name := range sp.backends {
+			m.backendProcessors[name] = append(m.backendProcessors[name], sp.p)
+		}
+	}
+	return nil
+}
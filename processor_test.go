@@ -0,0 +1,216 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMatchMeasurements(t *testing.T) {
+	p := MatchMeasurements([]string{"cpu*"}, ProcessorFunc(func(metrics []*Metric) []*Metric {
+		for _, m := range metrics {
+			m.WithTag("seen", "true")
+		}
+		return metrics
+	}))
+
+	metrics := p.Process([]*Metric{
+		NewMetric("cpu").WithField("v", 1),
+		NewMetric("mem").WithField("v", 1),
+		NewMetric("cpu_core").WithField("v", 1),
+	})
+	if len(metrics) != 3 {
+		t.Fatalf("got %d metrics, want 3", len(metrics))
+	}
+	for _, m := range metrics {
+		want := m.Measurement != "mem"
+		if (m.Tags["seen"] == "true") != want {
+			t.Errorf("%s: seen = %q", m.Measurement, m.Tags["seen"])
+		}
+	}
+}
+
+type probingBackend struct {
+	mockBackend
+	probed bool
+}
+
+func (p *probingBackend) Probe(ctx context.Context) error {
+	p.probed = true
+	return nil
+}
+
+func TestProcessedBackend(t *testing.T) {
+	inner := &probingBackend{mockBackend: mockBackend{name: "inner", healthy: true}}
+	dropDebug := ProcessorFunc(func(metrics []*Metric) []*Metric {
+		var kept []*Metric
+		for _, m := range metrics {
+			if m.Measurement != "debug" {
+				kept = append(kept, m.WithTag("backend", "inner"))
+			}
+		}
+		return kept
+	})
+	b := NewProcessedBackend(inner, dropDebug)
+
+	if b.Name() != "inner" {
+		t.Errorf("Name() = %q, want the wrapped backend's name", b.Name())
+	}
+	prober, ok := asProber(b)
+	if !ok {
+		t.Fatal("a wrapped Prober should still be found")
+	}
+	prober.Probe(context.Background())
+	if !inner.probed {
+		t.Error("Probe should reach the wrapped backend")
+	}
+
+	original := NewMetric("cpu").WithField("v", 1)
+	if err := b.Write(context.Background(), []*Metric{original, NewMetric("debug").WithField("v", 1)}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if len(inner.written) != 1 || len(inner.written[0]) != 1 || inner.written[0][0].Tags["backend"] != "inner" {
+		t.Fatalf("written = %v", inner.written)
+	}
+	if _, ok := original.Tags["backend"]; ok {
+		t.Error("processing for one backend must not modify the shared metric")
+	}
+
+	if err := b.Write(context.Background(), []*Metric{NewMetric("debug").WithField("v", 1)}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if len(inner.written) != 1 {
+		t.Error("an emptied batch should not be written")
+	}
+}
+
+func TestProcessedBackendDropsInvalid(t *testing.T) {
+	inner := &mockBackend{name: "influxdb", healthy: true}
+	b := NewProcessedBackend(inner, &FieldsProcessor{Drop: []string{"debug_*"}})
+
+	err := b.Write(context.Background(), []*Metric{
+		NewMetric("app").WithField("requests", 1),
+		NewMetric("trace").WithField("debug_id", 1),
+	})
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if len(inner.written) != 1 || len(inner.written[0]) != 1 || inner.written[0][0].Measurement != "app" {
+		t.Fatalf("written = %v, want only the metric that kept its fields", inner.written)
+	}
+
+	p := NewPipeline(PipelineConfig{})
+	p.AddBackend(b)
+	if got := p.BackendStats()[0].InvalidMetrics; got != 1 {
+		t.Errorf("InvalidMetrics = %d, want 1", got)
+	}
+}
+
+func TestProcessorsConfigOrder(t *testing.T) {
+	cfg := ProcessorsConfig{
+		Scale: []ScaleProcessorConfig{{
+			ProcessorScope: ProcessorScope{Order: 1},
+			Fields:         []string{"v"},
+			Factor:         10,
+		}},
+		Convert: []ConvertProcessorConfig{{
+			ProcessorScope: ProcessorScope{Order: 2},
+			Fields:         []string{"v"},
+			Type:           FieldTypeInteger,
+		}},
+		Tags: []TagsProcessorConfig{{
+			ProcessorScope: ProcessorScope{Order: 3, Backends: []string{"influxdb"}},
+			Add:            map[string]string{"a": "b"},
+		}},
+	}
+	procs, err := cfg.build()
+	if err != nil {
+		t.Fatalf("build() error: %v", err)
+	}
+	if len(procs) != 3 || procs[2].backends[0] != "influxdb" {
+		t.Fatalf("procs = %+v", procs)
+	}
+
+	m := NewMetric("x").WithField("v", 1.26)
+	procs[0].p.Process([]*Metric{m})
+	procs[1].p.Process([]*Metric{m})
+	if m.Fields["v"] != int64(12) {
+		t.Errorf("v = %#v, want scaled before being converted", m.Fields["v"])
+	}
+
+	cfg = ProcessorsConfig{Regex: []RegexProcessorConfig{{Tag: "t", Pattern: "("}}}
+	if _, err := cfg.build(); err == nil {
+		t.Error("build() should fail on an invalid pattern")
+	}
+}
+
+func TestMonitorProcessors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Processors = ProcessorsConfig{
+		Rename: []RenameProcessorConfig{{
+			Measurement: map[string]string{"cpu": "host_cpu"},
+		}},
+		Tags: []TagsProcessorConfig{{
+			ProcessorScope: ProcessorScope{Backends: []string{"b2"}},
+			Add:            map[string]string{"dest": "b2"},
+		}},
+	}
+
+	b1 := &mockBackend{name: "b1", healthy: true}
+	b2 := &mockBackend{name: "b2", healthy: true}
+	var order []string
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) {
+		return []*Metric{NewMetric("cpu").WithField("v", 1)}, nil
+	},
+		WithConfig(cfg),
+		WithRunOnce(true),
+		WithBackend(b1),
+		WithBackend(b2),
+		WithProcessor(ProcessorFunc(func(metrics []*Metric) []*Metric {
+			for _, m := range metrics {
+				order = append(order, m.Measurement)
+			}
+			return metrics
+		})),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := m.Emitter().Emit(NewMetric("event").WithField("v", 1)); err != nil {
+		t.Fatalf("Emit() error: %v", err)
+	}
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "event" || order[1] != "cpu" {
+		t.Errorf("WithProcessor saw %v, want emitted and collected metrics before renaming", order)
+	}
+	check := func(b *mockBackend, wantDest string) {
+		t.Helper()
+		var names []string
+		for _, batch := range b.written {
+			for _, metric := range batch {
+				names = append(names, metric.Measurement)
+				if metric.Tags["dest"] != wantDest {
+					t.Errorf("%s: dest tag = %q, want %q", b.name, metric.Tags["dest"], wantDest)
+				}
+			}
+		}
+		if len(names) != 2 || names[1] != "host_cpu" {
+			t.Errorf("%s received %v", b.name, names)
+		}
+	}
+	check(b1, "")
+	check(b2, "b2")
+}
+
+func TestMonitorProcessorsInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Processors.Regex = []RegexProcessorConfig{{Tag: "t", Pattern: "["}}
+	_, err := New("test", func(ctx context.Context) ([]*Metric, error) { return nil, nil }, WithConfig(cfg))
+	if err == nil || !strings.Contains(err.Error(), "processors.regex[0]") {
+		t.Errorf("New() error = %v, want the invalid processor named", err)
+	}
+}
@@ -1,11 +1,14 @@
 package promexporter
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +16,7 @@
 	monitor "github.com/danweinerdev/go-monitor"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
 )
 
 // Backend implements monitor.Backend for Prometheus.
@@ -54,7 +58,9 @@ func (b *Backend) Initialize(ctx context.Context) error {
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle(b.cfg.Path, promhttp.Handler())
+	mux.Handle(b.cfg.Path, promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer, b.metricsHandler(prometheus.DefaultGatherer),
+	))
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -128,22 +134,102 @@ func (b *Backend) Healthy() bool {
 // Compile-time check.
 var _ monitor.Backend = (*Backend)(nil)
 
+// metricsHandler serves the gathered metrics in the negotiated exposition
+// format, gzip-compressed when the client accepts it. It replaces
+// promhttp.HandlerFor so that field units reach the output: promhttp never
+// asks the OpenMetrics encoder to write UNIT lines. Like promhttp's default,
+// a failed gather is reported with 500 rather than a partial result.
+func (b *Backend) metricsHandler(g prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := g.Gather()
+		if err != nil {
+			b.logger.Error("error gathering Prometheus metrics", "error", err)
+			http.Error(w, "An error has occurred while gathering metrics:\n\n"+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		units := b.collector.units()
+		for _, mf := range families {
+			if unit, ok := units[mf.GetName()]; ok {
+				mf.Unit = &unit
+			}
+		}
+
+		format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+		w.Header().Set("Content-Type", string(format))
+
+		out := io.Writer(w)
+		if acceptsGzip(r.Header) {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			out = gz
+		}
+
+		enc := expfmt.NewEncoder(out, format, expfmt.WithUnit())
+		for _, mf := range families {
+			if err := enc.Encode(mf); err != nil {
+				b.logger.Error("error encoding Prometheus metrics", "error", err)
+				return
+			}
+		}
+		if closer, ok := enc.(expfmt.Closer); ok {
+			closer.Close()
+		}
+	})
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+func acceptsGzip(h http.Header) bool {
+	for _, part := range strings.Split(h.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(part, ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			v, err := strconv.ParseFloat(q, 64)
+			return err == nil && v > 0
+		}
+		return true
+	}
+	return false
+}
+
 // dynamicCollector is a generic Prometheus collector that dynamically creates
-// gauges from metric measurement names and field names.
+// metrics from metric measurement names and field names. Each field is
+// exported with the Prometheus type matching its monitor.Kind, its help text,
+// and the conventional unit and _total suffixes.
 type dynamicCollector struct {
-	mu      sync.RWMutex
-	metrics map[string]*metricEntry // keyed by "measurement/tag_values"
+	mu       sync.RWMutex
+	metrics  map[string]*metricEntry // keyed by "measurement/tag_values"
+	families map[familyKey]family
 }
 
 type metricEntry struct {
 	measurement string
 	tags        map[string]string
-	fields      map[string]float64
+	fields      map[string]float64 // gauges and counters
+	histograms  map[string]monitor.Histogram
+	summaries   map[string]monitor.Summary
+}
+
+// familyKey identifies the series that share a Prometheus metric name.
+type familyKey struct {
+	measurement, field string
+}
+
+// family is the kind, help and unit of a measurement's field. Prometheus
+// rejects a scrape whose series of one name disagree on help or type, so
+// the first series to report a field decides them for every other series.
+type family struct {
+	kind monitor.Kind
+	monitor.FieldMeta
 }
 
 func newDynamicCollector() *dynamicCollector {
 	return &dynamicCollector{
-		metrics: make(map[string]*metricEntry),
+		metrics:  make(map[string]*metricEntry),
+		families: make(map[familyKey]family),
 	}
 }
 
@@ -158,6 +244,8 @@ func (c *dynamicCollector) update(m *monitor.Metric) {
 			measurement: m.Measurement,
 			tags:        make(map[string]string),
 			fields:      make(map[string]float64),
+			histograms:  make(map[string]monitor.Histogram),
+			summaries:   make(map[string]monitor.Summary),
 		}
 		c.metrics[key] = entry
 	}
@@ -166,10 +254,47 @@ func (c *dynamicCollector) update(m *monitor.Metric) {
 		entry.tags[k] = v
 	}
 	for k, v := range m.Fields {
-		entry.fields[k] = toFloat64(v)
+		kind := m.FieldKind(k)
+		switch v.(type) {
+		case string:
+			continue // strings have no Prometheus representation
+		case monitor.Histogram:
+			kind = monitor.KindHistogram
+		case monitor.Summary:
+			kind = monitor.KindSummary
+		}
+
+		fk := familyKey{m.Measurement, k}
+		fam, ok := c.families[fk]
+		if !ok {
+			fam = family{kind: kind, FieldMeta: monitor.FieldMeta{Help: m.FieldHelp(k), Unit: m.FieldUnit(k)}}
+			c.families[fk] = fam
+		}
+		switch {
+		case fam.kind == kind:
+		case isScalar(fam.kind) && isScalar(kind):
+			// A gauge reported as a counter, or the other way round, is
+			// exported as whichever came first.
+		default:
+			continue // e.g. a histogram where a gauge was reported before
+		}
+
+		switch val := v.(type) {
+		case monitor.Histogram:
+			entry.histograms[k] = val
+		case monitor.Summary:
+			entry.summaries[k] = val
+		default:
+			entry.fields[k] = toFloat64(v)
+		}
 	}
 }
 
+// isScalar reports whether kind is exported as a single value.
+func isScalar(kind monitor.Kind) bool {
+	return kind != monitor.KindHistogram && kind != monitor.KindSummary
+}
+
 func metricKey(m *monitor.Metric) string {
 	var sb strings.Builder
 	sb.WriteString(m.Measurement)
@@ -210,15 +335,76 @@ func (c *dynamicCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 
 		for fieldName, fieldValue := range entry.fields {
-			fqName := sanitizeName(entry.measurement + "_" + fieldName)
-			desc := prometheus.NewDesc(fqName, "", tagKeys, nil)
-			m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, fieldValue, tagValues...)
+			desc, kind := c.desc(entry.measurement, fieldName, tagKeys)
+			valueType := prometheus.GaugeValue
+			if kind == monitor.KindCounter {
+				valueType = prometheus.CounterValue
+			}
+			m, err := prometheus.NewConstMetric(desc, valueType, fieldValue, tagValues...)
+			if err != nil {
+				continue
+			}
+			ch <- m
+		}
+
+		for fieldName, h := range entry.histograms {
+			desc, _ := c.desc(entry.measurement, fieldName, tagKeys)
+			m, err := prometheus.NewConstHistogram(desc, h.Count, h.Sum, h.Buckets, tagValues...)
 			if err != nil {
 				continue
 			}
 			ch <- m
 		}
+
+		for fieldName, s := range entry.summaries {
+			desc, _ := c.desc(entry.measurement, fieldName, tagKeys)
+			m, err := prometheus.NewConstSummary(desc, s.Count, s.Sum, s.Quantiles, tagValues...)
+			if err != nil {
+				continue
+			}
+			ch <- m
+		}
+	}
+}
+
+// units returns the unit of every exported metric that has one, keyed by
+// metric name.
+func (c *dynamicCollector) units() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	units := make(map[string]string)
+	for fk, fam := range c.families {
+		if fam.Unit == "" {
+			continue
+		}
+		units[metricName(fk.measurement, fk.field, fam.kind, fam.Unit)] = sanitizeName(string(fam.Unit))
+	}
+	return units
+}
+
+// desc returns the descriptor of a measurement's field and the kind it is
+// exported as.
+func (c *dynamicCollector) desc(measurement, fieldName string, tagKeys []string) (*prometheus.Desc, monitor.Kind) {
+	fam := c.families[familyKey{measurement, fieldName}]
+	fqName := metricName(measurement, fieldName, fam.kind, fam.Unit)
+	return prometheus.NewDesc(fqName, fam.Help, tagKeys, nil), fam.kind
+}
+
+// metricName builds the Prometheus name for a field following the naming
+// conventions: the unit as a suffix, then _total for counters, each added
+// only if the name does not already end with it.
+func metricName(measurement, fieldName string, kind monitor.Kind, unit monitor.Unit) string {
+	name := sanitizeName(measurement + "_" + fieldName)
+	if unit != "" {
+		if suffix := "_" + sanitizeName(string(unit)); !strings.HasSuffix(name, suffix) {
+			name += suffix
+		}
+	}
+	if kind == monitor.KindCounter && !strings.HasSuffix(name, "_total") {
+		name += "_total"
 	}
+	return name
 }
 
 func sanitizeName(s string) string {
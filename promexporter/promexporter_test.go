@@ -1,10 +1,17 @@
 package promexporter
 
 import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	monitor "github.com/danweinerdev/go-monitor"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestNewBackend(t *testing.T) {
@@ -30,7 +37,8 @@ func TestDynamicCollector(t *testing.T) {
 	m := monitor.NewMetric("cpu").
 		WithTag("host", "server1").
 		WithField("usage", 42.5).
-		WithField("idle", 57.5)
+		WithField("idle", 57.5).
+		WithField("state", "ok") // strings are not exported
 
 	c.update(m)
 
@@ -151,3 +159,221 @@ func TestMetricKey(t *testing.T) {
 		t.Errorf("metricKey() = %q, want %q", key, "cpu/host=server1/region=us-east")
 	}
 }
+
+func TestDynamicCollectorKinds(t *testing.T) {
+	c := newDynamicCollector()
+	c.update(monitor.NewMetric("http").
+		WithTag("host", "server1").
+		WithField("in_flight", 3).
+		WithCounter("requests", 120).
+		WithHistogram("latency", monitor.Histogram{
+			Count:   3,
+			Sum:     1.5,
+			Buckets: map[float64]uint64{0.1: 1, 1: 2},
+		}).
+		WithSummary("size", monitor.Summary{Count: 3, Sum: 30, Quantiles: map[float64]float64{0.5: 9}}))
+
+	ch := make(chan prometheus.Metric, 10)
+	c.Collect(ch)
+	close(ch)
+
+	got := make(map[string]*dto.Metric)
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+		got[m.Desc().String()] = &out
+	}
+	if len(got) != 4 {
+		t.Fatalf("Expected 4 prometheus metrics, got %d", len(got))
+	}
+
+	for desc, m := range got {
+		switch {
+		case strings.Contains(desc, `"http_in_flight"`):
+			if m.Gauge == nil {
+				t.Errorf("http_in_flight should be a gauge: %v", m)
+			}
+		case strings.Contains(desc, `"http_requests_total"`):
+			if m.Counter == nil || m.Counter.GetValue() != 120 {
+				t.Errorf("http_requests_total should be a counter of 120: %v", m)
+			}
+		case strings.Contains(desc, `"http_latency"`):
+			if m.Histogram == nil || m.Histogram.GetSampleCount() != 3 || len(m.Histogram.Bucket) != 2 {
+				t.Errorf("http_latency should be a histogram with 2 buckets: %v", m)
+			}
+		case strings.Contains(desc, `"http_size"`):
+			if m.Summary == nil || len(m.Summary.Quantile) != 1 {
+				t.Errorf("http_size should be a summary with 1 quantile: %v", m)
+			}
+		default:
+			t.Errorf("unexpected metric %s", desc)
+		}
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	tests := []struct {
+		measurement, field string
+		kind               monitor.Kind
+		unit               monitor.Unit
+		want               string
+	}{
+		{"cpu", "usage", monitor.KindGauge, "", "cpu_usage"},
+		{"http", "requests", monitor.KindCounter, "", "http_requests_total"},
+		{"http", "requests_total", monitor.KindCounter, "", "http_requests_total"},
+		{"http", "latency", monitor.KindHistogram, monitor.UnitSeconds, "http_latency_seconds"},
+		{"net", "rx", monitor.KindCounter, monitor.UnitBytes, "net_rx_bytes_total"},
+		{"net", "rx_bytes", monitor.KindCounter, monitor.UnitBytes, "net_rx_bytes_total"},
+		{"disk", "used", monitor.KindGauge, monitor.UnitRatio, "disk_used_ratio"},
+	}
+
+	for _, tt := range tests {
+		if got := metricName(tt.measurement, tt.field, tt.kind, tt.unit); got != tt.want {
+			t.Errorf("metricName(%q, %q, %v, %q) = %q, want %q",
+				tt.measurement, tt.field, tt.kind, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestMetricsHandlerHelpAndUnit(t *testing.T) {
+	b := New(monitor.PrometheusConfig{}, nil)
+	b.collector.update(monitor.NewMetric("http").
+		WithCounter("requests", 10).
+		WithFieldMeta("requests", "Requests served.", "").
+		WithHistogram("latency", monitor.Histogram{Count: 1, Sum: 0.2, Buckets: map[float64]uint64{0.5: 1}}).
+		WithFieldMeta("latency", "Request latency.", monitor.UnitSeconds))
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(b.collector)
+	handler := b.metricsHandler(reg)
+
+	get := func(accept string) string {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	text := get("")
+	for _, want := range []string{
+		"# HELP http_requests_total Requests served.",
+		"# TYPE http_requests_total counter",
+		"# HELP http_latency_seconds Request latency.",
+		"# TYPE http_latency_seconds histogram",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text output missing %q:\n%s", want, text)
+		}
+	}
+
+	om := get("application/openmetrics-text; version=1.0.0")
+	for _, want := range []string{
+		"# UNIT http_latency_seconds seconds",
+		"# TYPE http_requests counter",
+		"# EOF",
+	} {
+		if !strings.Contains(om, want) {
+			t.Errorf("OpenMetrics output missing %q:\n%s", want, om)
+		}
+	}
+}
+
+func TestMetricsHandlerConflictingMeta(t *testing.T) {
+	b := New(monitor.PrometheusConfig{}, nil)
+	b.collector.update(monitor.NewMetric("http").
+		WithTag("host", "a").
+		WithCounter("requests", 10).
+		WithFieldMeta("requests", "Requests served.", ""))
+	b.collector.update(monitor.NewMetric("http").
+		WithTag("host", "b").
+		WithField("requests", 20).
+		WithFieldMeta("requests", "Requests handled.", monitor.UnitSeconds))
+	b.collector.update(monitor.NewMetric("http").
+		WithTag("host", "c").
+		WithHistogram("requests", monitor.Histogram{Count: 1, Sum: 1, Buckets: map[float64]uint64{1: 1}}))
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(b.collector)
+	rec := httptest.NewRecorder()
+	b.metricsHandler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200:\n%s", rec.Code, rec.Body)
+	}
+
+	text := rec.Body.String()
+	for _, want := range []string{
+		"# HELP http_requests_total Requests served.",
+		"# TYPE http_requests_total counter",
+		`http_requests_total{host="a"} 10`,
+		`http_requests_total{host="b"} 20`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, `host="c"`) {
+		t.Errorf("histogram of a counter's name should not be exported:\n%s", text)
+	}
+}
+
+func TestMetricsHandlerGzip(t *testing.T) {
+	b := New(monitor.PrometheusConfig{}, nil)
+	b.collector.update(monitor.NewMetric("cpu").WithField("usage", 42.5))
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(b.collector)
+	handler := b.metricsHandler(reg)
+
+	tests := []struct {
+		acceptEncoding string
+		gzipped        bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"br", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding") == "gzip"; got != tt.gzipped {
+			t.Errorf("Accept-Encoding %q: gzipped = %v, want %v", tt.acceptEncoding, got, tt.gzipped)
+			continue
+		}
+		body := io.Reader(rec.Body)
+		if tt.gzipped {
+			gz, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("Accept-Encoding %q: %v", tt.acceptEncoding, err)
+			}
+			body = gz
+		}
+		text, err := io.ReadAll(body)
+		if err != nil || !strings.Contains(string(text), "cpu_usage 42.5") {
+			t.Errorf("Accept-Encoding %q: body = %q, error = %v", tt.acceptEncoding, text, err)
+		}
+	}
+}
+
+func TestMetricsHandlerGatherError(t *testing.T) {
+	b := New(monitor.PrometheusConfig{}, nil)
+	handler := b.metricsHandler(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return nil, errors.New("collector failed")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+}
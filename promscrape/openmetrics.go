@@ -0,0 +1,171 @@
+package promscrape
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// openMetricsToText rewrites an OpenMetrics exposition into the Prometheus
+// text format that expfmt parses, and returns the units declared by UNIT
+// lines, keyed by the rewritten family name. The differences it bridges:
+//
+//   - counter and info families are declared without the _total and _info
+//     suffixes their samples carry
+//   - the unknown, info, stateset and gaugehistogram types
+//   - _created samples, exemplars and the # EOF marker
+//   - timestamps in seconds rather than milliseconds
+//
+// Gauge histograms have no text equivalent; their samples come through as
+// untyped families.
+func openMetricsToText(data []byte) ([]byte, map[string]string, error) {
+	lines := strings.Split(string(data), "\n")
+
+	types := make(map[string]string)
+	for _, line := range lines {
+		if keyword, name, rest := parseComment(line); keyword == "TYPE" {
+			types[name] = rest
+		}
+	}
+
+	var out bytes.Buffer
+	units := make(map[string]string)
+	for i, line := range lines {
+		if line == "# EOF" {
+			break
+		}
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			keyword, name, rest := parseComment(line)
+			family := familyName(name, types[name])
+			switch keyword {
+			case "TYPE":
+				if typ := textType(rest); typ != "" {
+					fmt.Fprintf(&out, "# TYPE %s %s\n", family, typ)
+				}
+			case "HELP":
+				fmt.Fprintf(&out, "# HELP %s %s\n", family, rest)
+			case "UNIT":
+				if rest != "" {
+					units[family] = rest
+				}
+			}
+			continue
+		}
+
+		sample, err := convertSample(line, types)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if sample != "" {
+			out.WriteString(sample)
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes(), units, nil
+}
+
+// parseComment splits a "# KEYWORD name rest" line. It returns an empty
+// keyword for other comments.
+func parseComment(line string) (keyword, name, rest string) {
+	parts := strings.SplitN(strings.TrimPrefix(line, "# "), " ", 3)
+	if len(parts) < 2 || !strings.HasPrefix(line, "# ") {
+		return "", "", ""
+	}
+	keyword, name = parts[0], parts[1]
+	if len(parts) == 3 {
+		rest = parts[2]
+	}
+	switch keyword {
+	case "TYPE", "HELP", "UNIT":
+		return keyword, name, rest
+	}
+	return "", "", ""
+}
+
+func familyName(name, typ string) string {
+	switch typ {
+	case "counter":
+		return name + "_total"
+	case "info":
+		return name + "_info"
+	}
+	return name
+}
+
+func textType(typ string) string {
+	switch typ {
+	case "counter", "gauge", "histogram", "summary":
+		return typ
+	case "info", "stateset":
+		return "gauge"
+	case "unknown":
+		return "untyped"
+	}
+	return ""
+}
+
+// convertSample rewrites one sample line, or returns "" for samples with
+// no text equivalent.
+func convertSample(line string, types map[string]string) (string, error) {
+	end := strings.IndexAny(line, "{ ")
+	if end < 0 {
+		return "", fmt.Errorf("sample without a value: %q", line)
+	}
+	name := line[:end]
+	if base, ok := strings.CutSuffix(name, "_created"); ok {
+		switch types[base] {
+		case "counter", "histogram", "summary", "gaugehistogram":
+			return "", nil
+		}
+	}
+
+	if line[end] == '{' {
+		brace, err := labelsEnd(line, end)
+		if err != nil {
+			return "", err
+		}
+		end = brace + 1
+	}
+	head, rest := line[:end], line[end:]
+	if i := strings.Index(rest, " # "); i >= 0 {
+		rest = rest[:i] // exemplar
+	}
+
+	values := strings.Fields(rest)
+	switch len(values) {
+	case 1:
+		return head + " " + values[0], nil
+	case 2:
+		sec, err := strconv.ParseFloat(values[1], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid timestamp %q", values[1])
+		}
+		ms := int64(math.Round(sec * 1000))
+		return head + " " + values[0] + " " + strconv.FormatInt(ms, 10), nil
+	default:
+		return "", fmt.Errorf("malformed sample: %q", line)
+	}
+}
+
+// labelsEnd returns the index of the brace closing the label set that opens
+// at start, skipping over quoted label values.
+func labelsEnd(line string, start int) (int, error) {
+	quoted := false
+	for i := start + 1; i < len(line); i++ {
+		switch c := line[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == '}':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated label set: %q", line)
+}
@@ -0,0 +1,274 @@
+package promscrape
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+// acceptHeader asks for the Prometheus text format, which is parsed
+// natively, and falls back to OpenMetrics.
+const acceptHeader = "text/plain;version=0.0.4;q=0.9,application/openmetrics-text;version=1.0.0;q=0.8,*/*;q=0.1"
+
+// maxScrapeSize bounds the size of a response body.
+const maxScrapeSize = 32 << 20
+
+// Field is the name of the field holding each sample's value.
+const Field = "value"
+
+// Scraper scrapes Prometheus exposition endpoints.
+type Scraper struct {
+	targets []*target
+	logger  *slog.Logger
+}
+
+type target struct {
+	cfg      monitor.ScrapeTargetConfig
+	instance string
+	timeout  time.Duration
+	client   *http.Client
+}
+
+// New creates a Scraper for the targets in cfg. It returns an error if a
+// target URL is invalid.
+func New(cfg monitor.ScrapeConfig, logger *slog.Logger) (*Scraper, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &Scraper{logger: logger}
+	for i, tc := range cfg.Targets {
+		u, err := url.Parse(tc.URL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("scrape.targets[%d].url: invalid URL %q", i, tc.URL)
+		}
+		t := &target{
+			cfg:      tc,
+			instance: u.Host,
+			timeout:  tc.Timeout.Duration,
+			client: &http.Client{
+				Transport: &http.Transport{
+					Proxy:           http.ProxyFromEnvironment,
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: tc.InsecureSkipVerify},
+				},
+			},
+		}
+		if t.timeout <= 0 {
+			t.timeout = cfg.Timeout.Duration
+		}
+		if t.timeout <= 0 {
+			t.timeout = 10 * time.Second
+		}
+		s.targets = append(s.targets, t)
+	}
+	return s, nil
+}
+
+// Collect scrapes every target concurrently. Each sample becomes a metric
+// named after its family, tagged with its labels, with the sample in a
+// single "value" field of the family's kind: counters and gauges as
+// float64 (untyped samples as gauges), histograms and summaries as
+// monitor.Histogram and monitor.Summary. Family help and OpenMetrics units
+// become field metadata.
+//
+// Every scraped metric is also tagged with "instance" (the target's
+// host:port), "job" for named targets and the target's tags, unless it
+// carries labels of those names. A "prometheus_scrape" metric per target
+// reports whether the scrape succeeded ("up"), how long it took and how
+// many samples it returned; a failing target is logged rather than returned
+// as an error.
+func (s *Scraper) Collect(ctx context.Context) ([]*monitor.Metric, error) {
+	results := make([][]*monitor.Metric, len(s.targets))
+	var wg sync.WaitGroup
+	for i, t := range s.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.collect(ctx, t)
+		}()
+	}
+	wg.Wait()
+
+	var metrics []*monitor.Metric
+	for _, r := range results {
+		metrics = append(metrics, r...)
+	}
+	return metrics, nil
+}
+
+func (s *Scraper) collect(ctx context.Context, t *target) []*monitor.Metric {
+	start := time.Now()
+	metrics, err := t.scrape(ctx)
+	duration := time.Since(start)
+
+	tags := map[string]string{"instance": t.instance}
+	if t.cfg.Name != "" {
+		tags["job"] = t.cfg.Name
+	}
+	for k, v := range t.cfg.Tags {
+		tags[k] = v
+	}
+
+	status := monitor.NewMetric("prometheus_scrape").
+		WithTags(tags).
+		WithGauge("up", err == nil).
+		WithGauge("duration", duration.Seconds()).
+		WithFieldMeta("duration", "", monitor.UnitSeconds).
+		WithGauge("samples", int64(len(metrics)))
+	if err != nil {
+		s.logger.Warn("scrape failed", "url", t.cfg.URL, "error", err)
+		return []*monitor.Metric{status}
+	}
+
+	for _, m := range metrics {
+		for k, v := range tags {
+			if _, ok := m.Tags[k]; !ok {
+				m.WithTag(k, v)
+			}
+		}
+	}
+	return append(metrics, status)
+}
+
+func (t *target) scrape(ctx context.Context) ([]*monitor.Metric, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	for k, v := range t.cfg.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	now := time.Now()
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxScrapeSize))
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScrapeSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxScrapeSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxScrapeSize)
+	}
+
+	return Parse(body, resp.Header.Get("Content-Type"), now)
+}
+
+// Parse converts an exposition in the format named by contentType, either
+// the Prometheus text format or OpenMetrics, into metrics as described for
+// Collect. Samples without a timestamp are given now.
+func Parse(data []byte, contentType string, now time.Time) ([]*monitor.Metric, error) {
+	var units map[string]string
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/openmetrics-text" {
+		var err error
+		if data, units, err = openMetricsToText(data); err != nil {
+			return nil, fmt.Errorf("invalid OpenMetrics exposition: %w", err)
+		}
+	}
+
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var metrics []*monitor.Metric
+	for _, name := range names {
+		metrics = appendFamily(metrics, families[name], monitor.Unit(units[name]), now)
+	}
+	return metrics, nil
+}
+
+func appendFamily(metrics []*monitor.Metric, mf *dto.MetricFamily, unit monitor.Unit, now time.Time) []*monitor.Metric {
+	for _, sample := range mf.GetMetric() {
+		m := monitor.NewMetric(mf.GetName()).WithTimestamp(now)
+		if sample.TimestampMs != nil {
+			m.WithTimestamp(time.UnixMilli(sample.GetTimestampMs()))
+		}
+		for _, l := range sample.GetLabel() {
+			m.WithTag(l.GetName(), l.GetValue())
+		}
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			m.WithCounter(Field, sample.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			m.WithGauge(Field, sample.GetGauge().GetValue())
+		case dto.MetricType_HISTOGRAM:
+			m.WithHistogram(Field, histogram(sample.GetHistogram()))
+		case dto.MetricType_SUMMARY:
+			m.WithSummary(Field, summary(sample.GetSummary()))
+		default:
+			m.WithGauge(Field, sample.GetUntyped().GetValue())
+		}
+		if mf.GetHelp() != "" || unit != "" {
+			m.WithFieldMeta(Field, mf.GetHelp(), unit)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func histogram(h *dto.Histogram) monitor.Histogram {
+	out := monitor.Histogram{
+		Count:   h.GetSampleCount(),
+		Sum:     h.GetSampleSum(),
+		Buckets: make(map[float64]uint64, len(h.GetBucket())),
+	}
+	for _, b := range h.GetBucket() {
+		// The +Inf bucket is implied by Count.
+		if !math.IsInf(b.GetUpperBound(), 1) {
+			out.Buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+	}
+	return out
+}
+
+func summary(s *dto.Summary) monitor.Summary {
+	out := monitor.Summary{
+		Count:     s.GetSampleCount(),
+		Sum:       s.GetSampleSum(),
+		Quantiles: make(map[float64]float64, len(s.GetQuantile())),
+	}
+	for _, q := range s.GetQuantile() {
+		out.Quantiles[q.GetQuantile()] = q.GetValue()
+	}
+	return out
+}
@@ -0,0 +1,248 @@
+package promscrape
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+const textExposition = `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="get"} 1027
+http_requests_total{code="500",method="get"} 3 1700000000000
+# TYPE temperature gauge
+temperature{room="lab"} 21.5
+# A comment.
+plain 7
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.05
+rpc_duration_seconds{quantile="0.99"} 0.2
+rpc_duration_seconds_sum 17.5
+rpc_duration_seconds_count 200
+# TYPE request_size_bytes histogram
+request_size_bytes_bucket{le="100"} 4
+request_size_bytes_bucket{le="1000"} 9
+request_size_bytes_bucket{le="+Inf"} 10
+request_size_bytes_sum 4200
+request_size_bytes_count 10
+`
+
+const openMetricsExposition = `# TYPE build info
+# HELP build Build information.
+build_info{version="1.2.3"} 1
+# TYPE requests counter
+# UNIT requests
+# HELP requests Requests served.
+requests_total{path="/"} 12 # {trace_id="abc"} 1.0 1700000000.5
+requests_created{path="/"} 1700000000
+# TYPE latency_seconds histogram
+# UNIT latency_seconds seconds
+latency_seconds_bucket{le="0.1"} 3
+latency_seconds_bucket{le="+Inf"} 5
+latency_seconds_sum 1.5
+latency_seconds_count 5
+latency_seconds_created 1700000000
+# TYPE mode stateset
+mode{mode="a b}"} 1
+# TYPE queue_depth unknown
+queue_depth 4 1700000000.250
+# EOF
+`
+
+func byName(metrics []*monitor.Metric) map[string][]*monitor.Metric {
+	out := make(map[string][]*monitor.Metric)
+	for _, m := range metrics {
+		out[m.Measurement] = append(out[m.Measurement], m)
+	}
+	return out
+}
+
+func TestParseText(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+	metrics, err := Parse([]byte(textExposition), "text/plain; version=0.0.4", now)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	got := byName(metrics)
+
+	reqs := got["http_requests_total"]
+	if len(reqs) != 2 {
+		t.Fatalf("got %d http_requests_total metrics, want 2", len(reqs))
+	}
+	ok := reqs[0]
+	if ok.Tags["code"] == "500" {
+		ok = reqs[1]
+	}
+	if ok.Fields[Field] != 1027.0 || ok.FieldKind(Field) != monitor.KindCounter {
+		t.Errorf("counter = %v (%v)", ok.Fields[Field], ok.FieldKind(Field))
+	}
+	if ok.Tags["method"] != "get" || !ok.Timestamp.Equal(now) {
+		t.Errorf("tags = %v, timestamp = %v", ok.Tags, ok.Timestamp)
+	}
+	if ok.FieldHelp(Field) != "Requests served." {
+		t.Errorf("help = %q", ok.FieldHelp(Field))
+	}
+	for _, m := range reqs {
+		if m.Tags["code"] == "500" && !m.Timestamp.Equal(time.UnixMilli(1700000000000)) {
+			t.Errorf("explicit timestamp = %v", m.Timestamp)
+		}
+	}
+
+	if temp := got["temperature"]; len(temp) != 1 || temp[0].Fields[Field] != 21.5 || temp[0].FieldKind(Field) != monitor.KindGauge {
+		t.Errorf("temperature = %+v", temp)
+	}
+	if plain := got["plain"]; len(plain) != 1 || plain[0].Fields[Field] != 7.0 {
+		t.Errorf("untyped = %+v", plain)
+	}
+
+	s, ok2 := got["rpc_duration_seconds"][0].Fields[Field].(monitor.Summary)
+	if !ok2 || s.Count != 200 || s.Sum != 17.5 || s.Quantiles[0.99] != 0.2 {
+		t.Errorf("summary = %+v", got["rpc_duration_seconds"][0].Fields[Field])
+	}
+
+	h, ok2 := got["request_size_bytes"][0].Fields[Field].(monitor.Histogram)
+	if !ok2 || h.Count != 10 || h.Sum != 4200 {
+		t.Fatalf("histogram = %+v", got["request_size_bytes"][0].Fields[Field])
+	}
+	if len(h.Buckets) != 2 || h.Buckets[100] != 4 || h.Buckets[1000] != 9 {
+		t.Errorf("buckets = %v, want 100:4 1000:9 without +Inf", h.Buckets)
+	}
+	for _, m := range metrics {
+		if err := m.Validate(); err != nil {
+			t.Errorf("%s: %v", m.Measurement, err)
+		}
+	}
+}
+
+func TestParseOpenMetrics(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+	metrics, err := Parse([]byte(openMetricsExposition), "application/openmetrics-text; version=1.0.0; charset=utf-8", now)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	got := byName(metrics)
+
+	if len(metrics) != 5 {
+		t.Errorf("got %d metrics, want 5 (no _created samples): %v", len(metrics), got)
+	}
+	if b := got["build_info"]; len(b) != 1 || b[0].Tags["version"] != "1.2.3" || b[0].FieldHelp(Field) != "Build information." {
+		t.Errorf("build_info = %+v", b)
+	}
+
+	reqs := got["requests_total"]
+	if len(reqs) != 1 || reqs[0].Fields[Field] != 12.0 || reqs[0].FieldKind(Field) != monitor.KindCounter {
+		t.Fatalf("requests_total = %+v", reqs)
+	}
+	if reqs[0].FieldHelp(Field) != "Requests served." {
+		t.Errorf("help = %q", reqs[0].FieldHelp(Field))
+	}
+
+	lat := got["latency_seconds"]
+	if len(lat) != 1 || lat[0].FieldUnit(Field) != monitor.UnitSeconds {
+		t.Fatalf("latency_seconds = %+v", lat)
+	}
+	if h := lat[0].Fields[Field].(monitor.Histogram); h.Count != 5 || h.Buckets[0.1] != 3 {
+		t.Errorf("histogram = %+v", h)
+	}
+
+	if mode := got["mode"]; len(mode) != 1 || mode[0].Tags["mode"] != "a b}" {
+		t.Errorf("stateset = %+v", mode)
+	}
+	depth := got["queue_depth"]
+	if len(depth) != 1 || !depth[0].Timestamp.Equal(time.UnixMilli(1700000000250)) {
+		t.Errorf("queue_depth = %+v", depth)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse([]byte("metric{a=\"b\" 1\n"), "text/plain", time.Now()); err == nil {
+		t.Error("expected error for malformed text")
+	}
+	if _, err := Parse([]byte("metric 1 notatime\n# EOF\n"), "application/openmetrics-text", time.Now()); err == nil {
+		t.Error("expected error for malformed OpenMetrics timestamp")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte("# TYPE up_seconds gauge\nup_seconds{instance=\"self\"} 5\nother 1\n"))
+	}))
+	defer srv.Close()
+	host := func(s string) string {
+		u, _ := url.Parse(s)
+		return u.Host
+	}(srv.URL)
+
+	s, err := New(monitor.ScrapeConfig{
+		Timeout: monitor.Duration{Duration: 2 * time.Second},
+		Targets: []monitor.ScrapeTargetConfig{
+			{
+				Name:    "app",
+				URL:     srv.URL + "/metrics",
+				Headers: map[string]string{"Authorization": "Bearer secret"},
+				Tags:    map[string]string{"env": "test"},
+			},
+			{URL: srv.URL + "/metrics"}, // unauthorized
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	metrics, err := s.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	got := byName(metrics)
+
+	other := got["other"]
+	if len(other) != 1 {
+		t.Fatalf("got %d other metrics, want 1", len(other))
+	}
+	want := map[string]string{"job": "app", "instance": host, "env": "test"}
+	for k, v := range want {
+		if other[0].Tags[k] != v {
+			t.Errorf("tag %s = %q, want %q", k, other[0].Tags[k], v)
+		}
+	}
+	if got["up_seconds"][0].Tags["instance"] != "self" {
+		t.Error("scraped labels should take precedence over target tags")
+	}
+
+	status := got["prometheus_scrape"]
+	if len(status) != 2 {
+		t.Fatalf("got %d prometheus_scrape metrics, want 2", len(status))
+	}
+	for _, m := range status {
+		wantUp := m.Tags["job"] == "app"
+		if m.Fields["up"] != wantUp {
+			t.Errorf("job %q: up = %v, want %v", m.Tags["job"], m.Fields["up"], wantUp)
+		}
+		wantSamples := int64(0)
+		if wantUp {
+			wantSamples = 2
+		}
+		if m.Fields["samples"] != wantSamples {
+			t.Errorf("job %q: samples = %v, want %d", m.Tags["job"], m.Fields["samples"], wantSamples)
+		}
+		if _, ok := m.Fields["duration"].(float64); !ok {
+			t.Error("missing duration")
+		}
+	}
+}
+
+func TestNewInvalidURL(t *testing.T) {
+	if _, err := New(monitor.ScrapeConfig{Targets: []monitor.ScrapeTargetConfig{{URL: "not a url"}}}, nil); err == nil {
+		t.Fatal("expected error for invalid URL")
+	}
+}
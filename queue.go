@@ -0,0 +1,392 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// deliveryTimeout bounds writing a single batch, including retries, and a
+// probe. Each batch replayed from the spool gets its own deadline, so a long
+// backlog is not cut short.
+const deliveryTimeout = 30 * time.Second
+
+// BackendStats holds delivery statistics for a single backend.
+type BackendStats struct {
+	Name           string
+	Healthy        bool
+	State          BreakerState
+	QueueLen       int
+	SpoolLen       int
+	DeliveredBatch int64
+	FailedBatch    int64
+	DroppedBatch   int64
+	PanickedBatch  int64
+	RejectedBatch  int64
+	Retries        int64
+
+	// InvalidMetrics counts metrics the backend's own processors left
+	// invalid, which are dropped rather than written.
+	InvalidMetrics int64
+
+	// LastWriteLatency is how long the last successful write took,
+	// including retries.
+	LastWriteLatency time.Duration
+}
+
+// delivery is a unit of work for a backend worker. A nil done channel marks
+// an asynchronous delivery; otherwise the result is sent on done.
+type delivery struct {
+	ctx   context.Context
+	batch []*Metric
+	done  chan error
+}
+
+// backendQueue owns delivery to a single backend: a bounded queue of batches
+// drained by a dedicated worker goroutine, so a slow or failing backend only
+// ever delays its own queue.
+type backendQueue struct {
+	backend Backend
+	spool   *spool
+	breaker *circuitBreaker
+	ch      chan delivery
+	logger  *slog.Logger
+
+	// tripOnPanic opens the breaker when the backend panics.
+	tripOnPanic bool
+
+	mu    sync.Mutex
+	stats BackendStats
+}
+
+func newBackendQueue(b Backend, size int, breaker *circuitBreaker, logger *slog.Logger) *backendQueue {
+	return &backendQueue{
+		backend: b,
+		breaker: breaker,
+		ch:      make(chan delivery, size),
+		logger:  logger,
+	}
+}
+
+// offer enqueues a batch without blocking. When the queue is full the batch
+// is spooled if possible and dropped otherwise.
+func (q *backendQueue) offer(batch []*Metric) {
+	select {
+	case q.ch <- delivery{batch: batch}:
+	default:
+		q.logger.Warn("backend queue full", "backend", q.backend.Name(), "count", len(batch))
+		if !q.spoolBatch(batch) {
+			q.recordDropped()
+		}
+	}
+}
+
+// run drains the queue until it is closed. Asynchronous deliveries are
+// cancelled with stopCtx. While the breaker is open the worker also wakes
+// when the cooldown elapses to probe the backend or replay its spool, so it
+// recovers even if no new batches arrive. A backend with neither waits for
+// the next batch, which is its trial write.
+func (q *backendQueue) run(stopCtx context.Context, p *Pipeline) {
+	_, probes := asProber(q.backend)
+	for {
+		var timer *time.Timer
+		var probe <-chan time.Time
+		if wait, ok := q.breaker.retryIn(time.Now()); ok && (probes || q.hasBacklog()) {
+			timer = time.NewTimer(wait)
+			probe = timer.C
+		}
+
+		var d delivery
+		var open bool
+		select {
+		case d, open = <-q.ch:
+		case <-probe:
+			open = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if !open {
+			return
+		}
+
+		ctx := d.ctx
+		if ctx == nil {
+			ctx = stopCtx
+		}
+
+		err := q.deliver(ctx, p, d.batch)
+
+		if err != nil {
+			q.logger.Error("backend write failed", "backend", q.backend.Name(), "error", err)
+		}
+		if d.done != nil {
+			d.done <- err
+		}
+	}
+}
+
+// deliver writes a batch to the backend, replaying any spooled backlog first.
+// Batches that cannot be delivered are spooled when a spool is configured and
+// dropped otherwise. The circuit breaker decides whether the backend is
+// written to at all.
+func (q *backendQueue) deliver(ctx context.Context, p *Pipeline, batch []*Metric) error {
+	b := q.backend
+
+	switch q.breaker.current(time.Now()) {
+	case BreakerOpen:
+		q.hold(batch)
+		return nil
+
+	case BreakerHalfOpen:
+		if prober, ok := asProber(b); ok {
+			pctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+			err := safeProbe(pctx, prober)
+			cancel()
+			if err != nil {
+				if pe, ok := asPanic(err); ok {
+					q.logPanic("probe", pe)
+				}
+				q.breaker.failure(time.Now())
+				q.hold(batch)
+				return fmt.Errorf("probe failed: %w", err)
+			}
+			q.breaker.success()
+			q.logger.Info("backend recovered, circuit closed", "backend", b.Name())
+		}
+		// Without a prober the spool replay or the delivery below is the
+		// trial write.
+
+	case BreakerClosed:
+		if !b.Healthy() {
+			// Skipping an unhealthy backend counts as a failed delivery.
+			q.logger.Warn("backend unhealthy, skipping batch", "backend", b.Name())
+			q.recordBreakerFailure()
+			q.hold(batch)
+			return nil
+		}
+	}
+
+	if q.spool != nil && q.spool.Len() > 0 {
+		replayed, err := q.spool.Replay(func(spooled []*Metric) error {
+			err := q.write(ctx, p, spooled)
+			if pe, ok := asPanic(err); ok {
+				q.panicked(spooled, pe)
+				return nil
+			}
+			switch {
+			case err == nil:
+				q.recordDelivered(1)
+			case !IsRetryable(err):
+				q.reject(spooled, err)
+				return nil
+			}
+			return err
+		})
+		if replayed > 0 {
+			q.logger.Info("replayed spooled batches", "backend", b.Name(), "batches", replayed)
+		}
+		if err != nil {
+			// Keep the new batch behind the backlog to preserve ordering.
+			q.spoolBatch(batch)
+			if ctx.Err() != nil {
+				// The delivery was cancelled, not refused by the backend:
+				// the rest of the backlog waits for the next wakeup.
+				if replayed > 0 {
+					q.recordBreakerSuccess()
+				}
+				return fmt.Errorf("spool replay interrupted: %w", err)
+			}
+			q.recordFailed()
+			q.recordBreakerFailure()
+			return fmt.Errorf("spool replay failed: %w", err)
+		}
+		if replayed > 0 {
+			q.recordBreakerSuccess()
+		}
+	}
+
+	if len(batch) == 0 {
+		if q.breaker.peek() == BreakerHalfOpen {
+			// Nothing was written, so the backend is still untested: wait
+			// another cooldown instead of sitting half-open.
+			q.breaker.trip(time.Now())
+		}
+		return nil
+	}
+
+	start := time.Now()
+	if err := q.write(ctx, p, batch); err != nil {
+		if pe, ok := asPanic(err); ok {
+			q.panicked(batch, pe)
+			return err
+		}
+		if !IsRetryable(err) {
+			// The backend answered; it just refused this batch.
+			q.recordBreakerSuccess()
+			q.reject(batch, err)
+			return err
+		}
+		q.spoolBatch(batch)
+		q.recordFailed()
+		q.recordBreakerFailure()
+		return err
+	}
+	q.recordDelivered(1)
+	q.recordLatency(time.Since(start))
+	q.recordBreakerSuccess()
+	return nil
+}
+
+// write writes one batch with retries, bounded by deliveryTimeout.
+func (q *backendQueue) write(ctx context.Context, p *Pipeline, batch []*Metric) error {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	return p.writeWithRetry(ctx, q, batch)
+}
+
+// hold keeps a batch the backend cannot take right now: spooled when
+// possible, dropped otherwise.
+func (q *backendQueue) hold(batch []*Metric) {
+	if len(batch) == 0 {
+		return
+	}
+	if !q.spoolBatch(batch) {
+		q.logger.Warn("circuit open, dropping batch", "backend", q.backend.Name(), "count", len(batch))
+		q.recordDropped()
+	}
+}
+
+func (q *backendQueue) recordBreakerSuccess() {
+	if q.breaker.peek() != BreakerClosed {
+		q.logger.Info("backend recovered, circuit closed", "backend", q.backend.Name())
+	}
+	q.breaker.success()
+}
+
+func (q *backendQueue) recordBreakerFailure() {
+	if q.breaker.failure(time.Now()) {
+		q.logger.Warn("circuit opened", "backend", q.backend.Name(), "cooldown", q.breaker.cooldown)
+	}
+}
+
+// spoolBatch appends a batch to the spool, reporting whether it was kept.
+func (q *backendQueue) spoolBatch(batch []*Metric) bool {
+	if q.spool == nil || len(batch) == 0 {
+		return false
+	}
+	if err := q.spool.Append(batch); err != nil {
+		q.logger.Error("failed to spool batch, metrics lost",
+			"backend", q.backend.Name(),
+			"count", len(batch),
+			"error", err,
+		)
+		return false
+	}
+	q.logger.Warn("spooled undelivered batch", "backend", q.backend.Name(), "count", len(batch))
+	return true
+}
+
+// reject drops a batch the backend refused with a permanent error; spooling
+// it would only replay the same failure.
+func (q *backendQueue) reject(batch []*Metric, err error) {
+	q.logger.Error("backend rejected batch, dropping",
+		"backend", q.backend.Name(),
+		"count", len(batch),
+		"error", err,
+	)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.RejectedBatch++
+}
+
+// panicked drops a batch whose write panicked. Like a rejected batch it is
+// not spooled, since replaying it would likely panic again. The breaker is
+// opened when tripOnPanic is set.
+func (q *backendQueue) panicked(batch []*Metric, pe *PanicError) {
+	q.logPanic("write", pe)
+	q.mu.Lock()
+	q.stats.FailedBatch++
+	q.stats.PanickedBatch++
+	q.mu.Unlock()
+
+	if q.tripOnPanic {
+		q.breaker.trip(time.Now())
+		q.logger.Warn("backend panicked, circuit opened", "backend", q.backend.Name())
+	}
+}
+
+func (q *backendQueue) logPanic(op string, pe *PanicError) {
+	q.logger.Error("backend panicked",
+		"backend", q.backend.Name(),
+		"op", op,
+		"panic", pe.Value,
+		"stack", string(pe.Stack),
+	)
+}
+
+func (q *backendQueue) hasBacklog() bool {
+	return q.spool != nil && q.spool.Len() > 0
+}
+
+// safeWrite calls b.Write, returning a *PanicError if it panics.
+func safeWrite(ctx context.Context, b Backend, metrics []*Metric) (err error) {
+	defer recoverPanic(&err)
+	return b.Write(ctx, metrics)
+}
+
+// safeProbe calls p.Probe, returning a *PanicError if it panics.
+func safeProbe(ctx context.Context, p Prober) (err error) {
+	defer recoverPanic(&err)
+	return p.Probe(ctx)
+}
+
+func (q *backendQueue) recordDelivered(n int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.DeliveredBatch += n
+}
+
+func (q *backendQueue) recordLatency(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.LastWriteLatency = d
+}
+
+func (q *backendQueue) recordRetry() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.Retries++
+}
+
+func (q *backendQueue) recordFailed() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.FailedBatch++
+}
+
+func (q *backendQueue) recordDropped() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.DroppedBatch++
+}
+
+func (q *backendQueue) snapshot() BackendStats {
+	q.mu.Lock()
+	stats := q.stats
+	q.mu.Unlock()
+
+	stats.Name = q.backend.Name()
+	stats.Healthy = q.backend.Healthy()
+	stats.State = q.breaker.peek()
+	stats.QueueLen = len(q.ch)
+	if q.spool != nil {
+		stats.SpoolLen = q.spool.Len()
+	}
+	if c, ok := unwrapBackend[interface{ invalidMetrics() int64 }](q.backend); ok {
+		stats.InvalidMetrics = c.invalidMetrics()
+	}
+	return stats
+}
@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBackendQueueOfferFull(t *testing.T) {
+	q := newBackendQueue(&mockBackend{name: "test", healthy: true}, 1, newCircuitBreaker(3, time.Minute), slog.Default())
+
+	q.offer([]*Metric{NewMetric("a").WithField("v", 1)})
+	q.offer([]*Metric{NewMetric("b").WithField("v", 2)})
+
+	stats := q.snapshot()
+	if stats.QueueLen != 1 {
+		t.Errorf("QueueLen = %d, want 1", stats.QueueLen)
+	}
+	if stats.DroppedBatch != 1 {
+		t.Errorf("DroppedBatch = %d, want 1", stats.DroppedBatch)
+	}
+}
+
+func TestBackendQueueOfferFullSpools(t *testing.T) {
+	q := newBackendQueue(&mockBackend{name: "test", healthy: true}, 1, newCircuitBreaker(3, time.Minute), slog.Default())
+	sp, err := openSpool(t.TempDir(), 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+	q.spool = sp
+
+	q.offer([]*Metric{NewMetric("a").WithField("v", 1)})
+	q.offer([]*Metric{NewMetric("b").WithField("v", 2)})
+
+	stats := q.snapshot()
+	if stats.DroppedBatch != 0 {
+		t.Errorf("DroppedBatch = %d, want 0", stats.DroppedBatch)
+	}
+	if stats.SpoolLen != 1 {
+		t.Errorf("SpoolLen = %d, want 1", stats.SpoolLen)
+	}
+}
+
+func TestBackendQueueDeliverFailure(t *testing.T) {
+	backend := &mockBackend{name: "test", healthy: true, writeErr: fmt.Errorf("write failed")}
+	q := newBackendQueue(backend, 1, newCircuitBreaker(3, time.Minute), slog.Default())
+	p := NewPipeline(PipelineConfig{RetryAttempts: 1})
+
+	err := q.deliver(context.Background(), p, []*Metric{NewMetric("a").WithField("v", 1)})
+	if err == nil {
+		t.Error("deliver() should return the write error")
+	}
+
+	stats := q.snapshot()
+	if stats.FailedBatch != 1 {
+		t.Errorf("FailedBatch = %d, want 1", stats.FailedBatch)
+	}
+	if stats.DroppedBatch != 0 {
+		t.Errorf("DroppedBatch = %d, want 0", stats.DroppedBatch)
+	}
+}
@@ -0,0 +1,294 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spoolSegmentExt = ".seg"
+	spoolTempExt    = ".tmp"
+)
+
+func init() {
+	// Distribution field values travel through interface{} fields.
+	gob.Register(Histogram{})
+	gob.Register(Summary{})
+}
+
+// spool persists undelivered batches for a single backend as segment files
+// in a directory. Each segment holds exactly one batch; segments are named by
+// a monotonically increasing sequence number so they replay in write order.
+type spool struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	segments []spoolSegment // oldest first
+	size     int64
+	nextSeq  uint64
+}
+
+type spoolSegment struct {
+	seq     uint64
+	path    string
+	size    int64
+	created time.Time
+}
+
+// openSpool opens (or creates) the spool directory and indexes any segments
+// left behind by a previous run.
+func openSpool(dir string, maxSize int64, maxAge time.Duration, logger *slog.Logger) (*spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	s := &spool{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		logger:  logger,
+		nextSeq: 1,
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, spoolTempExt) {
+			// A segment whose write was interrupted before it was committed.
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				logger.Warn("failed to remove stale spool file", "file", name, "error", err)
+			}
+			continue
+		}
+		if entry.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, spoolSegment{
+			seq:     seq,
+			path:    filepath.Join(dir, name),
+			size:    info.Size(),
+			created: info.ModTime(),
+		})
+		s.size += info.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+
+	s.mu.Lock()
+	s.enforceLimitsLocked(time.Now())
+	s.mu.Unlock()
+
+	return s, nil
+}
+
+// Append writes a batch to a new segment at the tail of the spool.
+func (s *spool) Append(batch []*Metric) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(batch); err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seq := s.nextSeq
+	s.nextSeq++
+
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentExt))
+	tmp := path + spoolTempExt
+	if err := writeFileSync(tmp, buf.Bytes()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write spool segment: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to commit spool segment: %w", err)
+	}
+	// Make the rename itself durable.
+	if err := syncDir(s.dir); err != nil {
+		return fmt.Errorf("failed to commit spool segment: %w", err)
+	}
+
+	s.segments = append(s.segments, spoolSegment{
+		seq:     seq,
+		path:    path,
+		size:    int64(buf.Len()),
+		created: time.Now(),
+	})
+	s.size += int64(buf.Len())
+	s.enforceLimitsLocked(time.Now())
+
+	return nil
+}
+
+// Replay hands spooled batches to fn oldest first, removing each segment once
+// fn succeeds. It stops at the first error, leaving that segment in place.
+// Returns the number of batches replayed.
+func (s *spool) Replay(fn func(batch []*Metric) error) (int, error) {
+	replayed := 0
+	for {
+		s.mu.Lock()
+		s.enforceLimitsLocked(time.Now())
+		if len(s.segments) == 0 {
+			s.mu.Unlock()
+			return replayed, nil
+		}
+		seg := s.segments[0]
+		s.mu.Unlock()
+
+		batch, err := readSpoolSegment(seg.path)
+		if err != nil {
+			s.logger.Error("discarding unreadable spool segment", "path", seg.path, "error", err)
+			s.remove(seg.seq)
+			continue
+		}
+
+		if err := fn(batch); err != nil {
+			return replayed, err
+		}
+
+		s.remove(seg.seq)
+		replayed++
+	}
+}
+
+// Len returns the number of spooled batches.
+func (s *spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.segments)
+}
+
+// Size returns the total size of spooled segments in bytes.
+func (s *spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func (s *spool) remove(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, seg := range s.segments {
+		if seg.seq == seq {
+			s.removeLocked(i)
+			return
+		}
+	}
+}
+
+func (s *spool) removeLocked(i int) {
+	seg := s.segments[i]
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		s.logger.Error("failed to remove spool segment", "path", seg.path, "error", err)
+	}
+	s.size -= seg.size
+	s.segments = append(s.segments[:i], s.segments[i+1:]...)
+}
+
+// enforceLimitsLocked drops the oldest segments until the spool is within its
+// size and age caps. The caller must hold s.mu.
+func (s *spool) enforceLimitsLocked(now time.Time) {
+	dropped := 0
+	for len(s.segments) > 0 {
+		oldest := s.segments[0]
+		tooOld := s.maxAge > 0 && now.Sub(oldest.created) > s.maxAge
+		tooBig := s.maxSize > 0 && s.size > s.maxSize
+		if !tooOld && !tooBig {
+			break
+		}
+		s.removeLocked(0)
+		dropped++
+	}
+	if dropped > 0 {
+		s.logger.Warn("spool limits exceeded, dropped oldest batches",
+			"dir", s.dir,
+			"dropped", dropped,
+		)
+	}
+}
+
+func readSpoolSegment(path string) ([]*Metric, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch []*Metric
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&batch); err != nil {
+		return nil, err
+	}
+
+	// gob omits empty maps; restore them so the builder methods keep working.
+	for _, m := range batch {
+		if m.Tags == nil {
+			m.Tags = make(map[string]string)
+		}
+		if m.Fields == nil {
+			m.Fields = make(map[string]interface{})
+		}
+	}
+	return batch, nil
+}
+
+// writeFileSync writes data to a new file and flushes it to stable storage
+// before closing it.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes a directory's entries, such as a rename into it, to
+// stable storage.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,206 @@
+package monitor
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSpoolAppendReplay(t *testing.T) {
+	dir := t.TempDir()
+	sp, err := openSpool(dir, 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		batch := []*Metric{
+			NewMetric("cpu").WithTag("host", "a").WithField("seq", i).WithTimestamp(ts),
+		}
+		if err := sp.Append(batch); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	if sp.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", sp.Len())
+	}
+	if sp.Size() <= 0 {
+		t.Errorf("Size() = %d, want > 0", sp.Size())
+	}
+
+	var seen []int
+	n, err := sp.Replay(func(batch []*Metric) error {
+		seen = append(seen, batch[0].Fields["seq"].(int))
+		if batch[0].Tags["host"] != "a" {
+			t.Errorf("Tags[host] = %q, want %q", batch[0].Tags["host"], "a")
+		}
+		if !batch[0].Timestamp.Equal(ts) {
+			t.Errorf("Timestamp = %v, want %v", batch[0].Timestamp, ts)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay() error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Replay() = %d, want 3", n)
+	}
+	for i, v := range seen {
+		if v != i {
+			t.Errorf("replay order = %v, want ascending", seen)
+			break
+		}
+	}
+	if sp.Len() != 0 || sp.Size() != 0 {
+		t.Errorf("spool should be empty after replay, Len=%d Size=%d", sp.Len(), sp.Size())
+	}
+}
+
+func TestSpoolReplayStopsOnError(t *testing.T) {
+	sp, err := openSpool(t.TempDir(), 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+
+	sp.Append([]*Metric{NewMetric("a").WithField("v", 1)})
+	sp.Append([]*Metric{NewMetric("b").WithField("v", 2)})
+
+	calls := 0
+	n, err := sp.Replay(func(batch []*Metric) error {
+		calls++
+		return fmt.Errorf("backend down")
+	})
+	if err == nil {
+		t.Error("Replay() should return the callback error")
+	}
+	if n != 0 || calls != 1 {
+		t.Errorf("Replay() = %d after %d calls, want 0 after 1", n, calls)
+	}
+	if sp.Len() != 2 {
+		t.Errorf("Len() = %d, want 2 (nothing removed)", sp.Len())
+	}
+}
+
+func TestSpoolReopen(t *testing.T) {
+	dir := t.TempDir()
+	sp, err := openSpool(dir, 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+	sp.Append([]*Metric{NewMetric("first").WithField("v", 1)})
+	sp.Append([]*Metric{NewMetric("second").WithField("v", 2)})
+
+	reopened, err := openSpool(dir, 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() reopen error: %v", err)
+	}
+	if reopened.Len() != 2 {
+		t.Fatalf("Len() after reopen = %d, want 2", reopened.Len())
+	}
+
+	reopened.Append([]*Metric{NewMetric("third").WithField("v", 3)})
+
+	var order []string
+	reopened.Replay(func(batch []*Metric) error {
+		order = append(order, batch[0].Measurement)
+		return nil
+	})
+	want := []string{"first", "second", "third"}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("replay order = %v, want %v", order, want)
+	}
+}
+
+func TestSpoolMaxSize(t *testing.T) {
+	sp, err := openSpool(t.TempDir(), 1, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+
+	sp.Append([]*Metric{NewMetric("a").WithField("v", 1)})
+
+	if sp.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 (segment exceeds max size)", sp.Len())
+	}
+}
+
+func TestSpoolMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	sp, err := openSpool(dir, 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+	sp.Append([]*Metric{NewMetric("a").WithField("v", 1)})
+
+	// Age the segment on disk, then reopen with an age cap.
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(sp.segments[0].path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := openSpool(dir, 0, time.Hour, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() reopen error: %v", err)
+	}
+	if reopened.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 (segment older than max age)", reopened.Len())
+	}
+}
+
+func TestSpoolDiscardsCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000001.seg"), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err := openSpool(dir, 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+	sp.Append([]*Metric{NewMetric("good").WithField("v", 1)})
+
+	var got []string
+	n, err := sp.Replay(func(batch []*Metric) error {
+		got = append(got, batch[0].Measurement)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay() error: %v", err)
+	}
+	if n != 1 || len(got) != 1 || got[0] != "good" {
+		t.Errorf("Replay() = %d %v, want 1 [good]", n, got)
+	}
+}
+
+func TestSpoolRemovesStaleTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	sp, err := openSpool(dir, 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+	if err := sp.Append([]*Metric{NewMetric("cpu").WithField("v", 1)}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	// A write interrupted by a crash leaves its temporary file behind.
+	stale := filepath.Join(dir, fmt.Sprintf("%020d%s%s", 99, spoolSegmentExt, spoolTempExt))
+	if err := os.WriteFile(stale, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err = openSpool(dir, 0, 0, slog.Default())
+	if err != nil {
+		t.Fatalf("openSpool() error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale temporary file should be removed, stat error = %v", err)
+	}
+	if sp.Len() != 1 {
+		t.Errorf("Len() = %d, want the committed segment kept", sp.Len())
+	}
+}
@@ -10,8 +10,19 @@ type PollStats struct {
 	TotalPolls      int64
 	SuccessfulPolls int64
 	FailedPolls     int64
+	SkippedPolls    int64
+	PanickedPolls   int64
 	TotalMetrics    int64
 	LastDuration    time.Duration
+
+	// EmittedMetrics counts metrics submitted through the Emitter rather
+	// than returned by a collection. It is only set on Monitor.Stats.
+	EmittedMetrics int64
+
+	// ConsecutiveFailures counts failed polls since the last successful one.
+	ConsecutiveFailures int64
+	LastSuccess         time.Time
+	LastError           string
 }
 
 // statsTracker provides thread-safe poll statistics tracking.
@@ -27,12 +38,45 @@ func (s *statsTracker) recordPoll(success bool, metricCount int, duration time.D
 	if success {
 		s.stats.SuccessfulPolls++
 		s.stats.TotalMetrics += int64(metricCount)
+		s.stats.ConsecutiveFailures = 0
+		s.stats.LastSuccess = time.Now()
 	} else {
 		s.stats.FailedPolls++
+		s.stats.ConsecutiveFailures++
 	}
 	s.stats.LastDuration = duration
 }
 
+// recordError keeps the message of the most recent collection error.
+func (s *statsTracker) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.LastError = err.Error()
+}
+
+// recordSkipped counts a poll that did not run because the previous one was
+// still in progress (or was superseded by a newer one).
+func (s *statsTracker) recordSkipped() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.SkippedPolls++
+}
+
+// recordEmitted counts a metric submitted through the Emitter.
+func (s *statsTracker) recordEmitted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.EmittedMetrics++
+}
+
+// recordPanic counts a poll whose CollectFunc panicked. The poll is also
+// recorded as failed.
+func (s *statsTracker) recordPanic() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.PanickedPolls++
+}
+
 func (s *statsTracker) snapshot() PollStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -48,3 +49,31 @@ func TestStatsTracker(t *testing.T) {
 		t.Errorf("LastDuration = %v, want 50ms", stats.LastDuration)
 	}
 }
+
+func TestStatsTrackerConsecutiveFailures(t *testing.T) {
+	var s statsTracker
+
+	s.recordPoll(false, 0, time.Millisecond)
+	s.recordError(fmt.Errorf("connection refused"))
+	s.recordPoll(false, 0, time.Millisecond)
+
+	stats := s.snapshot()
+	if stats.ConsecutiveFailures != 2 {
+		t.Errorf("ConsecutiveFailures = %d, want 2", stats.ConsecutiveFailures)
+	}
+	if stats.LastError != "connection refused" {
+		t.Errorf("LastError = %q, want %q", stats.LastError, "connection refused")
+	}
+	if !stats.LastSuccess.IsZero() {
+		t.Errorf("LastSuccess = %v, want zero before any success", stats.LastSuccess)
+	}
+
+	s.recordPoll(true, 1, time.Millisecond)
+	stats = s.snapshot()
+	if stats.ConsecutiveFailures != 0 {
+		t.Errorf("ConsecutiveFailures = %d, want 0 after a success", stats.ConsecutiveFailures)
+	}
+	if stats.LastSuccess.IsZero() {
+		t.Error("LastSuccess should be set after a success")
+	}
+}
@@ -0,0 +1,222 @@
+package statsd
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// metricTypes maps each StatsD type to the metric_type tag reported for it.
+var metricTypes = map[string]string{
+	typeCounter:      "counter",
+	typeGauge:        "gauge",
+	typeTiming:       "timing",
+	typeHistogram:    "histogram",
+	typeDistribution: "distribution",
+	typeSet:          "set",
+}
+
+// series accumulates the samples of one name, type and tag set between
+// flushes.
+type series struct {
+	name    string
+	typ     string
+	tags    map[string]string
+	updated bool
+
+	// Counters and gauges.
+	value float64
+
+	// Timers, histograms and distributions. count is weighted by the
+	// sample rate; n, sum and sumSq cover the values actually received.
+	count  float64
+	n      int
+	sum    float64
+	sumSq  float64
+	min    float64
+	max    float64
+	values []float64
+
+	// Sets.
+	members map[string]struct{}
+}
+
+// aggregator folds samples into series and turns them into metrics at each
+// flush. It is not safe for concurrent use.
+type aggregator struct {
+	percentiles  []float64
+	maxSamples   int
+	deleteGauges bool
+	series       map[string]*series
+	lastFlush    time.Time
+}
+
+func newAggregator(cfg monitor.StatsDConfig, now time.Time) *aggregator {
+	return &aggregator{
+		percentiles:  cfg.Percentiles,
+		maxSamples:   cfg.MaxSamples,
+		deleteGauges: cfg.DeleteGauges,
+		series:       make(map[string]*series),
+		lastFlush:    now,
+	}
+}
+
+func seriesKey(s sample) string {
+	var sb strings.Builder
+	sb.WriteString(s.typ)
+	sb.WriteByte('|')
+	sb.WriteString(s.name)
+
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteByte('|')
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(s.tags[k])
+	}
+	return sb.String()
+}
+
+func (a *aggregator) add(s sample) {
+	// Timers and histograms are the same thing under two names.
+	if s.typ == typeHistogram {
+		s.typ = typeTiming
+	}
+	key := seriesKey(s)
+	ser, ok := a.series[key]
+	if !ok {
+		ser = &series{name: s.name, typ: s.typ, tags: s.tags}
+		a.series[key] = ser
+	}
+	ser.updated = true
+
+	switch s.typ {
+	case typeCounter:
+		ser.value += s.value / s.rate
+	case typeGauge:
+		if s.delta {
+			ser.value += s.value
+		} else {
+			ser.value = s.value
+		}
+	case typeSet:
+		if ser.members == nil {
+			ser.members = make(map[string]struct{})
+		}
+		ser.members[s.member] = struct{}{}
+	default:
+		a.addValue(ser, s.value, s.rate)
+	}
+}
+
+func (a *aggregator) addValue(ser *series, v, rate float64) {
+	if ser.n == 0 || v < ser.min {
+		ser.min = v
+	}
+	if ser.n == 0 || v > ser.max {
+		ser.max = v
+	}
+	ser.count += 1 / rate
+	ser.n++
+	ser.sum += v
+	ser.sumSq += v * v
+
+	// Beyond maxSamples, keep a uniform random sample of the values.
+	switch {
+	case a.maxSamples <= 0 || len(ser.values) < a.maxSamples:
+		ser.values = append(ser.values, v)
+	default:
+		if i := rand.IntN(ser.n); i < a.maxSamples {
+			ser.values[i] = v
+		}
+	}
+}
+
+// flush returns a metric for every series updated since the last flush (and
+// every retained gauge), timestamped now, and resets the interval.
+func (a *aggregator) flush(now time.Time) []*monitor.Metric {
+	interval := now.Sub(a.lastFlush).Seconds()
+	a.lastFlush = now
+
+	keys := make([]string, 0, len(a.series))
+	for k := range a.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var metrics []*monitor.Metric
+	for _, key := range keys {
+		ser := a.series[key]
+		// Only gauges outlive the interval they were updated in.
+		if !ser.updated && a.deleteGauges {
+			delete(a.series, key)
+			continue
+		}
+
+		m := monitor.NewMetric(ser.name).
+			WithTags(ser.tags).
+			WithTag("metric_type", metricTypes[ser.typ]).
+			WithTimestamp(now)
+
+		switch ser.typ {
+		case typeCounter:
+			m.WithGauge("count", ser.value)
+			if interval > 0 {
+				m.WithGauge("rate", ser.value/interval)
+			}
+		case typeGauge:
+			m.WithGauge("value", ser.value)
+		case typeSet:
+			m.WithGauge("count", int64(len(ser.members)))
+		default:
+			a.summarize(m, ser)
+		}
+		metrics = append(metrics, m)
+
+		if ser.typ == typeGauge {
+			ser.updated = false
+		} else {
+			delete(a.series, key)
+		}
+	}
+	return metrics
+}
+
+// summarize adds the statistics of a timer, histogram or distribution.
+func (a *aggregator) summarize(m *monitor.Metric, ser *series) {
+	mean := ser.sum / float64(ser.n)
+	variance := max(ser.sumSq/float64(ser.n)-mean*mean, 0)
+	m.WithGauge("count", ser.count).
+		WithGauge("sum", ser.sum).
+		WithGauge("min", ser.min).
+		WithGauge("max", ser.max).
+		WithGauge("mean", mean).
+		WithGauge("stddev", math.Sqrt(variance))
+
+	sort.Float64s(ser.values)
+	for _, p := range a.percentiles {
+		m.WithGauge(percentileField(p), percentile(ser.values, p))
+	}
+}
+
+// percentile returns the nearest-rank percentile p (0 to 100) of sorted
+// values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+// percentileField names the field of percentile p, such as "p99" or
+// "p99_9".
+func percentileField(p float64) string {
+	return "p" + strings.ReplaceAll(strconv.FormatFloat(p, 'f', -1, 64), ".", "_")
+}
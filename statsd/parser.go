@@ -0,0 +1,114 @@
+package statsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StatsD metric types, as they appear after the first "|".
+const (
+	typeCounter      = "c"
+	typeGauge        = "g"
+	typeTiming       = "ms"
+	typeHistogram    = "h"
+	typeDistribution = "d"
+	typeSet          = "s"
+)
+
+// sample is a single value read from a StatsD line.
+type sample struct {
+	name string
+	typ  string
+	// value holds the number for every type but sets.
+	value float64
+	// member is the raw value of a set.
+	member string
+	// delta marks a gauge value with an explicit sign, which adjusts the
+	// gauge instead of setting it.
+	delta bool
+	rate  float64
+	tags  map[string]string
+}
+
+// parseLine parses one line of StatsD or DogStatsD:
+//
+//	<name>:<value>[:<value>...]|<type>[|@<rate>][|#<tag>[:<value>],...]
+//
+// Several values packed into one line share the type, rate and tags.
+// DogStatsD events and service checks, and fields this listener has no use
+// for (container IDs, timestamps), are ignored.
+func parseLine(line string) ([]sample, error) {
+	if strings.HasPrefix(line, "_e{") || strings.HasPrefix(line, "_sc|") {
+		return nil, nil
+	}
+
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("missing metric name")
+	}
+	parts := strings.Split(rest, "|")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("missing metric type")
+	}
+
+	typ := parts[1]
+	switch typ {
+	case typeCounter, typeGauge, typeTiming, typeHistogram, typeDistribution, typeSet:
+	default:
+		return nil, fmt.Errorf("unknown metric type %q", typ)
+	}
+
+	rate := 1.0
+	var tags map[string]string
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			r, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || r <= 0 || r > 1 {
+				return nil, fmt.Errorf("invalid sample rate %q", part[1:])
+			}
+			rate = r
+		case strings.HasPrefix(part, "#"):
+			tags = parseTags(part[1:])
+		}
+	}
+
+	values := strings.Split(parts[0], ":")
+	samples := make([]sample, 0, len(values))
+	for _, v := range values {
+		s := sample{name: name, typ: typ, rate: rate, tags: tags}
+		if typ == typeSet {
+			if v == "" {
+				return nil, fmt.Errorf("empty set value")
+			}
+			s.member = v
+		} else {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", v)
+			}
+			s.value = f
+			s.delta = typ == typeGauge && (v[0] == '+' || v[0] == '-')
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+// parseTags parses DogStatsD tags. A tag without a value is given the value
+// "true".
+func parseTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(tag, ":")
+		if k == "" {
+			continue
+		}
+		if !ok || v == "" {
+			v = "true"
+		}
+		tags[k] = v
+	}
+	return tags
+}
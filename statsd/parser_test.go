@@ -0,0 +1,63 @@
+package statsd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []sample
+	}{
+		{"hits:1|c", []sample{{name: "hits", typ: "c", value: 1, rate: 1}}},
+		{"hits:3|c|@0.5", []sample{{name: "hits", typ: "c", value: 3, rate: 0.5}}},
+		{"temp:-2.5|g", []sample{{name: "temp", typ: "g", value: -2.5, delta: true, rate: 1}}},
+		{"temp:20|g", []sample{{name: "temp", typ: "g", value: 20, rate: 1}}},
+		{"users:alice|s", []sample{{name: "users", typ: "s", member: "alice", rate: 1}}},
+		{
+			"req.time:12|ms|@0.1|#env:prod,canary",
+			[]sample{{name: "req.time", typ: "ms", value: 12, rate: 0.1, tags: map[string]string{"env": "prod", "canary": "true"}}},
+		},
+		{
+			"size:1:2|d|#a:b|c:abc123|T1700000000",
+			[]sample{
+				{name: "size", typ: "d", value: 1, rate: 1, tags: map[string]string{"a": "b"}},
+				{name: "size", typ: "d", value: 2, rate: 1, tags: map[string]string{"a": "b"}},
+			},
+		},
+		{"_e{5,4}:title|text", nil},
+		{"_sc|check|0", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLine(tt.line)
+		if err != nil {
+			t.Errorf("parseLine(%q) error: %v", tt.line, err)
+			continue
+		}
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	for _, line := range []string{
+		"novalue",
+		":1|c",
+		"hits:1",
+		"hits:1|x",
+		"hits:abc|c",
+		"hits:1|c|@0",
+		"hits:1|c|@2",
+		"users:|s",
+	} {
+		if _, err := parseLine(line); err == nil {
+			t.Errorf("parseLine(%q) should fail", line)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+// maxPacketSize is the largest datagram read; anything longer is truncated.
+const maxPacketSize = 64 * 1024
+
+// Listener is a monitor.Input that receives StatsD and DogStatsD packets
+// over UDP, aggregates them and pushes the results every flush interval.
+//
+// Each series, a metric name with its type and tags, is reported as one
+// metric named after it and tagged with its DogStatsD tags, the configured
+// tags and metric_type. Fields per type:
+//
+//	counter       count (total in the interval, scaled by sample rate), rate (per second)
+//	gauge         value
+//	timing,       count, sum, min, max, mean, stddev and a p<N> field per
+//	distribution  configured percentile, such as p99 or p99_9
+//	set           count (unique values)
+//
+// Histograms ("h") are reported as timings. Counters, timers and sets are
+// reported only for intervals in which they were updated.
+type Listener struct {
+	cfg    monitor.StatsDConfig
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	agg  *aggregator
+	conn net.PacketConn
+	sink monitor.Sink
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a StatsD listener. It does not listen until started.
+func New(cfg monitor.StatsDConfig, logger *slog.Logger) *Listener {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.FlushInterval.Duration <= 0 {
+		cfg.FlushInterval.Duration = 10 * time.Second
+	}
+	return &Listener{cfg: cfg, logger: logger}
+}
+
+func (l *Listener) Name() string {
+	return "statsd"
+}
+
+// Start listens on the configured address and begins aggregating.
+func (l *Listener) Start(ctx context.Context, sink monitor.Sink) error {
+	conn, err := net.ListenPacket("udp", l.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for StatsD: %w", err)
+	}
+
+	l.mu.Lock()
+	l.conn = conn
+	l.sink = sink
+	l.agg = newAggregator(l.cfg, time.Now())
+	l.done = make(chan struct{})
+	l.mu.Unlock()
+
+	l.wg.Add(2)
+	go l.readLoop(conn)
+	go l.flushLoop()
+
+	l.logger.Info("StatsD listener started", "addr", conn.LocalAddr().String())
+	return nil
+}
+
+// Stop closes the socket and pushes what has been aggregated so far.
+func (l *Listener) Stop() error {
+	l.mu.Lock()
+	conn := l.conn
+	l.conn = nil
+	l.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+
+	close(l.done)
+	err := conn.Close()
+	l.wg.Wait()
+	l.flush()
+	return err
+}
+
+// Addr returns the address the listener is bound to, or nil if it is not
+// running.
+func (l *Listener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	return l.conn.LocalAddr()
+}
+
+func (l *Listener) readLoop(conn net.PacketConn) {
+	defer l.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			l.logger.Warn("StatsD read error", "error", err)
+			continue
+		}
+		l.handlePacket(string(buf[:n]))
+	}
+}
+
+func (l *Listener) handlePacket(packet string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, line := range strings.Split(packet, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		samples, err := parseLine(line)
+		if err != nil {
+			l.logger.Debug("invalid StatsD line", "line", line, "error", err)
+			continue
+		}
+		for _, s := range samples {
+			l.agg.add(s)
+		}
+	}
+}
+
+func (l *Listener) flushLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.cfg.FlushInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.flush()
+		}
+	}
+}
+
+func (l *Listener) flush() {
+	l.mu.Lock()
+	metrics := l.agg.flush(time.Now())
+	sink := l.sink
+	l.mu.Unlock()
+
+	for _, m := range metrics {
+		for k, v := range l.cfg.Tags {
+			if _, ok := m.Tags[k]; !ok {
+				m.WithTag(k, v)
+			}
+		}
+		sink.Push(m)
+	}
+	if len(metrics) > 0 {
+		l.logger.Debug("flushed StatsD metrics", "count", len(metrics))
+	}
+}
+
+// Compile-time check.
+var _ monitor.Input = (*Listener)(nil)
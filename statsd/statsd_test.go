@@ -0,0 +1,225 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	monitor "github.com/danweinerdev/go-monitor"
+)
+
+func testConfig() monitor.StatsDConfig {
+	return monitor.StatsDConfig{
+		Addr:          "127.0.0.1:0",
+		FlushInterval: monitor.Duration{Duration: time.Hour},
+		Percentiles:   []float64{50, 90, 99.9},
+	}
+}
+
+func addLines(t *testing.T, a *aggregator, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		samples, err := parseLine(line)
+		if err != nil {
+			t.Fatalf("parseLine(%q) error: %v", line, err)
+		}
+		for _, s := range samples {
+			a.add(s)
+		}
+	}
+}
+
+func find(metrics []*monitor.Metric, name string) *monitor.Metric {
+	for _, m := range metrics {
+		if m.Measurement == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestAggregatorCounter(t *testing.T) {
+	start := time.Unix(1000, 0)
+	a := newAggregator(testConfig(), start)
+	addLines(t, a, "hits:1|c", "hits:2|c", "hits:1|c|@0.25")
+
+	metrics := a.flush(start.Add(2 * time.Second))
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(metrics))
+	}
+	m := metrics[0]
+	if m.Fields["count"] != 7.0 || m.Fields["rate"] != 3.5 {
+		t.Errorf("fields = %v, want count 7 and rate 3.5", m.Fields)
+	}
+	if m.Tags["metric_type"] != "counter" {
+		t.Errorf("metric_type = %q", m.Tags["metric_type"])
+	}
+
+	if metrics := a.flush(start.Add(4 * time.Second)); len(metrics) != 0 {
+		t.Errorf("counter reported again without updates: %v", metrics)
+	}
+}
+
+func TestAggregatorGauge(t *testing.T) {
+	a := newAggregator(testConfig(), time.Now())
+	addLines(t, a, "temp:10|g", "temp:+5|g", "temp:-3|g")
+
+	if m := find(a.flush(time.Now()), "temp"); m == nil || m.Fields["value"] != 12.0 {
+		t.Fatalf("gauge = %+v, want 12", m)
+	}
+	if m := find(a.flush(time.Now()), "temp"); m == nil || m.Fields["value"] != 12.0 {
+		t.Errorf("gauge should keep being reported, got %+v", m)
+	}
+
+	cfg := testConfig()
+	cfg.DeleteGauges = true
+	a = newAggregator(cfg, time.Now())
+	addLines(t, a, "temp:10|g")
+	a.flush(time.Now())
+	if metrics := a.flush(time.Now()); len(metrics) != 0 {
+		t.Errorf("idle gauge should be deleted, got %v", metrics)
+	}
+}
+
+func TestAggregatorTiming(t *testing.T) {
+	a := newAggregator(testConfig(), time.Now())
+	for i := 1; i <= 100; i++ {
+		a.add(sample{name: "lat", typ: typeTiming, value: float64(i), rate: 1})
+	}
+	addLines(t, a, "lat:1000|h|@0.5")
+
+	m := find(a.flush(time.Now()), "lat")
+	if m == nil {
+		t.Fatal("missing timing metric")
+	}
+	want := map[string]float64{
+		"count": 102, // 100 + one sample at rate 0.5
+		"sum":   6050,
+		"min":   1,
+		"max":   1000,
+		"p50":   51,
+		"p90":   91,
+		"p99_9": 1000,
+	}
+	for k, v := range want {
+		if m.Fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, m.Fields[k], v)
+		}
+	}
+	if mean := m.Fields["mean"].(float64); mean < 59.9 || mean > 59.91 {
+		t.Errorf("mean = %v, want ~59.9", mean)
+	}
+	if m.Tags["metric_type"] != "timing" {
+		t.Errorf("histograms should be reported as timings, got %q", m.Tags["metric_type"])
+	}
+}
+
+func TestAggregatorMaxSamples(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxSamples = 10
+	a := newAggregator(cfg, time.Now())
+	for i := 0; i < 1000; i++ {
+		a.add(sample{name: "lat", typ: typeDistribution, value: float64(i), rate: 1})
+	}
+
+	for _, ser := range a.series {
+		if len(ser.values) != 10 {
+			t.Errorf("kept %d values, want 10", len(ser.values))
+		}
+	}
+	m := find(a.flush(time.Now()), "lat")
+	if m.Fields["count"] != 1000.0 || m.Fields["min"] != 0.0 || m.Fields["max"] != 999.0 {
+		t.Errorf("count, min and max should stay exact: %v", m.Fields)
+	}
+}
+
+func TestAggregatorSetAndTags(t *testing.T) {
+	a := newAggregator(testConfig(), time.Now())
+	addLines(t, a,
+		"users:alice|s|#region:eu",
+		"users:bob|s|#region:eu",
+		"users:alice|s|#region:eu",
+		"users:carol|s|#region:us",
+	)
+
+	metrics := a.flush(time.Now())
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want one per tag set", len(metrics))
+	}
+	for _, m := range metrics {
+		want := int64(2)
+		if m.Tags["region"] == "us" {
+			want = 1
+		}
+		if m.Fields["count"] != want {
+			t.Errorf("region %s: count = %v, want %d", m.Tags["region"], m.Fields["count"], want)
+		}
+	}
+}
+
+type recordingSink struct {
+	mu      sync.Mutex
+	metrics []*monitor.Metric
+}
+
+func (s *recordingSink) Push(m *monitor.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, m)
+}
+
+func (s *recordingSink) snapshot() []*monitor.Metric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*monitor.Metric(nil), s.metrics...)
+}
+
+func TestListener(t *testing.T) {
+	cfg := testConfig()
+	cfg.FlushInterval = monitor.Duration{Duration: 20 * time.Millisecond}
+	cfg.Tags = map[string]string{"host": "web1", "env": "default"}
+
+	l := New(cfg, nil)
+	sink := &recordingSink{}
+	if err := l.Start(context.Background(), sink); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	conn, err := net.Dial("udp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("hits:1|c|#env:prod\nhits:1|c|#env:prod\ngarbage\n"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	var hits *monitor.Metric
+	for hits == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		hits = find(sink.snapshot(), "hits")
+	}
+	if hits == nil {
+		t.Fatal("no metrics pushed")
+	}
+	if hits.Fields["count"] != 2.0 {
+		t.Errorf("count = %v, want 2", hits.Fields["count"])
+	}
+	if hits.Tags["env"] != "prod" || hits.Tags["host"] != "web1" {
+		t.Errorf("tags = %v, want packet tags over configured ones", hits.Tags)
+	}
+
+	// Anything received before Stop is flushed by it.
+	conn.Write([]byte("last:5|g"))
+	time.Sleep(10 * time.Millisecond)
+	if err := l.Stop(); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if find(sink.snapshot(), "last") == nil {
+		t.Error("Stop() should flush pending metrics")
+	}
+	if l.Addr() != nil {
+		t.Error("Addr() should be nil after Stop")
+	}
+}
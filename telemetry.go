@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"context"
+	"time"
+)
+
+// TelemetryCollectorName is the name of the built-in collector that reports
+// the monitor's own health when [telemetry] is enabled.
+const TelemetryCollectorName = "telemetry"
+
+// Measurement suffixes emitted by the telemetry collector, appended to
+// telemetry.prefix with an underscore.
+const (
+	telemetryPoll     = "poll"
+	telemetryPipeline = "pipeline"
+	telemetryBackend  = "backend"
+)
+
+// telemetryCollector returns a CollectFunc that reports the monitor's poll
+// statistics, pipeline state and per-backend delivery statistics as metrics,
+// so they reach the same backends as everything else.
+func (m *Monitor) telemetryCollector(prefix string) CollectFunc {
+	return func(ctx context.Context) ([]*Metric, error) {
+		now := time.Now()
+		var metrics []*Metric
+
+		for name, s := range m.CollectorStats() {
+			metrics = append(metrics, NewMetric(prefix+"_"+telemetryPoll).
+				WithTag("monitor", m.name).
+				WithTag("collector", name).
+				WithKind(KindCounter).
+				WithFields(map[string]interface{}{
+					"total":      s.TotalPolls,
+					"successful": s.SuccessfulPolls,
+					"failed":     s.FailedPolls,
+					"skipped":    s.SkippedPolls,
+					"panicked":   s.PanickedPolls,
+					"metrics":    s.TotalMetrics,
+				}).
+				WithGauge("consecutive_failures", s.ConsecutiveFailures).
+				WithGauge("last_duration_seconds", s.LastDuration.Seconds()).
+				WithFieldMeta("last_duration_seconds", "Duration of the last collection.", UnitSeconds).
+				WithTimestamp(now))
+		}
+
+		ps := m.pipeline.Stats()
+		metrics = append(metrics, NewMetric(prefix+"_"+telemetryPipeline).
+			WithTag("monitor", m.name).
+			WithFields(map[string]interface{}{
+				"buffer_len": ps.BufferLen,
+				"spool_len":  m.pipeline.SpoolLen(),
+				"backends":   m.pipeline.BackendCount(),
+			}).
+			WithCounter("pushed", ps.PushedMetrics).
+			WithCounter("emitted", m.Stats().EmittedMetrics).
+			WithCounter("invalid", ps.InvalidMetrics).
+			WithCounter("dropped", ps.DroppedMetrics).
+			WithTimestamp(now))
+
+		for _, bs := range m.pipeline.BackendStats() {
+			metrics = append(metrics, NewMetric(prefix+"_"+telemetryBackend).
+				WithTag("monitor", m.name).
+				WithTag("backend", bs.Name).
+				WithFields(map[string]interface{}{
+					"healthy":                    bs.Healthy,
+					"circuit_open":               bs.State != BreakerClosed,
+					"queue_len":                  bs.QueueLen,
+					"spool_len":                  bs.SpoolLen,
+					"last_write_latency_seconds": bs.LastWriteLatency.Seconds(),
+				}).
+				WithFieldMeta("last_write_latency_seconds", "Duration of the last successful write, including retries.", UnitSeconds).
+				WithCounter("delivered", bs.DeliveredBatch).
+				WithCounter("failed", bs.FailedBatch).
+				WithCounter("dropped", bs.DroppedBatch).
+				WithCounter("rejected", bs.RejectedBatch).
+				WithCounter("invalid", bs.InvalidMetrics).
+				WithCounter("panicked", bs.PanickedBatch).
+				WithCounter("retries", bs.Retries).
+				WithTimestamp(now))
+		}
+
+		return metrics, nil
+	}
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTelemetryCollector(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Global.LogLevel = "error"
+	cfg.Telemetry.Enabled = true
+
+	backend := &mockBackend{name: "sink", healthy: true}
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) {
+		return []*Metric{NewMetric("app").WithField("value", 1)}, nil
+	},
+		WithConfig(cfg),
+		WithRunOnce(true),
+		WithBackend(backend),
+	)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	got := make(map[string]*Metric)
+	for _, batch := range backend.written {
+		for _, metric := range batch {
+			got[metric.Measurement+"/"+metric.Tags["collector"]+metric.Tags["backend"]] = metric
+		}
+	}
+
+	poll, ok := got["gomonitor_poll/"+DefaultCollectorName]
+	if !ok {
+		t.Fatalf("missing gomonitor_poll for the default collector, got %v", got)
+	}
+	if poll.Tags["monitor"] != "test" {
+		t.Errorf("monitor tag = %q, want %q", poll.Tags["monitor"], "test")
+	}
+	if poll.Fields["successful"] != int64(1) || poll.Fields["metrics"] != int64(1) {
+		t.Errorf("gomonitor_poll fields = %v, want one successful poll with one metric", poll.Fields)
+	}
+
+	pipeline, ok := got["gomonitor_pipeline/"]
+	if !ok {
+		t.Fatal("missing gomonitor_pipeline")
+	}
+	if pipeline.Fields["backends"] != 1 {
+		t.Errorf("gomonitor_pipeline backends = %v, want 1", pipeline.Fields["backends"])
+	}
+
+	if b, ok := got["gomonitor_backend/sink"]; !ok {
+		t.Error("missing gomonitor_backend for the sink backend")
+	} else if b.Fields["healthy"] != true || b.Fields["circuit_open"] != false {
+		t.Errorf("gomonitor_backend fields = %v, want healthy with a closed circuit", b.Fields)
+	}
+
+	if _, ok := m.CollectorStats()[TelemetryCollectorName]; !ok {
+		t.Error("telemetry collector should report its own CollectorStats")
+	}
+}
+
+func TestTelemetryCollectorPrefixAndInterval(t *testing.T) {
+	data := `
+[telemetry]
+enabled = true
+poll_interval = "1m"
+prefix = "selfmon"
+`
+	cfg, err := LoadConfigFromString(data)
+	if err != nil {
+		t.Fatalf("LoadConfigFromString() error: %v", err)
+	}
+
+	m, err := New("test", func(ctx context.Context) ([]*Metric, error) { return nil, nil }, WithConfig(cfg))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	var tc *collector
+	for _, c := range m.collectors {
+		if c.name == TelemetryCollectorName {
+			tc = c
+		}
+	}
+	if tc == nil {
+		t.Fatal("telemetry collector not registered")
+	}
+	if got := tc.intervalFor(cfg); got != time.Minute {
+		t.Errorf("telemetry interval = %v, want 1m", got)
+	}
+
+	metrics, _ := tc.fn(context.Background())
+	if len(metrics) == 0 || metrics[0].Measurement != "selfmon_poll" {
+		t.Errorf("expected selfmon_poll metrics, got %v", metrics)
+	}
+}
+
+func TestTelemetryReservedName(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Telemetry.Enabled = true
+	fn := func(ctx context.Context) ([]*Metric, error) { return nil, nil }
+
+	if _, err := New("test", nil, WithConfig(cfg), WithCollector(TelemetryCollectorName, fn, 0)); err == nil {
+		t.Error("New() should error when a collector uses the telemetry name")
+	}
+
+	cfg.Telemetry.Enabled = false
+	if _, err := New("test", nil, WithConfig(cfg), WithCollector(TelemetryCollectorName, fn, 0)); err != nil {
+		t.Errorf("New() error with telemetry disabled: %v", err)
+	}
+}
+
+func TestValidationTelemetry(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Telemetry = TelemetryConfig{Enabled: true, PollInterval: Duration{-1 * time.Second}}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should error for a negative interval and empty prefix")
+	}
+	if errs := err.(ValidationErrors); len(errs) != 2 {
+		t.Errorf("Expected 2 validation errors, got %d: %v", len(errs), errs)
+	}
+}
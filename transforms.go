@@ -0,0 +1,374 @@
+package monitor
+
+import (
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+)
+
+// FieldType is the target type of a ConvertProcessor.
+type FieldType string
+
+const (
+	FieldTypeInteger FieldType = "integer"
+	FieldTypeFloat   FieldType = "float"
+	FieldTypeString  FieldType = "string"
+	FieldTypeBoolean FieldType = "boolean"
+)
+
+// Valid reports whether the type is one of the known field types.
+func (t FieldType) Valid() bool {
+	switch t {
+	case FieldTypeInteger, FieldTypeFloat, FieldTypeString, FieldTypeBoolean:
+		return true
+	default:
+		return false
+	}
+}
+
+// RenameProcessor renames measurements, tags and fields. Each map goes from
+// the old name to the new one; a renamed tag or field replaces any existing
+// one of the new name. Renames are not chained, so {a: b, b: a} swaps.
+type RenameProcessor struct {
+	Measurements map[string]string
+	Tags         map[string]string
+	Fields       map[string]string
+}
+
+func (r *RenameProcessor) Process(metrics []*Metric) []*Metric {
+	for _, m := range metrics {
+		if to, ok := r.Measurements[m.Measurement]; ok {
+			m.Measurement = to
+		}
+
+		moved := make(map[string]string, len(r.Tags))
+		for from, to := range r.Tags {
+			if v, ok := m.Tags[from]; ok {
+				delete(m.Tags, from)
+				moved[to] = v
+			}
+		}
+		for k, v := range moved {
+			m.Tags[k] = v
+		}
+
+		fields := make(map[string]fieldEntry, len(r.Fields))
+		for from, to := range r.Fields {
+			if e, ok := m.takeField(from); ok {
+				fields[to] = e
+			}
+		}
+		for k, e := range fields {
+			m.putField(k, e)
+		}
+	}
+	return metrics
+}
+
+// TagsProcessor drops the tags matching any of the Drop glob patterns, then
+// adds the Add tags. Existing tags are only replaced with Overwrite.
+type TagsProcessor struct {
+	Add       map[string]string
+	Drop      []string
+	Overwrite bool
+}
+
+func (t *TagsProcessor) Process(metrics []*Metric) []*Metric {
+	for _, m := range metrics {
+		for k := range m.Tags {
+			if matchAny(t.Drop, k) {
+				delete(m.Tags, k)
+			}
+		}
+		if len(t.Add) > 0 && m.Tags == nil {
+			m.Tags = make(map[string]string, len(t.Add))
+		}
+		for k, v := range t.Add {
+			if _, ok := m.Tags[k]; !ok || t.Overwrite {
+				m.Tags[k] = v
+			}
+		}
+	}
+	return metrics
+}
+
+// FieldsProcessor drops the fields matching any of the Drop glob patterns,
+// then adds the Add fields. Existing fields are only replaced with
+// Overwrite.
+type FieldsProcessor struct {
+	Add       map[string]interface{}
+	Drop      []string
+	Overwrite bool
+}
+
+func (f *FieldsProcessor) Process(metrics []*Metric) []*Metric {
+	for _, m := range metrics {
+		for k := range m.Fields {
+			if matchAny(f.Drop, k) {
+				m.takeField(k)
+			}
+		}
+		if len(f.Add) > 0 && m.Fields == nil {
+			m.Fields = make(map[string]interface{}, len(f.Add))
+		}
+		for k, v := range f.Add {
+			if _, ok := m.Fields[k]; !ok || f.Overwrite {
+				m.Fields[k] = v
+			}
+		}
+	}
+	return metrics
+}
+
+// RegexProcessor rewrites the value of Tag where Pattern matches it, as
+// regexp.Regexp.ReplaceAllString does. The result goes to ResultTag when
+// set, leaving Tag as it was. Values that do not match are left alone.
+type RegexProcessor struct {
+	Tag         string
+	Pattern     *regexp.Regexp
+	Replacement string
+	ResultTag   string
+}
+
+func (r *RegexProcessor) Process(metrics []*Metric) []*Metric {
+	dest := r.Tag
+	if r.ResultTag != "" {
+		dest = r.ResultTag
+	}
+	for _, m := range metrics {
+		v, ok := m.Tags[r.Tag]
+		if !ok || !r.Pattern.MatchString(v) {
+			continue
+		}
+		m.Tags[dest] = r.Pattern.ReplaceAllString(v, r.Replacement)
+	}
+	return metrics
+}
+
+// ConvertProcessor converts the fields matching any of the Fields glob
+// patterns to Type. Values that cannot be converted, such as a string that
+// is not a number, and histogram and summary fields are left unchanged.
+type ConvertProcessor struct {
+	Fields []string
+	Type   FieldType
+}
+
+func (c *ConvertProcessor) Process(metrics []*Metric) []*Metric {
+	for _, m := range metrics {
+		for k, v := range m.Fields {
+			if !matchAny(c.Fields, k) {
+				continue
+			}
+			if converted, ok := convertField(v, c.Type); ok {
+				m.Fields[k] = converted
+			}
+		}
+	}
+	return metrics
+}
+
+// ScaleProcessor replaces the numeric fields matching any of the Fields glob
+// patterns with value*Factor + Offset, as a float. Other fields are left
+// unchanged.
+type ScaleProcessor struct {
+	Fields []string
+	Factor float64
+	Offset float64
+}
+
+func (s *ScaleProcessor) Process(metrics []*Metric) []*Metric {
+	for _, m := range metrics {
+		for k, v := range m.Fields {
+			if !matchAny(s.Fields, k) {
+				continue
+			}
+			switch v.(type) {
+			case string, bool:
+				continue
+			}
+			if f, ok := toFloat(v); ok {
+				m.Fields[k] = f*s.Factor + s.Offset
+			}
+		}
+	}
+	return metrics
+}
+
+// fieldEntry is a field's value together with its kind and metadata.
+type fieldEntry struct {
+	value   interface{}
+	kind    Kind
+	hasKind bool
+	meta    FieldMeta
+	hasMeta bool
+}
+
+// takeField removes a field, returning it with its kind and metadata.
+func (m *Metric) takeField(key string) (fieldEntry, bool) {
+	v, ok := m.Fields[key]
+	if !ok {
+		return fieldEntry{}, false
+	}
+	e := fieldEntry{value: v}
+	e.kind, e.hasKind = m.FieldKinds[key]
+	e.meta, e.hasMeta = m.FieldMeta[key]
+	delete(m.Fields, key)
+	delete(m.FieldKinds, key)
+	delete(m.FieldMeta, key)
+	return e, true
+}
+
+// putField sets a field taken with takeField, replacing any existing one.
+func (m *Metric) putField(key string, e fieldEntry) {
+	m.Fields[key] = e.value
+	delete(m.FieldKinds, key)
+	delete(m.FieldMeta, key)
+	if e.hasKind {
+		if m.FieldKinds == nil {
+			m.FieldKinds = make(map[string]Kind)
+		}
+		m.FieldKinds[key] = e.kind
+	}
+	if e.hasMeta {
+		if m.FieldMeta == nil {
+			m.FieldMeta = make(map[string]FieldMeta)
+		}
+		m.FieldMeta[key] = e.meta
+	}
+}
+
+// matchAny reports whether name matches any of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// convertField converts a field value to the given type.
+func convertField(v interface{}, to FieldType) (interface{}, bool) {
+	switch v.(type) {
+	case Histogram, Summary:
+		return nil, false
+	}
+
+	switch to {
+	case FieldTypeFloat:
+		return toFloat(v)
+
+	case FieldTypeInteger:
+		switch val := v.(type) {
+		case string:
+			if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+				return i, true
+			}
+		}
+		if i, ok := toInt(v); ok {
+			return i, true
+		}
+		if u, ok := toUint(v); ok {
+			if u > math.MaxInt64 {
+				return nil, false
+			}
+			return int64(u), true
+		}
+		f, ok := toFloat(v)
+		if !ok || math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return nil, false
+		}
+		return int64(f), true
+
+	case FieldTypeString:
+		switch val := v.(type) {
+		case string:
+			return val, true
+		case bool:
+			return strconv.FormatBool(val), true
+		case float32:
+			return strconv.FormatFloat(float64(val), 'g', -1, 32), true
+		case float64:
+			return strconv.FormatFloat(val, 'g', -1, 64), true
+		}
+		if i, ok := toInt(v); ok {
+			return strconv.FormatInt(i, 10), true
+		}
+		if u, ok := toUint(v); ok {
+			return strconv.FormatUint(u, 10), true
+		}
+
+	case FieldTypeBoolean:
+		switch val := v.(type) {
+		case bool:
+			return val, true
+		case string:
+			if b, err := strconv.ParseBool(val); err == nil {
+				return b, true
+			}
+			return nil, false
+		}
+		if f, ok := toFloat(v); ok {
+			return f != 0, true
+		}
+	}
+	return nil, false
+}
+
+// toFloat converts a numeric, boolean or numeric string value to a float.
+func toFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case bool:
+		if val {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		return f, err == nil
+	}
+	if i, ok := toInt(v); ok {
+		return float64(i), true
+	}
+	if u, ok := toUint(v); ok {
+		return float64(u), true
+	}
+	return 0, false
+}
+
+func toInt(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int8:
+		return int64(val), true
+	case int16:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case int64:
+		return val, true
+	}
+	return 0, false
+}
+
+func toUint(v interface{}) (uint64, bool) {
+	switch val := v.(type) {
+	case uint:
+		return uint64(val), true
+	case uint8:
+		return uint64(val), true
+	case uint16:
+		return uint64(val), true
+	case uint32:
+		return uint64(val), true
+	case uint64:
+		return val, true
+	}
+	return 0, false
+}
@@ -0,0 +1,158 @@
+package monitor
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestRenameProcessor(t *testing.T) {
+	m := NewMetric("cpu").
+		WithTag("hostname", "web1").
+		WithTag("a", "1").
+		WithTag("b", "2").
+		WithCounter("usage", 5).
+		WithFieldMeta("usage", "CPU usage.", UnitRatio).
+		WithField("usage_percent", 1)
+
+	(&RenameProcessor{
+		Measurements: map[string]string{"cpu": "host_cpu"},
+		Tags:         map[string]string{"hostname": "host", "a": "b", "b": "a"},
+		Fields:       map[string]string{"usage": "usage_percent"},
+	}).Process([]*Metric{m})
+
+	if m.Measurement != "host_cpu" {
+		t.Errorf("Measurement = %q", m.Measurement)
+	}
+	wantTags := map[string]string{"host": "web1", "a": "2", "b": "1"}
+	if !reflect.DeepEqual(m.Tags, wantTags) {
+		t.Errorf("Tags = %v, want %v", m.Tags, wantTags)
+	}
+	if len(m.Fields) != 1 || m.Fields["usage_percent"] != 5 {
+		t.Errorf("Fields = %v, want the renamed field replacing the existing one", m.Fields)
+	}
+	if m.FieldKind("usage_percent") != KindCounter || m.FieldUnit("usage_percent") != UnitRatio {
+		t.Error("renamed field should keep its kind and metadata")
+	}
+}
+
+func TestTagsAndFieldsProcessors(t *testing.T) {
+	m := NewMetric("cpu").
+		WithTag("env", "dev").
+		WithTag("pod_id", "x").
+		WithTag("pod_ip", "y").
+		WithField("usage", 1).
+		WithField("debug_a", 2)
+
+	(&TagsProcessor{
+		Add:  map[string]string{"env": "prod", "dc": "eu1"},
+		Drop: []string{"pod_*"},
+	}).Process([]*Metric{m})
+	if want := map[string]string{"env": "dev", "dc": "eu1"}; !reflect.DeepEqual(m.Tags, want) {
+		t.Errorf("Tags = %v, want %v", m.Tags, want)
+	}
+
+	(&TagsProcessor{Add: map[string]string{"env": "prod"}, Overwrite: true}).Process([]*Metric{m})
+	if m.Tags["env"] != "prod" {
+		t.Errorf("env = %q, want overwritten", m.Tags["env"])
+	}
+
+	(&FieldsProcessor{
+		Add:  map[string]interface{}{"usage": 9, "version": "1.2"},
+		Drop: []string{"debug_*"},
+	}).Process([]*Metric{m})
+	if want := map[string]interface{}{"usage": 1, "version": "1.2"}; !reflect.DeepEqual(m.Fields, want) {
+		t.Errorf("Fields = %v, want %v", m.Fields, want)
+	}
+
+	// A metric built without NewMetric has no maps yet.
+	bare := &Metric{Measurement: "bare"}
+	(&TagsProcessor{Add: map[string]string{"a": "b"}}).Process([]*Metric{bare})
+	(&FieldsProcessor{Add: map[string]interface{}{"v": 1}}).Process([]*Metric{bare})
+	if bare.Tags["a"] != "b" || bare.Fields["v"] != 1 {
+		t.Errorf("bare metric = %+v", bare)
+	}
+}
+
+func TestRegexProcessor(t *testing.T) {
+	p := &RegexProcessor{
+		Tag:         "path",
+		Pattern:     regexp.MustCompile(`^/users/\d+`),
+		Replacement: "/users/:id",
+	}
+	a := NewMetric("http").WithTag("path", "/users/42/orders").WithField("n", 1)
+	b := NewMetric("http").WithTag("path", "/health").WithField("n", 1)
+	p.Process([]*Metric{a, b})
+
+	if a.Tags["path"] != "/users/:id/orders" {
+		t.Errorf("path = %q", a.Tags["path"])
+	}
+	if b.Tags["path"] != "/health" {
+		t.Errorf("non-matching value changed to %q", b.Tags["path"])
+	}
+
+	p = &RegexProcessor{
+		Tag:         "host",
+		Pattern:     regexp.MustCompile(`^(\w+)\.(\w+)\.example\.com$`),
+		Replacement: "$2",
+		ResultTag:   "dc",
+	}
+	c := NewMetric("cpu").WithTag("host", "web1.eu1.example.com").WithField("n", 1)
+	p.Process([]*Metric{c})
+	if c.Tags["dc"] != "eu1" || c.Tags["host"] != "web1.eu1.example.com" {
+		t.Errorf("tags = %v, want dc extracted and host kept", c.Tags)
+	}
+}
+
+func TestConvertField(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		to   FieldType
+		want interface{}
+		ok   bool
+	}{
+		{"42", FieldTypeInteger, int64(42), true},
+		{"4.7", FieldTypeInteger, int64(4), true},
+		{3.9, FieldTypeInteger, int64(3), true},
+		{uint64(7), FieldTypeInteger, int64(7), true},
+		{true, FieldTypeInteger, int64(1), true},
+		{"abc", FieldTypeInteger, nil, false},
+		{int32(5), FieldTypeFloat, 5.0, true},
+		{"2.5", FieldTypeFloat, 2.5, true},
+		{1.5, FieldTypeString, "1.5", true},
+		{int64(-3), FieldTypeString, "-3", true},
+		{uint8(200), FieldTypeString, "200", true},
+		{"true", FieldTypeBoolean, true, true},
+		{0, FieldTypeBoolean, false, true},
+		{"maybe", FieldTypeBoolean, nil, false},
+		{Histogram{Count: 1}, FieldTypeFloat, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := convertField(tt.in, tt.to)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("convertField(%#v, %s) = %#v, %v; want %#v, %v", tt.in, tt.to, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConvertAndScaleProcessors(t *testing.T) {
+	m := NewMetric("disk").
+		WithField("status", "200").
+		WithField("note", "n/a").
+		WithField("used_bytes", int64(2048)).
+		WithField("free_bytes", uint64(1024)).
+		WithField("up", true)
+
+	(&ConvertProcessor{Fields: []string{"status", "note"}, Type: FieldTypeInteger}).Process([]*Metric{m})
+	if m.Fields["status"] != int64(200) || m.Fields["note"] != "n/a" {
+		t.Errorf("converted fields = %v", m.Fields)
+	}
+
+	(&ScaleProcessor{Fields: []string{"*_bytes", "up"}, Factor: 1.0 / 1024, Offset: 1}).Process([]*Metric{m})
+	if m.Fields["used_bytes"] != 3.0 || m.Fields["free_bytes"] != 2.0 {
+		t.Errorf("scaled fields = %v", m.Fields)
+	}
+	if m.Fields["up"] != true {
+		t.Errorf("booleans should not be scaled, got %v", m.Fields["up"])
+	}
+}
@@ -2,6 +2,11 @@
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -39,6 +44,17 @@ func (c *Config) Validate() error {
 	errs = append(errs, c.validateGlobal()...)
 	errs = append(errs, c.validateInfluxDB()...)
 	errs = append(errs, c.validatePrometheus()...)
+	errs = append(errs, c.validateSpool()...)
+	errs = append(errs, c.validateTelemetry()...)
+	errs = append(errs, c.validateAdmin()...)
+	errs = append(errs, c.validateHost()...)
+	errs = append(errs, c.validateProbes()...)
+	errs = append(errs, c.validateScrape()...)
+	errs = append(errs, c.validateStatsD()...)
+	errs = append(errs, c.validateInfluxListener()...)
+	errs = append(errs, c.validateProcessors()...)
+	errs = append(errs, c.validateFilters()...)
+	errs = append(errs, c.validateCollectors()...)
 
 	if len(errs) > 0 {
 		return errs
@@ -56,6 +72,20 @@ func (c *Config) validateGlobal() ValidationErrors {
 		})
 	}
 
+	if c.Global.CollectTimeout.Duration < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "global.collect_timeout",
+			Message: "must not be negative",
+		})
+	}
+
+	if !c.Global.OverlapPolicy.Valid() {
+		errs = append(errs, ValidationError{
+			Field:   "global.overlap_policy",
+			Message: "must be one of: skip, queue, cancel",
+		})
+	}
+
 	if c.Global.BatchSize <= 0 {
 		errs = append(errs, ValidationError{
 			Field:   "global.batch_size",
@@ -63,6 +93,55 @@ func (c *Config) validateGlobal() ValidationErrors {
 		})
 	}
 
+	if c.Global.RetryMaxDelay.Duration < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "global.retry_max_delay",
+			Message: "must not be negative",
+		})
+	}
+
+	if c.Global.QueueSize <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "global.queue_size",
+			Message: "must be positive",
+		})
+	}
+
+	if c.Global.BreakerThreshold <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "global.breaker_threshold",
+			Message: "must be positive",
+		})
+	}
+
+	if c.Global.BreakerCooldown.Duration <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "global.breaker_cooldown",
+			Message: "must be positive",
+		})
+	}
+
+	if c.Global.MaxBufferSize < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "global.max_buffer_size",
+			Message: "must not be negative",
+		})
+	}
+
+	if !c.Global.OverflowPolicy.Valid() {
+		errs = append(errs, ValidationError{
+			Field:   "global.overflow_policy",
+			Message: "must be one of: drop-oldest, drop-newest, block",
+		})
+	}
+
+	if c.Global.OverflowPolicy == OverflowBlock && c.Global.BlockTimeout.Duration <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "global.block_timeout",
+			Message: "must be positive when overflow_policy is block",
+		})
+	}
+
 	validLevels := map[string]bool{
 		"debug": true, "info": true, "warn": true, "error": true,
 	}
@@ -144,3 +223,594 @@ func (c *Config) validatePrometheus() ValidationErrors {
 
 	return errs
 }
+
+func (c *Config) validateTelemetry() ValidationErrors {
+	var errs ValidationErrors
+
+	if !c.Telemetry.Enabled {
+		return errs
+	}
+
+	if c.Telemetry.PollInterval.Duration < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "telemetry.poll_interval",
+			Message: "must not be negative",
+		})
+	}
+
+	if c.Telemetry.Prefix == "" {
+		errs = append(errs, ValidationError{
+			Field:   "telemetry.prefix",
+			Message: "required when telemetry is enabled",
+		})
+	}
+
+	return errs
+}
+
+func (c *Config) validateAdmin() ValidationErrors {
+	var errs ValidationErrors
+
+	if !c.Admin.Enabled {
+		return errs
+	}
+
+	if c.Admin.Addr == "" {
+		errs = append(errs, ValidationError{
+			Field:   "admin.addr",
+			Message: "required when the admin server is enabled",
+		})
+	}
+
+	if c.Admin.ReadyFailures <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "admin.ready_failures",
+			Message: "must be positive",
+		})
+	}
+
+	return errs
+}
+
+func (c *Config) validateHost() ValidationErrors {
+	var errs ValidationErrors
+
+	if !c.Host.Enabled {
+		return errs
+	}
+
+	if c.Host.ProcRoot == "" {
+		errs = append(errs, ValidationError{
+			Field:   "host.proc_root",
+			Message: "required when host metrics are enabled",
+		})
+	}
+
+	for _, pid := range c.Host.PIDs {
+		if pid <= 0 {
+			errs = append(errs, ValidationError{
+				Field:   "host.pids",
+				Message: fmt.Sprintf("must be positive, got %d", pid),
+			})
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateProbes() ValidationErrors {
+	var errs ValidationErrors
+
+	if c.Probes.Timeout.Duration <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "probes.timeout",
+			Message: "must be positive",
+		})
+	}
+
+	names := make(map[string]bool)
+	for i, p := range c.Probes.HTTP {
+		field := fmt.Sprintf("probes.http[%d]", i)
+		if p.URL == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".url",
+				Message: "is required",
+			})
+		} else if u, err := url.Parse(p.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".url",
+				Message: fmt.Sprintf("must be an absolute http or https URL, got %q", p.URL),
+			})
+		}
+		errs = append(errs, validateProbeName(names, field, p.Name)...)
+		errs = append(errs, validateProbeTimeout(field, p.Timeout)...)
+		errs = append(errs, validateProbeRegex(field+".body_regex", p.BodyRegex)...)
+		if p.JSONValue != "" && p.JSONPath == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".json_value",
+				Message: "requires json_path",
+			})
+		}
+		for _, code := range p.ExpectStatus {
+			if code < 100 || code > 599 {
+				errs = append(errs, ValidationError{
+					Field:   field + ".expect_status",
+					Message: fmt.Sprintf("invalid status code %d", code),
+				})
+			}
+		}
+	}
+
+	for i, p := range c.Probes.TCP {
+		field := fmt.Sprintf("probes.tcp[%d]", i)
+		errs = append(errs, validateProbeName(names, field, p.Name)...)
+		errs = append(errs, validateProbeAddress(field, p.Address)...)
+		errs = append(errs, validateProbeRegex(field+".expect", p.Expect)...)
+		errs = append(errs, validateProbeTimeout(field, p.Timeout)...)
+	}
+
+	for i, p := range c.Probes.UDP {
+		field := fmt.Sprintf("probes.udp[%d]", i)
+		errs = append(errs, validateProbeName(names, field, p.Name)...)
+		errs = append(errs, validateProbeAddress(field, p.Address)...)
+		errs = append(errs, validateProbeRegex(field+".expect", p.Expect)...)
+		errs = append(errs, validateProbeTimeout(field, p.Timeout)...)
+		if p.Send == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".send",
+				Message: "is required",
+			})
+		}
+	}
+
+	for i, p := range c.Probes.DNS {
+		field := fmt.Sprintf("probes.dns[%d]", i)
+		errs = append(errs, validateProbeName(names, field, p.Name)...)
+		errs = append(errs, validateProbeTimeout(field, p.Timeout)...)
+		if p.Query == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".query",
+				Message: "is required",
+			})
+		}
+		switch strings.ToUpper(p.Type) {
+		case "", "A", "AAAA", "CNAME", "MX", "NS", "TXT", "PTR":
+		default:
+			errs = append(errs, ValidationError{
+				Field:   field + ".type",
+				Message: fmt.Sprintf("must be A, AAAA, CNAME, MX, NS, TXT or PTR, got %q", p.Type),
+			})
+		}
+		switch p.Network {
+		case "", "udp", "tcp":
+		default:
+			errs = append(errs, ValidationError{
+				Field:   field + ".network",
+				Message: fmt.Sprintf("must be \"udp\" or \"tcp\", got %q", p.Network),
+			})
+		}
+	}
+
+	return errs
+}
+
+func validateProbeName(names map[string]bool, field, name string) ValidationErrors {
+	if name == "" {
+		return nil
+	}
+	if names[name] {
+		return ValidationErrors{{
+			Field:   field + ".name",
+			Message: fmt.Sprintf("duplicate probe name %q", name),
+		}}
+	}
+	names[name] = true
+	return nil
+}
+
+func validateProbeAddress(field, addr string) ValidationErrors {
+	if addr == "" {
+		return ValidationErrors{{Field: field + ".address", Message: "is required"}}
+	}
+	if _, port, err := net.SplitHostPort(addr); err != nil || port == "" {
+		return ValidationErrors{{
+			Field:   field + ".address",
+			Message: fmt.Sprintf("must be host:port, got %q", addr),
+		}}
+	}
+	return nil
+}
+
+func validateProbeRegex(field, expr string) ValidationErrors {
+	if expr == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(expr); err != nil {
+		return ValidationErrors{{Field: field, Message: err.Error()}}
+	}
+	return nil
+}
+
+func validateProbeTimeout(field string, timeout Duration) ValidationErrors {
+	if timeout.Duration < 0 {
+		return ValidationErrors{{Field: field + ".timeout", Message: "must not be negative"}}
+	}
+	return nil
+}
+
+func (c *Config) validateScrape() ValidationErrors {
+	var errs ValidationErrors
+
+	if c.Scrape.Timeout.Duration <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "scrape.timeout",
+			Message: "must be positive",
+		})
+	}
+
+	names := make(map[string]bool)
+	for i, t := range c.Scrape.Targets {
+		field := fmt.Sprintf("scrape.targets[%d]", i)
+		if t.URL == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".url",
+				Message: "is required",
+			})
+		} else if u, err := url.Parse(t.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".url",
+				Message: fmt.Sprintf("must be an absolute http or https URL, got %q", t.URL),
+			})
+		}
+		// The same URL may be scraped under different job names.
+		key := t.Name + " " + t.URL
+		if t.URL != "" && names[key] {
+			errs = append(errs, ValidationError{
+				Field:   field + ".url",
+				Message: fmt.Sprintf("duplicate target %q", t.URL),
+			})
+		}
+		names[key] = true
+		if t.Timeout.Duration < 0 {
+			errs = append(errs, ValidationError{
+				Field:   field + ".timeout",
+				Message: "must not be negative",
+			})
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateStatsD() ValidationErrors {
+	var errs ValidationErrors
+
+	if !c.StatsD.Enabled {
+		return errs
+	}
+
+	if c.StatsD.Addr == "" {
+		errs = append(errs, ValidationError{
+			Field:   "statsd.addr",
+			Message: "is required when StatsD is enabled",
+		})
+	} else if _, _, err := net.SplitHostPort(c.StatsD.Addr); err != nil {
+		errs = append(errs, ValidationError{
+			Field:   "statsd.addr",
+			Message: fmt.Sprintf("must be host:port, got %q", c.StatsD.Addr),
+		})
+	}
+
+	if c.StatsD.FlushInterval.Duration <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "statsd.flush_interval",
+			Message: "must be positive",
+		})
+	}
+
+	for _, p := range c.StatsD.Percentiles {
+		if p <= 0 || p >= 100 {
+			errs = append(errs, ValidationError{
+				Field:   "statsd.percentiles",
+				Message: fmt.Sprintf("must be between 0 and 100 exclusive, got %v", p),
+			})
+		}
+	}
+
+	if c.StatsD.MaxSamples < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "statsd.max_samples",
+			Message: "must not be negative",
+		})
+	}
+
+	return errs
+}
+
+func (c *Config) validateInfluxListener() ValidationErrors {
+	var errs ValidationErrors
+
+	l := c.InfluxListener
+	if !l.Enabled {
+		return errs
+	}
+
+	if l.Addr == "" && l.UDPAddr == "" {
+		errs = append(errs, ValidationError{
+			Field:   "influx_listener.addr",
+			Message: "addr or udp_addr is required when the listener is enabled",
+		})
+	}
+	for _, a := range []struct{ field, addr string }{{"addr", l.Addr}, {"udp_addr", l.UDPAddr}} {
+		if a.addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(a.addr); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   "influx_listener." + a.field,
+				Message: fmt.Sprintf("must be host:port, got %q", a.addr),
+			})
+		}
+	}
+
+	if l.UDPAddr != "" && !l.UDPPrecision.Valid() {
+		errs = append(errs, ValidationError{
+			Field:   "influx_listener.udp_precision",
+			Message: fmt.Sprintf("must be one of: h, m, s, ms, us, ns, got %q", l.UDPPrecision),
+		})
+	}
+
+	if l.MaxBodySize <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "influx_listener.max_body_size",
+			Message: "must be positive",
+		})
+	}
+
+	return errs
+}
+
+func (c *Config) validateProcessors() ValidationErrors {
+	var errs ValidationErrors
+	p := c.Processors
+	required := func(field string) {
+		errs = append(errs, ValidationError{Field: field, Message: "is required"})
+	}
+
+	for i, r := range p.Rename {
+		field := fmt.Sprintf("processors.rename[%d]", i)
+		errs = append(errs, validateProcessorScope(field, r.ProcessorScope)...)
+		if len(r.Measurement)+len(r.Tags)+len(r.Fields) == 0 {
+			errs = append(errs, ValidationError{
+				Field:   field,
+				Message: "must rename a measurement, tag or field",
+			})
+		}
+		for _, renames := range []struct {
+			key string
+			m   map[string]string
+		}{{"measurement", r.Measurement}, {"tags", r.Tags}, {"fields", r.Fields}} {
+			froms := make([]string, 0, len(renames.m))
+			for from := range renames.m {
+				froms = append(froms, from)
+			}
+			sort.Strings(froms)
+			for _, from := range froms {
+				if renames.m[from] == "" {
+					errs = append(errs, ValidationError{
+						Field:   field + "." + renames.key,
+						Message: fmt.Sprintf("new name for %q must not be empty", from),
+					})
+				}
+			}
+		}
+	}
+
+	for i, t := range p.Tags {
+		field := fmt.Sprintf("processors.tags[%d]", i)
+		errs = append(errs, validateProcessorScope(field, t.ProcessorScope)...)
+		errs = append(errs, validateGlobs(field+".drop", t.Drop)...)
+		if len(t.Add)+len(t.Drop) == 0 {
+			errs = append(errs, ValidationError{
+				Field:   field,
+				Message: "must add or drop tags",
+			})
+		}
+	}
+
+	for i, f := range p.Fields {
+		field := fmt.Sprintf("processors.fields[%d]", i)
+		errs = append(errs, validateProcessorScope(field, f.ProcessorScope)...)
+		errs = append(errs, validateGlobs(field+".drop", f.Drop)...)
+		if len(f.Add)+len(f.Drop) == 0 {
+			errs = append(errs, ValidationError{
+				Field:   field,
+				Message: "must add or drop fields",
+			})
+		}
+	}
+
+	for i, r := range p.Regex {
+		field := fmt.Sprintf("processors.regex[%d]", i)
+		errs = append(errs, validateProcessorScope(field, r.ProcessorScope)...)
+		if r.Tag == "" {
+			required(field + ".tag")
+		}
+		if r.Pattern == "" {
+			required(field + ".pattern")
+		} else if _, err := regexp.Compile(r.Pattern); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   field + ".pattern",
+				Message: fmt.Sprintf("invalid regular expression: %v", err),
+			})
+		}
+	}
+
+	for i, cv := range p.Convert {
+		field := fmt.Sprintf("processors.convert[%d]", i)
+		errs = append(errs, validateProcessorScope(field, cv.ProcessorScope)...)
+		if len(cv.Fields) == 0 {
+			required(field + ".fields")
+		}
+		errs = append(errs, validateGlobs(field+".fields", cv.Fields)...)
+		if !cv.Type.Valid() {
+			errs = append(errs, ValidationError{
+				Field:   field + ".type",
+				Message: fmt.Sprintf("must be one of: integer, float, string, boolean, got %q", cv.Type),
+			})
+		}
+	}
+
+	for i, sc := range p.Scale {
+		field := fmt.Sprintf("processors.scale[%d]", i)
+		errs = append(errs, validateProcessorScope(field, sc.ProcessorScope)...)
+		if len(sc.Fields) == 0 {
+			required(field + ".fields")
+		}
+		errs = append(errs, validateGlobs(field+".fields", sc.Fields)...)
+		if sc.Factor == 0 {
+			errs = append(errs, ValidationError{
+				Field:   field + ".factor",
+				Message: "must not be zero",
+			})
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateFilters() ValidationErrors {
+	var errs ValidationErrors
+
+	backends := make([]string, 0, len(c.Filters))
+	for name := range c.Filters {
+		backends = append(backends, name)
+	}
+	sort.Strings(backends)
+
+	for _, name := range backends {
+		f := c.Filters[name]
+		field := "filters." + name
+		errs = append(errs, validateGlobs(field+".include_measurements", f.IncludeMeasurements)...)
+		errs = append(errs, validateGlobs(field+".exclude_measurements", f.ExcludeMeasurements)...)
+		errs = append(errs, validateGlobs(field+".include_fields", f.IncludeFields)...)
+		errs = append(errs, validateGlobs(field+".exclude_fields", f.ExcludeFields)...)
+		for _, rule := range []struct {
+			key  string
+			tags map[string][]string
+		}{{"include_tags", f.IncludeTags}, {"exclude_tags", f.ExcludeTags}} {
+			keys := make([]string, 0, len(rule.tags))
+			for k := range rule.tags {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if len(rule.tags[k]) == 0 {
+					errs = append(errs, ValidationError{
+						Field:   field + "." + rule.key,
+						Message: fmt.Sprintf("tag %q needs at least one pattern", k),
+					})
+				}
+				errs = append(errs, validateGlobs(field+"."+rule.key, rule.tags[k])...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func validateProcessorScope(field string, scope ProcessorScope) ValidationErrors {
+	errs := validateGlobs(field+".measurements", scope.Measurements)
+	for _, name := range scope.Backends {
+		if name == "" {
+			errs = append(errs, ValidationError{
+				Field:   field + ".backends",
+				Message: "backend names must not be empty",
+			})
+		}
+	}
+	return errs
+}
+
+// validateGlobs checks that every pattern is a valid path.Match pattern.
+func validateGlobs(field string, patterns []string) ValidationErrors {
+	var errs ValidationErrors
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid pattern %q", p),
+			})
+		}
+	}
+	return errs
+}
+
+func (c *Config) validateSpool() ValidationErrors {
+	var errs ValidationErrors
+
+	if !c.Spool.Enabled {
+		return errs
+	}
+
+	if c.Spool.Dir == "" {
+		errs = append(errs, ValidationError{
+			Field:   "spool.dir",
+			Message: "required when the spool is enabled",
+		})
+	}
+
+	if c.Spool.MaxSize < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "spool.max_size",
+			Message: "must not be negative",
+		})
+	}
+
+	if c.Spool.MaxAge.Duration < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "spool.max_age",
+			Message: "must not be negative",
+		})
+	}
+
+	return errs
+}
+
+func (c *Config) validateCollectors() ValidationErrors {
+	var errs ValidationErrors
+
+	names := make([]string, 0, len(c.Collectors))
+	for name := range c.Collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cc := c.Collectors[name]
+		prefix := "collectors." + name
+
+		if cc.PollInterval.Duration < 0 {
+			errs = append(errs, ValidationError{
+				Field:   prefix + ".poll_interval",
+				Message: "must not be negative",
+			})
+		}
+
+		if cc.CollectTimeout.Duration < 0 {
+			errs = append(errs, ValidationError{
+				Field:   prefix + ".collect_timeout",
+				Message: "must not be negative",
+			})
+		}
+
+		if cc.OverlapPolicy != "" && !cc.OverlapPolicy.Valid() {
+			errs = append(errs, ValidationError{
+				Field:   prefix + ".overlap_policy",
+				Message: "must be one of: skip, queue, cancel",
+			})
+		}
+	}
+
+	return errs
+}